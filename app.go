@@ -8,10 +8,13 @@ import (
 	"github.com/cosmos/cosmos-sdk/types/module"
 	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/crisis"
 	"github.com/cosmos/cosmos-sdk/x/distribution"
 	"github.com/cosmos/cosmos-sdk/x/genaccounts"
 	"github.com/cosmos/cosmos-sdk/x/genutil"
+	"github.com/cosmos/cosmos-sdk/x/gov"
 	"github.com/cosmos/cosmos-sdk/x/params"
+	paramsclient "github.com/cosmos/cosmos-sdk/x/params/client"
 	"github.com/cosmos/cosmos-sdk/x/slashing"
 	"github.com/cosmos/cosmos-sdk/x/staking"
 	"github.com/cosmos/cosmos-sdk/x/supply"
@@ -26,6 +29,7 @@ import (
 	dbm "github.com/tendermint/tm-db"
 
 	"github.com/corestario/HERB/x/herb"
+	herbclient "github.com/corestario/HERB/x/herb/client"
 )
 
 const appName = "herb"
@@ -50,6 +54,8 @@ var (
 		distribution.AppModuleBasic{},
 		slashing.AppModuleBasic{},
 		supply.AppModuleBasic{},
+		crisis.AppModuleBasic{},
+		gov.NewAppModuleBasic(paramsclient.ProposalHandler, herbclient.ProposalHandler, herbclient.InstanceParamsProposalHandler),
 
 		herb.AppModule{},
 	)
@@ -60,6 +66,9 @@ var (
 		distribution.ModuleName:   nil,
 		staking.BondedPoolName:    {supply.Burner, supply.Staking},
 		staking.NotBondedPoolName: {supply.Burner, supply.Staking},
+		gov.ModuleName:            {supply.Burner},
+		herb.ModuleName:           nil,
+		herb.ProviderBondPoolName: nil,
 	}
 )
 
@@ -67,6 +76,8 @@ type herbApp struct {
 	*bam.BaseApp
 	cdc *codec.Codec
 
+	invCheckPeriod uint
+
 	// Keys to access the substores
 	keyMain      *sdk.KVStoreKey
 	keyAccount   *sdk.KVStoreKey
@@ -75,11 +86,12 @@ type herbApp struct {
 	tkeyStaking  *sdk.TransientStoreKey
 	keyDistr     *sdk.KVStoreKey
 	keyHERB      *sdk.KVStoreKey
-	keyCtShares   *sdk.KVStoreKey
+	keyCtShares  *sdk.KVStoreKey
 	keyDecShares *sdk.KVStoreKey
 	keyParams    *sdk.KVStoreKey
 	tkeyParams   *sdk.TransientStoreKey
 	keySlashing  *sdk.KVStoreKey
+	keyGov       *sdk.KVStoreKey
 
 	// Keepers
 	accountKeeper  auth.AccountKeeper
@@ -88,7 +100,9 @@ type herbApp struct {
 	slashingKeeper slashing.Keeper
 	distrKeeper    distribution.Keeper
 	supplyKeeper   supply.Keeper
+	crisisKeeper   crisis.Keeper
 	paramsKeeper   params.Keeper
+	govKeeper      gov.Keeper
 	herbKeeper     herb.Keeper
 
 	// Module Manager
@@ -96,15 +110,17 @@ type herbApp struct {
 }
 
 // NewHERBApp is a constructor for HERB application
-func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
+// invCheckPeriod is the number of blocks between the invariants checks, 0 checks them only on demand
+func NewHERBApp(logger log.Logger, db dbm.DB, invCheckPeriod uint) *herbApp {
 	cdc := MakeCodec()
 
 	// BaseApp handles interactions with Tendermint through the ABCI protocol
 	bApp := bam.NewBaseApp(appName, logger, db, auth.DefaultTxDecoder(cdc))
 
 	var app = &herbApp{
-		BaseApp: bApp,
-		cdc:     cdc,
+		BaseApp:        bApp,
+		cdc:            cdc,
+		invCheckPeriod: invCheckPeriod,
 
 		keyMain:      sdk.NewKVStoreKey(bam.MainStoreKey),
 		keyAccount:   sdk.NewKVStoreKey(auth.StoreKey),
@@ -113,11 +129,12 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 		tkeyStaking:  sdk.NewTransientStoreKey(staking.TStoreKey),
 		keyDistr:     sdk.NewKVStoreKey(distribution.StoreKey),
 		keyHERB:      sdk.NewKVStoreKey(herb.StoreKey),
-		keyCtShares:   sdk.NewKVStoreKey(herb.CtStoreKey),
+		keyCtShares:  sdk.NewKVStoreKey(herb.CtStoreKey),
 		keyDecShares: sdk.NewKVStoreKey(herb.DsStoreKey),
 		keyParams:    sdk.NewKVStoreKey(params.StoreKey),
 		tkeyParams:   sdk.NewTransientStoreKey(params.TStoreKey),
 		keySlashing:  sdk.NewKVStoreKey(slashing.StoreKey),
+		keyGov:       sdk.NewKVStoreKey(gov.StoreKey),
 	}
 
 	// The ParamsKeeper handles parameter storage for the application
@@ -128,6 +145,9 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 	stakingSubspace := app.paramsKeeper.Subspace(staking.DefaultParamspace)
 	slashingSubspace := app.paramsKeeper.Subspace(slashing.DefaultParamspace)
 	distrSubspace := app.paramsKeeper.Subspace(distribution.DefaultParamspace)
+	govSubspace := app.paramsKeeper.Subspace(gov.DefaultParamspace)
+	herbSubspace := app.paramsKeeper.Subspace(herb.DefaultParamspace)
+	crisisSubspace := app.paramsKeeper.Subspace(crisis.DefaultParamspace)
 
 	app.accountKeeper = auth.NewAccountKeeper(
 		app.cdc,
@@ -152,6 +172,13 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 		maccPerms,
 	)
 
+	app.crisisKeeper = crisis.NewKeeper(
+		crisisSubspace,
+		invCheckPeriod,
+		app.supplyKeeper,
+		auth.FeeCollectorName,
+	)
+
 	stakingKeeper := staking.NewKeeper(
 		app.cdc,
 		app.keyStaking,
@@ -192,23 +219,48 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 		app.keyHERB,
 		app.keyCtShares,
 		app.keyDecShares,
+		app.stakingKeeper,
+		app.slashingKeeper,
+		app.supplyKeeper,
+		app.bankKeeper,
+		herbSubspace,
 		app.cdc,
 	)
 
+	// register the proposal types
+	// key holders set is changed by the governance proposal
+	govRouter := gov.NewRouter()
+	govRouter.AddRoute(gov.RouterKey, gov.ProposalHandler).
+		AddRoute(params.RouterKey, params.NewParamChangeProposalHandler(app.paramsKeeper)).
+		AddRoute(herb.RouterKey, herb.NewKeyHoldersChangeProposalHandler(app.herbKeeper))
+
+	app.govKeeper = gov.NewKeeper(
+		app.cdc,
+		app.keyGov,
+		app.paramsKeeper,
+		govSubspace,
+		app.supplyKeeper,
+		&stakingKeeper,
+		gov.DefaultCodespace,
+		govRouter,
+	)
+
 	app.mm = module.NewManager(
 		genaccounts.NewAppModule(app.accountKeeper),
 		genutil.NewAppModule(app.accountKeeper, app.stakingKeeper, app.BaseApp.DeliverTx),
 		auth.NewAppModule(app.accountKeeper),
 		bank.NewAppModule(app.bankKeeper, app.accountKeeper),
+		crisis.NewAppModule(&app.crisisKeeper),
 		herb.NewAppModule(app.herbKeeper),
+		gov.NewAppModule(app.govKeeper, app.supplyKeeper),
 		supply.NewAppModule(app.supplyKeeper, app.accountKeeper),
 		distribution.NewAppModule(app.distrKeeper, app.supplyKeeper),
 		slashing.NewAppModule(app.slashingKeeper, app.stakingKeeper),
 		staking.NewAppModule(app.stakingKeeper, app.distrKeeper, app.accountKeeper, app.supplyKeeper),
 	)
 
-	app.mm.SetOrderBeginBlockers(distribution.ModuleName, slashing.ModuleName)
-	app.mm.SetOrderEndBlockers(staking.ModuleName)
+	app.mm.SetOrderBeginBlockers(distribution.ModuleName, slashing.ModuleName, herb.ModuleName)
+	app.mm.SetOrderEndBlockers(crisis.ModuleName, gov.ModuleName, staking.ModuleName, herb.ModuleName)
 
 	app.mm.SetOrderInitGenesis(
 		genaccounts.ModuleName,
@@ -217,11 +269,16 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 		auth.ModuleName,
 		bank.ModuleName,
 		slashing.ModuleName,
+		gov.ModuleName,
 		herb.ModuleName,
 		supply.ModuleName,
+		crisis.ModuleName,
 		genutil.ModuleName,
 	)
 
+	// invariants are asserted every invCheckPeriod blocks and by the crisis module messages
+	app.mm.RegisterInvariants(&app.crisisKeeper)
+
 	// register all module routes and module queriers
 	app.mm.RegisterRoutes(app.Router(), app.QueryRouter())
 
@@ -231,11 +288,15 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 	app.SetEndBlocker(app.EndBlocker)
 
 	// The AnteHandler handles signature verification and transaction pre-processing
+	// herb shares for the stale rounds are rejected in CheckTx before the signatures are verified
 	app.SetAnteHandler(
-		auth.NewAnteHandler(
-			app.accountKeeper,
-			app.supplyKeeper,
-			auth.DefaultSigVerificationGasConsumer,
+		herb.NewAnteHandler(
+			app.herbKeeper,
+			auth.NewAnteHandler(
+				app.accountKeeper,
+				app.supplyKeeper,
+				auth.DefaultSigVerificationGasConsumer,
+			),
 		),
 	)
 
@@ -247,6 +308,7 @@ func NewHERBApp(logger log.Logger, db dbm.DB) *herbApp {
 		app.tkeyStaking,
 		app.keyDistr,
 		app.keySlashing,
+		app.keyGov,
 		app.keyHERB,
 		app.keyCtShares,
 		app.keyDecShares,
@@ -25,7 +25,10 @@ func main() {
 		Short: "Distributed Key Generation simulator for HERB",
 	}
 
-	rootCmd.AddCommand(generateKeyFile(app.DefaultDKGHome))
+	rootCmd.AddCommand(
+		generateKeyFile(app.DefaultDKGHome),
+		generateDKGKey(),
+	)
 
 	// prepare and add flags
 	executor := cli.PrepareBaseCmd(rootCmd, "HERB", app.DefaultDKGHome)
@@ -113,6 +116,40 @@ func generateKeyFile(defaultDKGHome string) *cobra.Command {
 	}
 }
 
+func generateDKGKey() *cobra.Command {
+	return &cobra.Command{
+		Use:   "gen-dkg-key",
+		Short: "generates long-term key pair for the on-chain distributed key generation",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			group := herb.P256
+			privateKey := group.Scalar().Pick(group.RandomStream())
+			publicKey := group.Point().Mul(privateKey, nil)
+
+			privateKeyHex, err := kyberenc.ScalarToStringHex(group, privateKey)
+			if err != nil {
+				return fmt.Errorf("private key serialization failed: %v", err)
+			}
+			publicKeyHex, err := kyberenc.PointToStringHex(group, publicKey)
+			if err != nil {
+				return fmt.Errorf("public key serialization failed: %v", err)
+			}
+
+			resJSON, err := json.Marshal(dkgKeyPair{PrivateKey: privateKeyHex, PublicKey: publicKeyHex})
+			if err != nil {
+				return fmt.Errorf("results marshalling failed: %v", err)
+			}
+			fmt.Println(string(resJSON))
+			return nil
+		},
+	}
+}
+
+type dkgKeyPair struct {
+	PrivateKey string `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+}
+
 type keyGenResult struct {
 	CommonKey   string          `json:"common_key"`
 	PartialKeys []keyHolderJSON `json:"partial_keys"`
@@ -24,6 +24,10 @@ import (
 	herbcli "github.com/corestario/HERB/x/herb/client/cli"
 )
 
+const flagInvCheckPeriod = "inv-check-period"
+
+var invCheckPeriod uint
+
 func main() {
 	defer func() {
 		if r := recover(); r != nil {
@@ -59,9 +63,12 @@ func main() {
 		herbcli.SetThresholdsCmd(ctx, cdc),
 		herbcli.AddKeyHolderCmd(ctx, cdc),
 		herbcli.SetCommonPublicKeyCmd(ctx, cdc),
+		herbcli.AddDKGParticipantCmd(ctx, cdc),
 	)
 
 	server.AddCommands(ctx, cdc, rootCmd, newApp, exportAppStateAndTMValidators)
+	rootCmd.PersistentFlags().UintVar(&invCheckPeriod, flagInvCheckPeriod,
+		0, "Assert registered invariants every N blocks")
 
 	// prepare and add flags
 	executor := cli.PrepareBaseCmd(rootCmd, "HERB", app.DefaultNodeHome)
@@ -72,7 +79,7 @@ func main() {
 }
 
 func newApp(logger tlog.Logger, db dbm.DB, traceStore io.Writer) abci.Application {
-	return app.NewHERBApp(logger, db)
+	return app.NewHERBApp(logger, db, invCheckPeriod)
 }
 
 func exportAppStateAndTMValidators(
@@ -80,7 +87,7 @@ func exportAppStateAndTMValidators(
 ) (json.RawMessage, []tmtypes.GenesisValidator, error) {
 
 	if height != -1 {
-		hApp := app.NewHERBApp(logger, db)
+		hApp := app.NewHERBApp(logger, db, uint(1))
 		err := hApp.LoadHeight(height)
 		if err != nil {
 			return nil, nil, err
@@ -88,7 +95,7 @@ func exportAppStateAndTMValidators(
 		return hApp.ExportAppStateAndValidators(forZeroHeight, jailWhiteList)
 	}
 
-	hApp := app.NewHERBApp(logger, db)
+	hApp := app.NewHERBApp(logger, db, uint(1))
 
 	return hApp.ExportAppStateAndValidators(forZeroHeight, jailWhiteList)
 }
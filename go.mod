@@ -12,4 +12,5 @@ require (
 	github.com/tendermint/tendermint v0.32.2
 	github.com/tendermint/tm-db v0.1.1
 	go.dedis.ch/kyber/v3 v3.0.3
+	go.dedis.ch/protobuf v1.0.5
 )
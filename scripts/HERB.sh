@@ -29,7 +29,7 @@ do
     stage=$(hcli query herb stage)
   done
 
-  while !  hcli tx herb decrypt $2 $3 -y --from $user> /dev/null
+  while !  hcli tx herb decrypt $2 -y --from $user> /dev/null
   do
     sleep $sleeptime
 
@@ -0,0 +1,88 @@
+package herb
+
+import (
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// BeginBlocker migrates the store to the current layout before the block transactions
+func BeginBlocker(ctx sdk.Context, k Keeper) {
+	k.migrateStore(ctx)
+}
+
+// EndBlocker funds the rewards pool and runs the end block of each beacon instance
+func EndBlocker(ctx sdk.Context, k Keeper) {
+	k.collectRewardFees(ctx)
+	for _, id := range k.GetInstances(ctx) {
+		endBlockInstance(ctx, k.Instance(id))
+	}
+}
+
+// endBlockInstance prunes old rounds of the instance,
+// moves the DKG or the key refresh to the next phase and aborts the rounds collecting shares if their deadlines have passed.
+// Rounds keep running on the current keys while the key holders change DKG is running
+func endBlockInstance(ctx sdk.Context, k Keeper) {
+	k.pruneRounds(ctx)
+	if k.dkgRunning(ctx) {
+		phase := k.GetDKGPhase(ctx)
+		if ctx.BlockHeight()-k.dkgPhaseHeight(ctx) >= k.GetParams(ctx).DKGPhaseDeadline {
+			k.advanceDKGPhase(ctx)
+			ctx.Logger().Info(fmt.Sprintf("herb%s DKG phase %s deadline exceeded, new phase: %s", k.logInstance(), phase, k.GetDKGPhase(ctx)))
+		}
+		// there are no rounds before the initial DKG is completed
+		if !k.keysDefined(ctx) {
+			return
+		}
+	}
+	if phase := k.GetRefreshPhase(ctx); phase == types.RefreshPhaseDeals || phase == types.RefreshPhaseComplaints ||
+		phase == types.RefreshPhaseJustifications {
+		if ctx.BlockHeight()-k.refreshPhaseHeight(ctx) >= k.GetParams(ctx).DKGPhaseDeadline {
+			k.advanceRefreshPhase(ctx)
+			ctx.Logger().Info(fmt.Sprintf("herb%s key refresh phase %s deadline exceeded, new phase: %s", k.logInstance(), phase, k.GetRefreshPhase(ctx)))
+		}
+	}
+
+	// the idle round is left if the on-demand mode was switched off
+	k.advanceRounds(ctx)
+	params := k.GetParams(ctx)
+	if round, ok := k.DecryptingRound(ctx); ok && ctx.BlockHeight()-k.stageHeight(ctx, round) >= params.DecryptionDeadline {
+		shares, err := k.GetAllDecryptionShares(ctx, round)
+		if err != nil {
+			k.abortBrokenRound(ctx, round, fmt.Sprintf("can't get decryption shares: %v", err))
+		} else {
+			reason := fmt.Sprintf("decryption shares collecting deadline (%d blocks) exceeded: %d shares received", params.DecryptionDeadline, len(shares))
+			k.AbortRound(ctx, round, reason)
+			ctx.Logger().Info(fmt.Sprintf("herb%s round %d failed: %s", k.logInstance(), round, reason))
+		}
+	}
+
+	// the round with enough ciphertext shares isn't aborted while it waits for the decryption of the previous one
+	round := k.CurrentRound(ctx)
+	if k.GetStage(ctx, round) != stageCtCollecting || ctx.BlockHeight()-k.stageHeight(ctx, round) < params.CiphertextDeadline {
+		return
+	}
+	t, err := k.GetThresholdCiphertexts(ctx)
+	if err != nil {
+		k.abortBrokenRound(ctx, round, fmt.Sprintf("can't get ciphertext shares threshold: %v", err))
+		return
+	}
+	cts, err := k.GetAllCiphertexts(ctx, round)
+	if err != nil {
+		k.abortBrokenRound(ctx, round, fmt.Sprintf("can't get ciphertext shares: %v", err))
+		return
+	}
+	if uint64(len(cts)) >= t {
+		return
+	}
+	reason := fmt.Sprintf("ciphertext collecting deadline (%d blocks) exceeded: %d shares received", params.CiphertextDeadline, len(cts))
+	k.AbortRound(ctx, round, reason)
+	ctx.Logger().Info(fmt.Sprintf("herb%s round %d failed: %s", k.logInstance(), round, reason))
+}
+
+// abortBrokenRound aborts the round whose state can't be read, the error doesn't halt the chain or the other instances
+func (k *Keeper) abortBrokenRound(ctx sdk.Context, round uint64, reason string) {
+	ctx.Logger().Error(fmt.Sprintf("herb%s round %d: %s", k.logInstance(), round, reason))
+	k.AbortRound(ctx, round, reason)
+}
@@ -10,21 +10,61 @@ const (
 	StoreKey   = types.StoreKey
 	CtStoreKey = types.CtStoreKey
 	DsStoreKey = types.DsStoreKey
+
+	ProviderBondPoolName = types.ProviderBondPoolName
+
+	DefaultParamspace = types.DefaultParamspace
+
+	DefaultInstance = types.DefaultInstance
 )
 
 var (
 	NewMsgSetCiphertextShare = types.NewMsgSetCiphertextShare
-	ModuleCdc               = types.ModuleCdc
-	RegisterCodec           = types.RegisterCodec
-	P256                    = types.P256
+	ModuleCdc                = types.ModuleCdc
+	RegisterCodec            = types.RegisterCodec
+	P256                     = types.P256
+	DefaultParams            = types.DefaultParams
+	NewMultiHerbHooks        = types.NewMultiHerbHooks
+	DeriveRandomness         = types.DeriveRandomness
+	ErrRoundNotCompleted     = types.ErrRoundNotCompleted
+	ErrRoundPruned           = types.ErrRoundPruned
+	ErrUnknownInstance       = types.ErrUnknownInstance
 )
 
 type (
-	MsgSetCiphertextShare  = types.MsgSetCiphertextShare
+	MsgSetCiphertextShare = types.MsgSetCiphertextShare
 	MsgSetDecryptionShare = types.MsgSetDecryptionShare
-	CiphertextShare        = types.CiphertextShare
-	CiphertextShareJSON    = types.CiphertextShareJSON
+	CiphertextShare       = types.CiphertextShare
+	CiphertextShareJSON   = types.CiphertextShareJSON
 	DecryptionShare       = types.DecryptionShare
 	DecryptionShareJSON   = types.DecryptionShareJSON
 	GenesisState          = types.GenesisState
+	Params                = types.Params
+
+	MsgDKGDeal               = types.MsgDKGDeal
+	MsgDKGResponse           = types.MsgDKGResponse
+	MsgDKGJustification      = types.MsgDKGJustification
+	MsgDKGSecretCommits      = types.MsgDKGSecretCommits
+	MsgDKGComplaintCommits   = types.MsgDKGComplaintCommits
+	MsgDKGReconstructCommits = types.MsgDKGReconstructCommits
+	MsgStartRefresh          = types.MsgStartRefresh
+	MsgRefreshDeal           = types.MsgRefreshDeal
+	MsgRefreshComplaint      = types.MsgRefreshComplaint
+	MsgRefreshJustification  = types.MsgRefreshJustification
+	MsgSetEncryptionKey      = types.MsgSetEncryptionKey
+
+	MsgRegisterEntropyProvider   = types.MsgRegisterEntropyProvider
+	MsgDeregisterEntropyProvider = types.MsgDeregisterEntropyProvider
+	EntropyProvider              = types.EntropyProvider
+
+	MsgRequestRandomness = types.MsgRequestRandomness
+	RandomnessRequest    = types.RandomnessRequest
+
+	HerbHooks      = types.HerbHooks
+	MultiHerbHooks = types.MultiHerbHooks
+
+	RandomnessKeeper = types.RandomnessKeeper
+	RoundTranscript  = types.RoundTranscript
+
+	InstanceGenesisState = types.InstanceGenesisState
 )
@@ -0,0 +1,43 @@
+package herb
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines the ante handler checking rounds of the share messages. Message handlers don't run in CheckTx,
+//so the share for the finished round would stay in the mempool and fail only in the block. The round check is cheap
+//and runs before the proofs are verified, it's also repeated on the mempool recheck after every block
+
+// NewAnteHandler returns the ante handler rejecting share messages for the rounds which don't collect such shares
+// in CheckTx, other messages and DeliverTx are passed to the next ante handler
+func NewAnteHandler(k Keeper, next sdk.AnteHandler) sdk.AnteHandler {
+	return func(ctx sdk.Context, tx sdk.Tx, simulate bool) (sdk.Context, sdk.Result, bool) {
+		if ctx.IsCheckTx() {
+			for _, msg := range tx.GetMsgs() {
+				if err := checkMsgRound(ctx, k, msg); err != nil {
+					return ctx, err.Result(), true
+				}
+			}
+		}
+		return next(ctx, tx, simulate)
+	}
+}
+
+// checkMsgRound checks the round of the share message against the state of its beacon instance
+func checkMsgRound(ctx sdk.Context, k Keeper, msg sdk.Msg) sdk.Error {
+	switch msg := msg.(type) {
+	case MsgSetCiphertextShare:
+		keeper, err := k.instanceKeeper(ctx, msg.Instance)
+		if err != nil {
+			return err
+		}
+		return keeper.checkCiphertextRound(ctx, msg.Round)
+	case MsgSetDecryptionShare:
+		keeper, err := k.instanceKeeper(ctx, msg.Instance)
+		if err != nil {
+			return err
+		}
+		return keeper.checkDecryptionRound(ctx, msg.Round)
+	}
+	return nil
+}
@@ -0,0 +1,404 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/corestario/HERB/x/herb/types"
+
+	"go.dedis.ch/kyber/v3"
+	rabin "go.dedis.ch/kyber/v3/share/dkg/rabin"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+)
+
+const flagPollInterval = "poll-interval"
+
+// GetCmdDKGPhase implements the query DKG phase command.
+func GetCmdDKGPhase(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "dkg-phase",
+		Short: "returns current phase of the distributed key generation",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var out types.QueryDKGPhaseRes
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryDKGPhase, nil, &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
+
+// GetCmdDKGParticipants implements the query DKG participants command.
+func GetCmdDKGParticipants(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "dkg-participants",
+		Short: "returns participants of the distributed key generation and the key threshold",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var out types.QueryDKGParticipantsRes
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryDKGParticipants, nil, &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
+
+// GetCmdDKGRun implements the command which takes part in the on-chain distributed key generation.
+// DistKeyGenerator state is kept in memory, so the command must run until the DKG is completed.
+// The state holds the participant's secret polynomial and the received deals, it can't be serialized by kyber,
+// so it isn't persisted: a restarted command can't rejoin the running DKG.
+func GetCmdDKGRun(cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dkg-run [longterm-private-key]",
+		Short: "take part in the distributed key generation and print the resulting key share",
+		Long: `Take part in the on-chain distributed key generation.
+The long-term key must correspond to the public key registered for the sender's address in the genesis file.
+The command sends DKG messages as the phases change and prints the private key share and ID
+for the "decrypt" command when the DKG is completed.
+The DKG state is kept in memory only: if the command is stopped before the DKG is completed it can't rejoin,
+the participant is left out of QUAL or the DKG fails at the phase deadlines.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			longterm, err := kyberenc.StringHexToScalar(types.P256, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to decode long-term private key: %v", err)
+			}
+
+			var participantsRes types.QueryDKGParticipantsRes
+			if err := queryDKG(cliCtx, cdc, types.QuerierRouter, types.QueryDKGParticipants, nil, &participantsRes); err != nil {
+				return err
+			}
+			participants, err := types.DKGParticipantArrayDeserialize(participantsRes.Participants)
+			if err != nil {
+				return err
+			}
+			index := -1
+			pubKeys := make([]kyber.Point, len(participants))
+			for i, p := range participants {
+				pubKeys[i] = p.PubKey
+				if p.Address.Equals(cliCtx.GetFromAddress()) {
+					index = i
+				}
+			}
+			if index < 0 {
+				return fmt.Errorf("%v isn't a DKG participant", cliCtx.GetFromAddress())
+			}
+			if !pubKeys[index].Equal(types.P256.Point().Mul(longterm, nil)) {
+				return fmt.Errorf("long-term key doesn't match the registered public key")
+			}
+
+			gen, err := rabin.NewDistKeyGenerator(types.P256, longterm, pubKeys, int(participantsRes.Threshold))
+			if err != nil {
+				return fmt.Errorf("failed to create key generator: %v", err)
+			}
+			r := &dkgRunner{cliCtx: cliCtx, cdc: cdc, gen: gen, index: uint32(index)}
+			return r.run(viper.GetDuration(flagPollInterval))
+		},
+	}
+	cmd.Flags().Duration(flagPollInterval, 5*time.Second, "interval between the DKG phase queries")
+	return cmd
+}
+
+// dkgRunner processes DKG messages from the chain and sends own messages
+type dkgRunner struct {
+	cliCtx context.CLIContext
+	cdc    *codec.Codec
+	gen    *rabin.DistKeyGenerator
+	index  uint32
+}
+
+// run processes DKG phases in order. Phases which were missed are processed without sending messages.
+func (r *dkgRunner) run(pollInterval time.Duration) error {
+	steps := []func(send bool) error{
+		r.deals,
+		r.responses,
+		r.justifications,
+		r.secretCommits,
+		r.complaintCommits,
+		r.reconstructCommits,
+		r.finish,
+	}
+	next := 0
+	for {
+		var phaseRes types.QueryDKGPhaseRes
+		if err := queryDKG(r.cliCtx, r.cdc, types.QuerierRouter, types.QueryDKGPhase, nil, &phaseRes); err != nil {
+			return err
+		}
+		if phaseRes.Phase == types.DKGPhaseFailed {
+			return fmt.Errorf("distributed key generation failed")
+		}
+		current := -1
+		for i, phase := range types.DKGPhases {
+			if phase == phaseRes.Phase {
+				current = i
+			}
+		}
+		for ; next <= current; next++ {
+			if err := steps[next](next == current); err != nil {
+				return fmt.Errorf("phase %v: %v", types.DKGPhases[next], err)
+			}
+		}
+		if next == len(steps) {
+			return nil
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func (r *dkgRunner) deals(send bool) error {
+	deals, err := r.gen.Deals()
+	if err != nil {
+		return err
+	}
+	dkgDeals := make([]types.DKGDeal, 0, len(deals))
+	for recipient, deal := range deals {
+		bz, err := types.EncodeDKGMessage(deal)
+		if err != nil {
+			return err
+		}
+		dkgDeals = append(dkgDeals, types.DKGDeal{Recipient: uint32(recipient), Data: bz})
+	}
+	if !send {
+		return nil
+	}
+	return r.broadcast(types.NewMsgDKGDeal(instanceFlag(), dkgDeals, r.cliCtx.GetFromAddress()))
+}
+
+func (r *dkgRunner) responses(send bool) error {
+	var dealsRes types.QueryDKGDealsRes
+	if err := queryDKG(r.cliCtx, r.cdc, types.QuerierRouter, types.QueryDKGDeals, types.QueryDKGDealsParams{Recipient: r.index}, &dealsRes); err != nil {
+		return err
+	}
+	var responses [][]byte
+	for _, bz := range dealsRes.Deals {
+		var deal rabin.Deal
+		if err := types.DecodeDKGMessage(bz, &deal); err != nil {
+			fmt.Printf("skipping invalid deal: %v\n", err)
+			continue
+		}
+		resp, err := r.gen.ProcessDeal(&deal)
+		if err != nil {
+			fmt.Printf("skipping deal from %v: %v\n", deal.Index, err)
+			continue
+		}
+		bz, err := types.EncodeDKGMessage(resp)
+		if err != nil {
+			return err
+		}
+		responses = append(responses, bz)
+	}
+	if !send {
+		return nil
+	}
+	return r.broadcast(types.NewMsgDKGResponse(instanceFlag(), responses, r.cliCtx.GetFromAddress()))
+}
+
+func (r *dkgRunner) justifications(send bool) error {
+	var justifications [][]byte
+	err := r.processMessages(types.DKGPhaseResponses, func(bz []byte) error {
+		var resp rabin.Response
+		if err := types.DecodeDKGMessage(bz, &resp); err != nil {
+			return err
+		}
+		j, err := r.gen.ProcessResponse(&resp)
+		if err != nil || j == nil {
+			return err
+		}
+		jBytes, err := types.EncodeDKGMessage(j)
+		if err != nil {
+			return err
+		}
+		justifications = append(justifications, jBytes)
+		return nil
+	})
+	if err != nil || !send {
+		return err
+	}
+	return r.broadcast(types.NewMsgDKGJustification(instanceFlag(), justifications, r.cliCtx.GetFromAddress()))
+}
+
+func (r *dkgRunner) secretCommits(send bool) error {
+	err := r.processMessages(types.DKGPhaseJustify, func(bz []byte) error {
+		var j rabin.Justification
+		if err := types.DecodeDKGMessage(bz, &j); err != nil {
+			return err
+		}
+		return r.gen.ProcessJustification(&j)
+	})
+	if err != nil {
+		return err
+	}
+	r.gen.SetTimeout()
+
+	// secret commits are sent only if the own deal is certified
+	var scBytes []byte
+	if sc, err := r.gen.SecretCommits(); err == nil {
+		if scBytes, err = types.EncodeDKGMessage(sc); err != nil {
+			return err
+		}
+	}
+	var qual []uint32
+	for _, i := range r.gen.QUAL() {
+		qual = append(qual, uint32(i))
+	}
+	if !send {
+		return nil
+	}
+	return r.broadcast(types.NewMsgDKGSecretCommits(instanceFlag(), scBytes, qual, r.cliCtx.GetFromAddress()))
+}
+
+func (r *dkgRunner) complaintCommits(send bool) error {
+	var complaints [][]byte
+	err := r.processMessages(types.DKGPhaseCommits, func(bz []byte) error {
+		var sc rabin.SecretCommits
+		if err := types.DecodeDKGMessage(bz, &sc); err != nil {
+			return err
+		}
+		cc, err := r.gen.ProcessSecretCommits(&sc)
+		if err != nil || cc == nil {
+			return err
+		}
+		ccBytes, err := types.EncodeDKGMessage(cc)
+		if err != nil {
+			return err
+		}
+		complaints = append(complaints, ccBytes)
+		return nil
+	})
+	if err != nil || !send {
+		return err
+	}
+	return r.broadcast(types.NewMsgDKGComplaintCommits(instanceFlag(), complaints, r.cliCtx.GetFromAddress()))
+}
+
+func (r *dkgRunner) reconstructCommits(send bool) error {
+	var reconstructs [][]byte
+	err := r.processMessages(types.DKGPhaseComplaints, func(bz []byte) error {
+		var cc rabin.ComplaintCommits
+		if err := types.DecodeDKGMessage(bz, &cc); err != nil {
+			return err
+		}
+		rc, err := r.gen.ProcessComplaintCommits(&cc)
+		if err != nil {
+			return err
+		}
+		rcBytes, err := types.EncodeDKGMessage(rc)
+		if err != nil {
+			return err
+		}
+		reconstructs = append(reconstructs, rcBytes)
+		return nil
+	})
+	if err != nil || !send {
+		return err
+	}
+	return r.broadcast(types.NewMsgDKGReconstructCommits(instanceFlag(), reconstructs, r.cliCtx.GetFromAddress()))
+}
+
+// finish processes reconstruct commits and prints the resulting key share
+func (r *dkgRunner) finish(_ bool) error {
+	err := r.processMessages(types.DKGPhaseReconstructs, func(bz []byte) error {
+		var rc rabin.ReconstructCommits
+		if err := types.DecodeDKGMessage(bz, &rc); err != nil {
+			return err
+		}
+		return r.gen.ProcessReconstructCommits(&rc)
+	})
+	if err != nil {
+		return err
+	}
+	distKey, err := r.gen.DistKeyShare()
+	if err != nil {
+		return err
+	}
+
+	commonKey, err := kyberenc.PointToStringHex(types.P256, distKey.Public())
+	if err != nil {
+		return err
+	}
+	privateKey, err := kyberenc.ScalarToStringHex(types.P256, distKey.PriShare().V)
+	if err != nil {
+		return err
+	}
+	verificationKey, err := kyberenc.PointToStringHex(types.P256, types.P256.Point().Mul(distKey.PriShare().V, nil))
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(dkgKeyShare{
+		ID:              distKey.PriShare().I,
+		PrivateKey:      privateKey,
+		VerificationKey: verificationKey,
+		CommonKey:       commonKey,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+type dkgKeyShare struct {
+	ID              int    `json:"id"`
+	PrivateKey      string `json:"private_key"`
+	VerificationKey string `json:"verification_key"`
+	CommonKey       string `json:"common_key"`
+}
+
+// processMessages applies fn to all messages sent by other participants at the phase.
+// Invalid messages are skipped, a malicious participant can't stop the DKG.
+func (r *dkgRunner) processMessages(phase string, fn func(bz []byte) error) error {
+	var res types.QueryDKGMessagesRes
+	if err := queryDKG(r.cliCtx, r.cdc, types.QuerierRouter, types.QueryDKGMessages, types.QueryDKGMessagesParams{Phase: phase}, &res); err != nil {
+		return err
+	}
+	for _, msg := range res.Messages {
+		if msg.Index == r.index {
+			continue
+		}
+		for _, bz := range msg.Data {
+			if err := fn(bz); err != nil {
+				fmt.Printf("skipping %v message from %v: %v\n", phase, msg.Index, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *dkgRunner) broadcast(msg sdk.Msg) error {
+	return broadcastMsg(r.cliCtx, r.cdc, msg)
+}
+
+func queryDKG(cliCtx context.CLIContext, cdc *codec.Codec, queryRoute string, route string, params interface{}, out interface{}) error {
+	var bz []byte
+	if params != nil {
+		var err error
+		if bz, err = cdc.MarshalJSON(params); err != nil {
+			return err
+		}
+	}
+	res, _, err := cliCtx.QueryWithData(queryPath(queryRoute, route), bz)
+	if err != nil {
+		return err
+	}
+	return cdc.UnmarshalJSON(res, out)
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/version"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/auth/client/utils"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+
+	"github.com/spf13/cobra"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+// KeyHoldersChangeProposalJSON defines the key holders change proposal file
+type KeyHoldersChangeProposalJSON struct {
+	Title                string                     `json:"title"`
+	Description          string                     `json:"description"`
+	Participants         []types.DKGParticipantJSON `json:"participants"`
+	ThresholdCiphertexts uint64                     `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64                     `json:"threshold_decryption"`
+	Instance             string                     `json:"instance"` // beacon instance ID, empty for the default instance
+	Deposit              sdk.Coins                  `json:"deposit"`
+}
+
+// InstanceParamsChangeProposalJSON defines the instance params change proposal file
+type InstanceParamsChangeProposalJSON struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Instance    string       `json:"instance"`
+	Params      types.Params `json:"params"`
+	Deposit     sdk.Coins    `json:"deposit"`
+}
+
+// GetCmdKeyEpoch implements the query key epoch command.
+func GetCmdKeyEpoch(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "epoch [epoch](optional)",
+		Short: "returns key holders, common key and thresholds of the key epoch",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			epoch := int64(-1)
+			if len(args) > 0 {
+				parsedEpoch, err := strconv.ParseUint(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("epoch %s not a valid uint, please input a valid epoch", args[0])
+				}
+				epoch = int64(parsedEpoch)
+			}
+
+			var out types.KeyEpoch
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryEpoch, types.QueryEpochParams{Epoch: epoch}, &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
+
+// GetCmdRoundEpoch implements the query round key epoch command.
+func GetCmdRoundEpoch(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "round-epoch [round](optional)",
+		Short: "returns the key epoch used by the round",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			round := int64(-1)
+			if len(args) > 0 {
+				parsedRound, err := strconv.ParseUint(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("round %s not a valid uint, please input a valid round", args[0])
+				}
+				round = int64(parsedRound)
+			}
+
+			var out types.KeyEpoch
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryRoundEpoch, types.NewQueryByRound(round), &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
+
+// GetCmdSubmitKeyHoldersChangeProposal implements the command for submitting a key holders change proposal.
+func GetCmdSubmitKeyHoldersChangeProposal(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "key-holders-change [proposal-file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Submit a key holders change proposal",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Submit a key holders change proposal along with an initial deposit.
+After the proposal is passed new key holders run the DKG with "dkg-run" command,
+new keys are used since the first round started after the DKG completion.
+
+Example:
+$ %s tx gov submit-proposal key-holders-change <path/to/proposal.json> --from=<key_or_address>
+
+Where proposal.json contains:
+
+{
+  "title": "New key holders",
+  "description": "Replace key holders set",
+  "participants": [
+    {
+      "address": "cosmos1...",
+      "pub_key": "04..."
+    }
+  ],
+  "threshold_ciphertexts": 2,
+  "threshold_decryption": 2,
+  "instance": "",
+  "deposit": [
+    {
+      "denom": "stake",
+      "amount": "10000"
+    }
+  ]
+}
+`,
+				version.ClientName,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			contents, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var proposal KeyHoldersChangeProposalJSON
+			if err := cdc.UnmarshalJSON(contents, &proposal); err != nil {
+				return err
+			}
+
+			content := types.NewKeyHoldersChangeProposal(proposal.Instance, proposal.Title, proposal.Description, proposal.Participants,
+				proposal.ThresholdCiphertexts, proposal.ThresholdDecryption)
+			msg := gov.NewMsgSubmitProposal(content, proposal.Deposit, cliCtx.GetFromAddress())
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+
+			return utils.GenerateOrBroadcastMsgs(cliCtx, txBldr, []sdk.Msg{msg})
+		},
+	}
+}
+
+// GetCmdSubmitInstanceParamsChangeProposal implements the command for submitting an instance params change proposal.
+func GetCmdSubmitInstanceParamsChangeProposal(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "instance-params-change [proposal-file]",
+		Args:  cobra.ExactArgs(1),
+		Short: "Submit a beacon instance params change proposal",
+		Long: strings.TrimSpace(
+			fmt.Sprintf(`Submit a params change proposal of the beacon instance besides the default one along with an initial deposit.
+The proposal replaces all params of the instance, params of the default instance are changed by the "param-change" proposal.
+
+Example:
+$ %s tx gov submit-proposal instance-params-change <path/to/proposal.json> --from=<key_or_address>
+
+Where proposal.json contains:
+
+{
+  "title": "Games instance params",
+  "description": "Shorten the deadlines",
+  "instance": "games",
+  "params": {
+    "ciphertext_deadline": "5",
+    "decryption_deadline": "5",
+    ...
+  },
+  "deposit": [
+    {
+      "denom": "stake",
+      "amount": "10000"
+    }
+  ]
+}
+`,
+				version.ClientName,
+			),
+		),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			contents, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var proposal InstanceParamsChangeProposalJSON
+			if err := cdc.UnmarshalJSON(contents, &proposal); err != nil {
+				return err
+			}
+
+			content := types.NewInstanceParamsChangeProposal(proposal.Instance, proposal.Title, proposal.Description, proposal.Params)
+			msg := gov.NewMsgSubmitProposal(content, proposal.Deposit, cliCtx.GetFromAddress())
+			if err := msg.ValidateBasic(); err != nil {
+				return err
+			}
+
+			return utils.GenerateOrBroadcastMsgs(cliCtx, txBldr, []sdk.Msg{msg})
+		},
+	}
+}
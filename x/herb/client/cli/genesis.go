@@ -153,3 +153,56 @@ func SetCommonPublicKeyCmd(ctx *server.Context, cdc *codec.Codec) *cobra.Command
 		},
 	}
 }
+
+// AddDKGParticipantCmd implements command for adding participant of the on-chain distributed key generation
+func AddDKGParticipantCmd(ctx *server.Context, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add-dkg-participant [address] [longterm_public_key]",
+		Short: "add DKG participant with its long-term public key to genesis file",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := ctx.Config
+
+			addr, err := sdk.AccAddressFromBech32(args[0])
+			if err != nil {
+				return err
+			}
+
+			_, err = kyberenc.StringHexToPoint(types.P256, args[1])
+			if err != nil {
+				return fmt.Errorf("failed to decode long-term public key: %v", err)
+			}
+
+			genFile := config.GenesisFile()
+			appState, genDoc, err := genutil.GenesisStateFromGenFile(cdc, genFile)
+			if err != nil {
+				return err
+			}
+			genesisStateJSON := appState[types.ModuleName]
+			var genesisState types.GenesisState
+			types.ModuleCdc.MustUnmarshalJSON(genesisStateJSON, &genesisState)
+
+			participants := genesisState.DKGParticipants
+			for _, p := range participants {
+				if p.Address.Equals(addr) {
+					return fmt.Errorf("cannot add DKG participant at existing address %v", addr)
+				}
+			}
+
+			participants = append(participants, types.DKGParticipantJSON{Address: addr, PubKey: args[1]})
+			genesisState.DKGParticipants = participants
+
+			newGenesisState := types.ModuleCdc.MustMarshalJSON(genesisState)
+			appState[types.ModuleName] = newGenesisState
+			appStateJSON, err := cdc.MarshalJSON(appState)
+			if err != nil {
+				return err
+			}
+
+			// export app state
+			genDoc.AppState = appStateJSON
+
+			return genutil.ExportGenesisFile(genDoc, genFile)
+		},
+	}
+}
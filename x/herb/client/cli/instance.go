@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+const flagInstance = "instance"
+
+// addInstanceFlag adds the beacon instance flag to the herb command and its subcommands
+func addInstanceFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().String(flagInstance, types.DefaultInstance, "ID of the beacon instance, the default instance if empty")
+}
+
+// instanceFlag returns the beacon instance set by the flag
+func instanceFlag() string {
+	return viper.GetString(flagInstance)
+}
+
+// queryPath returns the path of the herb query to the instance set by the flag
+func queryPath(queryRoute string, query string) string {
+	if instance := instanceFlag(); instance != types.DefaultInstance {
+		return fmt.Sprintf("custom/%s/%s/%s", queryRoute, query, instance)
+	}
+	return fmt.Sprintf("custom/%s/%s", queryRoute, query)
+}
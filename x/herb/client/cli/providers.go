@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+// GetCmdEntropyProviders implements the query registered entropy providers command.
+func GetCmdEntropyProviders(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "providers",
+		Short: "returns registered entropy providers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var out []types.EntropyProvider
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryEntropyProviders, nil, &out); err != nil {
+				return err
+			}
+
+			for _, provider := range out {
+				fmt.Println(provider.String())
+			}
+			return nil
+		},
+	}
+}
+
+// GetCmdRegisterEntropyProvider implements the register entropy provider transaction command.
+func GetCmdRegisterEntropyProvider(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "register-provider",
+		Short: "register as an entropy provider, the bond defined by the herb params is taken",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			return broadcastMsg(cliCtx, cdc, types.NewMsgRegisterEntropyProvider(instanceFlag(), cliCtx.GetFromAddress()))
+		},
+	}
+}
+
+// GetCmdDeregisterEntropyProvider implements the deregister entropy provider transaction command.
+func GetCmdDeregisterEntropyProvider(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "deregister-provider",
+		Short: "deregister as an entropy provider and get the bond back",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			return broadcastMsg(cliCtx, cdc, types.NewMsgDeregisterEntropyProvider(instanceFlag(), cliCtx.GetFromAddress()))
+		},
+	}
+}
@@ -30,8 +30,20 @@ func GetQueryCmd(storeKey string, cdc *codec.Codec) *cobra.Command {
 		GetCmdCurrentRound(storeKey, cdc),
 		GetCmdRoundStage(storeKey, cdc),
 		GetCmdRoundResult(storeKey, cdc),
+		GetCmdDKGPhase(storeKey, cdc),
+		GetCmdDKGParticipants(storeKey, cdc),
+		GetCmdRefreshStatus(storeKey, cdc),
+		GetCmdKeyEpoch(storeKey, cdc),
+		GetCmdRoundEpoch(storeKey, cdc),
+		GetCmdMissCounters(storeKey, cdc),
+		GetCmdRewardPool(storeKey, cdc),
+		GetCmdEntropyProviders(storeKey, cdc),
+		GetCmdRequest(storeKey, cdc),
+		GetCmdRoundTranscript(storeKey, cdc),
+		GetCmdVerifyRound(storeKey, cdc),
 	)...)
 
+	addInstanceFlag(herbQueryCmd)
 	return herbQueryCmd
 }
 
@@ -61,7 +73,7 @@ func GetCmdAggregatedCiphertext(queryRoute string, cdc *codec.Codec) *cobra.Comm
 				return err
 			}
 
-			res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", queryRoute, types.QueryAggregatedCt), bz)
+			res, _, err := cliCtx.QueryWithData(queryPath(queryRoute, types.QueryAggregatedCt), bz)
 			if err != nil {
 				return err
 			}
@@ -100,7 +112,7 @@ func GetCmdAllCiphertexts(queryRoute string, cdc *codec.Codec) *cobra.Command {
 				return err
 			}
 
-			res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", queryRoute, types.QueryAllCt), bz)
+			res, _, err := cliCtx.QueryWithData(queryPath(queryRoute, types.QueryAllCt), bz)
 			if err != nil {
 				return err
 			}
@@ -140,7 +152,7 @@ func GetCmdAllDecryptionShares(queryRoute string, cdc *codec.Codec) *cobra.Comma
 				return err
 			}
 
-			res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", queryRoute, types.QueryAllDescryptionShares), bz)
+			res, _, err := cliCtx.QueryWithData(queryPath(queryRoute, types.QueryAllDescryptionShares), bz)
 			if err != nil {
 				return err
 			}
@@ -167,7 +179,7 @@ func GetCmdCurrentRound(queryRoute string, cdc *codec.Codec) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cliCtx := context.NewCLIContext().WithCodec(cdc)
 
-			resBytes, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", queryRoute, types.QueryCurrentRound), nil)
+			resBytes, _, err := cliCtx.QueryWithData(queryPath(queryRoute, types.QueryCurrentRound), nil)
 			if err != nil {
 				return err
 			}
@@ -207,7 +219,7 @@ func GetCmdRoundStage(queryRoute string, cdc *codec.Codec) *cobra.Command {
 				return err
 			}
 
-			stageBytes, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", queryRoute, types.QueryStage), bz)
+			stageBytes, _, err := cliCtx.QueryWithData(queryPath(queryRoute, types.QueryStage), bz)
 			if err != nil {
 				return err
 			}
@@ -216,6 +228,9 @@ func GetCmdRoundStage(queryRoute string, cdc *codec.Codec) *cobra.Command {
 			cdc.MustUnmarshalJSON(stageBytes, &out)
 
 			fmt.Println(out.Stage)
+			if out.Reason != "" {
+				fmt.Println(out.Reason)
+			}
 
 			return nil
 		},
@@ -225,7 +240,7 @@ func GetCmdRoundStage(queryRoute string, cdc *codec.Codec) *cobra.Command {
 func GetCmdRoundResult(queryRoute string, cdc *codec.Codec) *cobra.Command {
 	return &cobra.Command{
 		Use:   "get-random [round](optional)",
-		Short: "returns round result random number",
+		Short: "returns random number of the completed round, the latest result if the round isn't set",
 		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cliCtx := context.NewCLIContext().WithCodec(cdc)
@@ -247,7 +262,7 @@ func GetCmdRoundResult(queryRoute string, cdc *codec.Codec) *cobra.Command {
 				return err
 			}
 
-			resBytes, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", queryRoute, types.QueryResult), bz)
+			resBytes, _, err := cliCtx.QueryWithData(queryPath(queryRoute, types.QueryResult), bz)
 			if err != nil {
 				return fmt.Errorf(fmt.Sprintf("%v", err))
 			}
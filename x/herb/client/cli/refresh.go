@@ -0,0 +1,281 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/auth/client/utils"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/corestario/HERB/x/herb/types"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+)
+
+// GetCmdRefreshStatus implements the query key refresh state command.
+func GetCmdRefreshStatus(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh",
+		Short: "returns state of the key holders' shares refresh",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var out types.QueryRefreshRes
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryRefresh, nil, &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
+
+// GetCmdStartRefresh implements the start key refresh transaction command.
+func GetCmdStartRefresh(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "refresh-start",
+		Short: "start the key holders' shares refresh",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			msg := types.NewMsgStartRefresh(instanceFlag(), cliCtx.GetFromAddress())
+			err := msg.ValidateBasic()
+			if err != nil {
+				return err
+			}
+			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
+
+			return utils.GenerateOrBroadcastMsgs(cliCtx, txBldr, []sdk.Msg{msg})
+		},
+	}
+}
+
+// GetCmdSetEncryptionKey implements the register encryption key transaction command.
+func GetCmdSetEncryptionKey(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set-encryption-key [publicKey]",
+		Short: "register the key holder's long-term public key which encrypts the refresh deals",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+			return broadcastMsg(cliCtx, cdc, types.NewMsgSetEncryptionKey(instanceFlag(), args[0], cliCtx.GetFromAddress()))
+		},
+	}
+}
+
+// GetCmdRefreshRun implements the command which takes part in the key holders' shares refresh.
+func GetCmdRefreshRun(cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "refresh-run [privateKey] [encryptionPrivateKey]",
+		Short: "take part in the key holders' shares refresh and print the new private key",
+		Long: `Take part in the key holders' shares refresh.
+The command waits for the refresh to start, sends the sharing of zero, complaints about incorrect shares
+and reveals its shares for the key holders who complained about them.
+When the new verification keys are applied it prints the new private key and the round since which it must be used.
+The polynomial of the sent deal is kept in memory only, the command can't justify the deal after a restart.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			privKey, err := kyberenc.StringHexToScalar(types.P256, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to decode private key: %v", err)
+			}
+			encPrivKey, err := kyberenc.StringHexToScalar(types.P256, args[1])
+			if err != nil {
+				return fmt.Errorf("failed to decode encryption private key: %v", err)
+			}
+			pollInterval := viper.GetDuration(flagPollInterval)
+
+			var number uint64
+			var keysRound uint64
+			var poly *share.PriPoly
+			dealt, complained, justified := false, false, false
+			for {
+				var status types.QueryRefreshRes
+				if err := queryDKG(cliCtx, cdc, types.QuerierRouter, types.QueryRefresh, nil, &status); err != nil {
+					return err
+				}
+				keyHolders, err := types.VerificationKeyArrayDeserialize(status.KeyHolders)
+				if err != nil {
+					return err
+				}
+				pos := -1
+				for i, kh := range keyHolders {
+					if kh.Sender.Equals(cliCtx.GetFromAddress()) {
+						pos = i
+					}
+				}
+				if pos < 0 {
+					return fmt.Errorf("%v isn't a key holder", cliCtx.GetFromAddress())
+				}
+				t, n := int(status.Threshold), len(keyHolders)
+
+				switch {
+				case status.Phase == types.RefreshPhaseDeals && !dealt:
+					if !keyHolders[pos].Key.Equal(types.P256.Point().Mul(privKey, nil)) {
+						return fmt.Errorf("private key doesn't match the verification key")
+					}
+					encKeys, err := refreshEncryptionKeys(status)
+					if err != nil {
+						return err
+					}
+					if !encKeys[pos].Equal(types.P256.Point().Mul(encPrivKey, nil)) {
+						return fmt.Errorf("encryption private key doesn't match the registered encryption key")
+					}
+					number, keysRound = status.Number, status.KeysRound
+					var deal *types.RefreshDeal
+					if deal, poly, err = types.NewRefreshDeal(keyHolders, encKeys, t); err != nil {
+						return err
+					}
+					if err := broadcastMsg(cliCtx, cdc, types.NewMsgRefreshDeal(instanceFlag(), *deal, cliCtx.GetFromAddress())); err != nil {
+						return err
+					}
+					dealt = true
+
+				case status.Phase == types.RefreshPhaseComplaints && dealt && !complained:
+					var dealers []sdk.AccAddress
+					for _, deal := range status.Deals {
+						_, err := deal.Deal.DecryptShare(encPrivKey, pos, keyHolders[pos].KeyHolderID, t, n)
+						if err != nil {
+							fmt.Printf("complaining about %v: %v\n", deal.Dealer, err)
+							dealers = append(dealers, deal.Dealer)
+						}
+					}
+					if err := broadcastMsg(cliCtx, cdc, types.NewMsgRefreshComplaint(instanceFlag(), dealers, cliCtx.GetFromAddress())); err != nil {
+						return err
+					}
+					complained = true
+
+				case status.Phase == types.RefreshPhaseJustifications && dealt && !justified:
+					var justifications []types.RefreshJustification
+					for _, complaint := range status.Complaints {
+						if !containsAddr(complaint.Dealers, cliCtx.GetFromAddress()) {
+							continue
+						}
+						for _, kh := range keyHolders {
+							if !kh.Sender.Equals(complaint.Sender) {
+								continue
+							}
+							j, err := types.NewRefreshJustification(poly, kh)
+							if err != nil {
+								return err
+							}
+							justifications = append(justifications, j)
+						}
+					}
+					if len(justifications) > 0 {
+						if err := broadcastMsg(cliCtx, cdc, types.NewMsgRefreshJustification(instanceFlag(), justifications, cliCtx.GetFromAddress())); err != nil {
+							return err
+						}
+					}
+					justified = true
+
+				case status.Phase == types.RefreshPhaseNone && dealt && status.Number == number && status.KeysRound != keysRound:
+					// deals of the applied refresh are kept until the next refresh is started
+					newPrivKey := types.P256.Scalar().Set(privKey)
+					for _, deal := range status.Deals {
+						if !containsAddr(status.QUAL, deal.Dealer) {
+							continue
+						}
+						s, err := refreshShare(status, deal, encPrivKey, keyHolders[pos], pos)
+						if err != nil {
+							return fmt.Errorf("share from %v is incorrect: %v", deal.Dealer, err)
+						}
+						newPrivKey = newPrivKey.Add(newPrivKey, s)
+					}
+					if !keyHolders[pos].Key.Equal(types.P256.Point().Mul(newPrivKey, nil)) {
+						return fmt.Errorf("new private key doesn't match the new verification key")
+					}
+					return printRefreshedKey(keyHolders[pos], newPrivKey, status.KeysRound)
+
+				case dealt && (status.Number != number || status.Phase == types.RefreshPhaseNone):
+					return fmt.Errorf("key refresh failed, keys aren't changed")
+				}
+				time.Sleep(pollInterval)
+			}
+		},
+	}
+	cmd.Flags().Duration(flagPollInterval, 5*time.Second, "interval between the refresh state queries")
+	return cmd
+}
+
+// refreshEncryptionKeys returns the encryption keys of the key holders, all of them must be registered
+func refreshEncryptionKeys(status types.QueryRefreshRes) ([]kyber.Point, error) {
+	keys := make([]kyber.Point, len(status.EncryptionKeys))
+	for i, keyHex := range status.EncryptionKeys {
+		key, err := kyberenc.StringHexToPoint(types.P256, keyHex)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key of %v: %v", status.KeyHolders[i].Sender, err)
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// refreshShare returns the key holder's share of the deal, the share revealed by the dealer's justification is used if there is one
+func refreshShare(status types.QueryRefreshRes, deal types.RefreshDealJSON, encPrivKey kyber.Scalar, kh *types.VerificationKey, pos int) (kyber.Scalar, error) {
+	t, n := int(status.Threshold), len(status.KeyHolders)
+	for _, justifications := range status.Justifications {
+		if !justifications.Dealer.Equals(deal.Dealer) {
+			continue
+		}
+		for _, j := range justifications.Justifications {
+			if j.KeyHolder.Equals(kh.Sender) {
+				return deal.Deal.JustifiedShare(j, kh.KeyHolderID, t, n)
+			}
+		}
+	}
+	return deal.Deal.DecryptShare(encPrivKey, pos, kh.KeyHolderID, t, n)
+}
+
+func printRefreshedKey(vk *types.VerificationKey, privKey kyber.Scalar, round uint64) error {
+	privKeyHex, err := kyberenc.ScalarToStringHex(types.P256, privKey)
+	if err != nil {
+		return err
+	}
+	vkHex, err := kyberenc.PointToStringHex(types.P256, vk.Key)
+	if err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(struct {
+		ID              int    `json:"id"`
+		PrivateKey      string `json:"private_key"`
+		VerificationKey string `json:"verification_key"`
+		Round           uint64 `json:"round"`
+	}{vk.KeyHolderID, privKeyHex, vkHex, round}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func containsAddr(list []sdk.AccAddress, addr sdk.AccAddress) bool {
+	for _, a := range list {
+		if a.Equals(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func broadcastMsg(cliCtx context.CLIContext, cdc *codec.Codec, msg sdk.Msg) error {
+	if err := msg.ValidateBasic(); err != nil {
+		return err
+	}
+	txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
+	return utils.GenerateOrBroadcastMsgs(cliCtx, txBldr, []sdk.Msg{msg})
+}
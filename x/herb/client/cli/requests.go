@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/spf13/cobra"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+// GetCmdRequest implements the query randomness request command.
+func GetCmdRequest(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "request [id]",
+		Short: "returns the randomness request with the result of its round if the round is completed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			id, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("request ID %s not a valid uint, please input a valid request ID", args[0])
+			}
+
+			var out types.QueryRequestRes
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryRequest, types.QueryRequestParams{ID: id}, &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
+
+// GetCmdRequestRandomness implements the request randomness transaction command.
+func GetCmdRequestRandomness(cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "request-randomness [fee](optional)",
+		Short: "request the random number of the next round, the fee goes to the rewards pool",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var fee sdk.Coins
+			if len(args) > 0 {
+				var err error
+				if fee, err = sdk.ParseCoins(args[0]); err != nil {
+					return err
+				}
+			}
+
+			return broadcastMsg(cliCtx, cdc, types.NewMsgRequestRandomness(instanceFlag(), fee, cliCtx.GetFromAddress()))
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/spf13/cobra"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+// GetCmdRewardPool implements the query rewards pool command.
+func GetCmdRewardPool(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reward-pool",
+		Short: "returns balance of the herb rewards pool",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var out sdk.Coins
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryRewardPool, nil, &out); err != nil {
+				return err
+			}
+
+			fmt.Println(out.String())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+
+	"github.com/spf13/cobra"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+// GetCmdMissCounters implements the query key holders' miss counters command.
+func GetCmdMissCounters(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "miss-counters",
+		Short: "returns missed rounds and penalties of the key holders",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var out []types.MissCounter
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryMissCounters, nil, &out); err != nil {
+				return err
+			}
+
+			for _, counter := range out {
+				fmt.Println(counter.String())
+			}
+			return nil
+		},
+	}
+}
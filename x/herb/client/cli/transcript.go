@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/codec"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+const (
+	flagTranscriptFile = "transcript-file"
+	flagLight          = "light"
+)
+
+// GetCmdRoundTranscript implements the query round transcript command.
+func GetCmdRoundTranscript(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "round-transcript [round](optional)",
+		Short: "returns everything needed to check the round: keys, shares with proofs and the result",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			round, err := parseOptionalRound(args)
+			if err != nil {
+				return err
+			}
+			var out types.RoundTranscript
+			if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryRoundTranscript, types.NewQueryByRound(round), &out); err != nil {
+				return err
+			}
+
+			var bz []byte
+			if viper.GetBool(flagLight) {
+				lt, err := out.LightTranscript()
+				if err != nil {
+					return err
+				}
+				bz, err = json.MarshalIndent(lt, "", "  ")
+				if err != nil {
+					return err
+				}
+			} else {
+				bz, err = cdc.MarshalJSONIndent(out, "", "  ")
+				if err != nil {
+					return err
+				}
+			}
+			fmt.Println(string(bz))
+			return nil
+		},
+	}
+	cmd.Flags().Bool(flagLight, false, "print the transcript in the plain format of the light verifier package")
+	return cmd
+}
+
+// GetCmdVerifyRound implements the round verification command.
+// All proofs are checked locally, the transcript is taken from the node or from the file.
+func GetCmdVerifyRound(queryRoute string, cdc *codec.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-round [round](optional)",
+		Short: "checks CE and DLEQ proofs of the round, re-decrypts the aggregated ciphertext and compares it with the result",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliCtx := context.NewCLIContext().WithCodec(cdc)
+
+			var transcript types.RoundTranscript
+			if file := viper.GetString(flagTranscriptFile); file != "" {
+				bz, err := ioutil.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if err := cdc.UnmarshalJSON(bz, &transcript); err != nil {
+					return fmt.Errorf("can't decode transcript: %v", err)
+				}
+			} else {
+				round, err := parseOptionalRound(args)
+				if err != nil {
+					return err
+				}
+				if err := queryDKG(cliCtx, cdc, queryRoute, types.QueryRoundTranscript, types.NewQueryByRound(round), &transcript); err != nil {
+					return err
+				}
+			}
+
+			if err := transcript.Verify(); err != nil {
+				return fmt.Errorf("round %v verification failed: %v", transcript.Round, err)
+			}
+			fmt.Printf("round %v is correct, result: %x\n", transcript.Round, transcript.Result)
+			return nil
+		},
+	}
+	cmd.Flags().String(flagTranscriptFile, "", "verify the transcript saved by round-transcript instead of querying the node")
+	return cmd
+}
+
+// parseOptionalRound returns the round argument or -1 for the current round
+func parseOptionalRound(args []string) (int64, error) {
+	if len(args) == 0 {
+		return -1, nil
+	}
+	round, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("round %s not a valid uint, please input a valid round", args[0])
+	}
+	return int64(round), nil
+}
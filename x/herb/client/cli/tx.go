@@ -2,7 +2,6 @@ package cli
 
 import (
 	"fmt"
-	"strconv"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/context"
@@ -16,7 +15,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"go.dedis.ch/kyber/v3/share"
 	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
 )
 
@@ -33,8 +31,16 @@ func GetTxCmd(storeKey string, cdc *codec.Codec) *cobra.Command {
 	herbTxCmd.AddCommand(client.PostCommands(
 		GetCmdSetCiphertextShare(cdc),
 		GetCmdSetDecryptionShare(cdc),
+		GetCmdDKGRun(cdc),
+		GetCmdStartRefresh(cdc),
+		GetCmdRefreshRun(cdc),
+		GetCmdSetEncryptionKey(cdc),
+		GetCmdRegisterEntropyProvider(cdc),
+		GetCmdDeregisterEntropyProvider(cdc),
+		GetCmdRequestRandomness(cdc),
 	)...)
 
+	addInstanceFlag(herbTxCmd)
 	return herbTxCmd
 }
 
@@ -53,23 +59,30 @@ func GetCmdSetCiphertextShare(cdc *codec.Codec) *cobra.Command {
 				return fmt.Errorf("failed to decode common public key: %v", err)
 			}
 
-			ct, ceproof, err := elgamal.RandomCiphertext(group, pubKey)
+			rounds, err := queryRounds(cliCtx)
+			if err != nil {
+				return err
+			}
+			if rounds.CollectingRound < 0 {
+				return fmt.Errorf("round %v isn't collecting ciphertext shares", rounds.Round)
+			}
+
+			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
+			context := proofContext(elgamal.ContextCE, txBldr, uint64(rounds.CollectingRound), cliCtx.GetFromAddress())
+			ct, ceproof, err := elgamal.RandomCiphertext(group, context, pubKey)
 			if err != nil {
 				return fmt.Errorf("failed to create random ciphertext: %v", err)
 			}
 
-			sender := cliCtx.GetFromAddress()
-			ctShare := types.CiphertextShare{Ciphertext: ct, CEproof: ceproof, EntropyProvider: sender}
-			ctShareJSON, err := types.NewCiphertextShareJSON(&ctShare)
+			ctBytes, err := types.EncodeCiphertext(&ct)
 			if err != nil {
 				return err
 			}
-			msg := types.NewMsgSetCiphertextShare(*ctShareJSON, cliCtx.GetFromAddress())
+			msg := types.NewMsgSetCiphertextShare(instanceFlag(), uint64(rounds.CollectingRound), ctBytes, ceproof, cliCtx.GetFromAddress())
 			err = msg.ValidateBasic()
 			if err != nil {
 				return err
 			}
-			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
 
 			return utils.GenerateOrBroadcastMsgs(cliCtx, txBldr, []sdk.Msg{msg})
 		},
@@ -79,20 +92,27 @@ func GetCmdSetCiphertextShare(cdc *codec.Codec) *cobra.Command {
 // GetCmdSetDecryptionShare implements send decryption share transaction command.
 func GetCmdSetDecryptionShare(cdc *codec.Codec) *cobra.Command {
 	return &cobra.Command{
-		Use:   "decrypt [privateKey] [ID]",
+		Use:   "decrypt [privateKey]",
 		Short: "Send a decryption share of the aggregated ciphertext",
-		Args:  cobra.ExactArgs(2),
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cliCtx := context.NewCLIContext().WithCodec(cdc)
 
-			//Getting aggregated ciphertext
-			params := types.NewQueryByRound(-1) //-1 for the current round
+			//Getting aggregated ciphertext of the round collecting decryption shares
+			rounds, err := queryRounds(cliCtx)
+			if err != nil {
+				return err
+			}
+			if rounds.DecryptingRound < 0 {
+				return fmt.Errorf("no round is collecting decryption shares, current round: %v", rounds.Round)
+			}
+			params := types.NewQueryByRound(rounds.DecryptingRound)
 			bz, err := cdc.MarshalJSON(params)
 			if err != nil {
 				return err
 			}
 
-			ctShareBytes, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", types.QuerierRouter, types.QueryAggregatedCt), bz)
+			ctShareBytes, _, err := cliCtx.QueryWithData(queryPath(types.QuerierRouter, types.QueryAggregatedCt), bz)
 			if err != nil {
 				return err
 			}
@@ -111,32 +131,26 @@ func GetCmdSetDecryptionShare(cdc *codec.Codec) *cobra.Command {
 				return fmt.Errorf("failed to decode private key: %v", err)
 			}
 
-			id, err := strconv.ParseInt(args[1], 10, 64)
+			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
+			context := proofContext(elgamal.ContextDLEQ, txBldr, uint64(rounds.DecryptingRound), cliCtx.GetFromAddress())
+			sharePoint, proof, err := elgamal.CreateDecShare(group, context, *aggregatedCt, privKey)
 			if err != nil {
-				return fmt.Errorf("id %s not a valid int, please input a valid id", args[1])
+				return err
 			}
 
-			sharePoint, proof, err := elgamal.CreateDecShare(group, *aggregatedCt, privKey)
+			decShareBytes, err := types.EncodeDecSharePoint(sharePoint)
 			if err != nil {
 				return err
 			}
-
-			decryptionShare := &types.DecryptionShare{
-				DecShare:      share.PubShare{I: int(id), V: sharePoint},
-				DLEQproof:      proof,
-				KeyHolderAddr: cliCtx.GetFromAddress(),
-			}
-
-			decryptionShareJSON, err := types.NewDecryptionShareJSON(decryptionShare)
+			proofBytes, err := types.EncodeDLEQProof(proof)
 			if err != nil {
 				return err
 			}
-			msg := types.NewMsgSetDecryptionShare(decryptionShareJSON, cliCtx.GetFromAddress())
+			msg := types.NewMsgSetDecryptionShare(instanceFlag(), uint64(rounds.DecryptingRound), decShareBytes, proofBytes, cliCtx.GetFromAddress())
 			err = msg.ValidateBasic()
 			if err != nil {
 				return err
 			}
-			txBldr := auth.NewTxBuilderFromCLI().WithTxEncoder(utils.GetTxEncoder(cdc))
 			txBldr, err = utils.EnrichWithGas(txBldr, cliCtx, []sdk.Msg{msg})
 			if err != nil {
 				return err
@@ -146,3 +160,21 @@ func GetCmdSetDecryptionShare(cdc *codec.Codec) *cobra.Command {
 		},
 	}
 }
+
+// proofContext returns the context of the sender's share proof, the chain ID is taken from the chain-id flag
+func proofContext(kind string, txBldr auth.TxBuilder, round uint64, sender sdk.AccAddress) []byte {
+	return elgamal.ProofContext(kind, txBldr.ChainID(), instanceFlag(), round, sender.String())
+}
+
+// queryRounds returns the current round and the rounds collecting shares
+func queryRounds(cliCtx context.CLIContext) (types.QueryCurrentRoundRes, error) {
+	var rounds types.QueryCurrentRoundRes
+	resBytes, _, err := cliCtx.QueryWithData(queryPath(types.QuerierRouter, types.QueryCurrentRound), nil)
+	if err != nil {
+		return rounds, err
+	}
+	if err := cliCtx.Codec.UnmarshalJSON(resBytes, &rounds); err != nil {
+		return rounds, err
+	}
+	return rounds, nil
+}
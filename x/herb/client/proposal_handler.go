@@ -0,0 +1,14 @@
+package client
+
+import (
+	govclient "github.com/cosmos/cosmos-sdk/x/gov/client"
+
+	"github.com/corestario/HERB/x/herb/client/cli"
+	"github.com/corestario/HERB/x/herb/client/rest"
+)
+
+// ProposalHandler is the key holders change proposal handler
+var ProposalHandler = govclient.NewProposalHandler(cli.GetCmdSubmitKeyHoldersChangeProposal, rest.ProposalRESTHandler)
+
+// InstanceParamsProposalHandler is the instance params change proposal handler
+var InstanceParamsProposalHandler = govclient.NewProposalHandler(cli.GetCmdSubmitInstanceParamsChangeProposal, rest.InstanceParamsProposalRESTHandler)
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/types/rest"
+	"github.com/cosmos/cosmos-sdk/x/auth/client/utils"
+	"github.com/cosmos/cosmos-sdk/x/gov"
+	govrest "github.com/cosmos/cosmos-sdk/x/gov/client/rest"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+type keyHoldersChangeProposalReq struct {
+	BaseReq              rest.BaseReq               `json:"base_req"`
+	Title                string                     `json:"title"`
+	Description          string                     `json:"description"`
+	Participants         []types.DKGParticipantJSON `json:"participants"`
+	ThresholdCiphertexts uint64                     `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64                     `json:"threshold_decryption"`
+	Instance             string                     `json:"instance"`
+	Proposer             sdk.AccAddress             `json:"proposer"`
+	Deposit              sdk.Coins                  `json:"deposit"`
+}
+
+type instanceParamsChangeProposalReq struct {
+	BaseReq     rest.BaseReq   `json:"base_req"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Instance    string         `json:"instance"`
+	Params      types.Params   `json:"params"`
+	Proposer    sdk.AccAddress `json:"proposer"`
+	Deposit     sdk.Coins      `json:"deposit"`
+}
+
+// ProposalRESTHandler returns the key holders change proposal REST handler
+func ProposalRESTHandler(cliCtx context.CLIContext) govrest.ProposalRESTHandler {
+	return govrest.ProposalRESTHandler{
+		SubRoute: "herb_key_holders_change",
+		Handler:  postProposalHandler(cliCtx),
+	}
+}
+
+func postProposalHandler(cliCtx context.CLIContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req keyHoldersChangeProposalReq
+		if !rest.ReadRESTReq(w, r, cliCtx.Codec, &req) {
+			return
+		}
+
+		req.BaseReq = req.BaseReq.Sanitize()
+		if !req.BaseReq.ValidateBasic(w) {
+			return
+		}
+
+		content := types.NewKeyHoldersChangeProposal(req.Instance, req.Title, req.Description, req.Participants, req.ThresholdCiphertexts, req.ThresholdDecryption)
+		msg := gov.NewMsgSubmitProposal(content, req.Deposit, req.Proposer)
+		if err := msg.ValidateBasic(); err != nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteGenerateStdTxResponse(w, cliCtx, req.BaseReq, []sdk.Msg{msg})
+	}
+}
+
+// InstanceParamsProposalRESTHandler returns the instance params change proposal REST handler
+func InstanceParamsProposalRESTHandler(cliCtx context.CLIContext) govrest.ProposalRESTHandler {
+	return govrest.ProposalRESTHandler{
+		SubRoute: "herb_instance_params_change",
+		Handler:  postInstanceParamsProposalHandler(cliCtx),
+	}
+}
+
+func postInstanceParamsProposalHandler(cliCtx context.CLIContext) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req instanceParamsChangeProposalReq
+		if !rest.ReadRESTReq(w, r, cliCtx.Codec, &req) {
+			return
+		}
+
+		req.BaseReq = req.BaseReq.Sanitize()
+		if !req.BaseReq.ValidateBasic(w) {
+			return
+		}
+
+		content := types.NewInstanceParamsChangeProposal(req.Instance, req.Title, req.Description, req.Params)
+		msg := gov.NewMsgSubmitProposal(content, req.Deposit, req.Proposer)
+		if err := msg.ValidateBasic(); err != nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		utils.WriteGenerateStdTxResponse(w, cliCtx, req.BaseReq, []sdk.Msg{msg})
+	}
+}
@@ -24,6 +24,7 @@ func aggregatedCiphertextHandler(cliCtx context.CLIContext, storeName string) ht
 			parsedRound, err := strconv.ParseUint(roundStr, 10, 64)
 			if err != nil {
 				rest.WriteErrorResponse(w, http.StatusBadRequest, fmt.Errorf("round %s not a valid uint, please input a valid round", roundStr).Error())
+				return
 			}
 			round = int64(parsedRound)
 		} else {
@@ -38,7 +39,7 @@ func aggregatedCiphertextHandler(cliCtx context.CLIContext, storeName string) ht
 			return
 		}
 
-		res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", storeName, types.QueryAggregatedCt), bz)
+		res, _, err := cliCtx.QueryWithData(queryPath(r, storeName, types.QueryAggregatedCt), bz)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusNotFound, err.Error())
 		}
@@ -49,7 +50,7 @@ func aggregatedCiphertextHandler(cliCtx context.CLIContext, storeName string) ht
 
 func currentRoundHandler(cliCtx context.CLIContext, storeName string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", storeName, types.QueryCurrentRound), nil)
+		res, _, err := cliCtx.QueryWithData(queryPath(r, storeName, types.QueryCurrentRound), nil)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusNotFound, err.Error())
 			return
@@ -70,6 +71,7 @@ func roundStageHandler(cliCtx context.CLIContext, storeName string) http.Handler
 			parsedRound, err := strconv.ParseUint(roundStr, 10, 64)
 			if err != nil {
 				rest.WriteErrorResponse(w, http.StatusBadRequest, fmt.Errorf("round %s not a valid uint, please input a valid round", roundStr).Error())
+				return
 			}
 			round = int64(parsedRound)
 		} else {
@@ -85,7 +87,7 @@ func roundStageHandler(cliCtx context.CLIContext, storeName string) http.Handler
 			return
 		}
 
-		res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", storeName, types.QueryStage), bz)
+		res, _, err := cliCtx.QueryWithData(queryPath(r, storeName, types.QueryStage), bz)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
 			return
@@ -106,6 +108,7 @@ func roundResultHandler(cliCtx context.CLIContext, storeName string) http.Handle
 			parsedRound, err := strconv.ParseUint(roundStr, 10, 64)
 			if err != nil {
 				rest.WriteErrorResponse(w, http.StatusBadRequest, fmt.Errorf("round %s not a valid uint, please input a valid round", roundStr).Error())
+				return
 			}
 			round = int64(parsedRound)
 		} else {
@@ -121,7 +124,7 @@ func roundResultHandler(cliCtx context.CLIContext, storeName string) http.Handle
 			return
 		}
 
-		resBytes, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", storeName, types.QueryResult), bz)
+		resBytes, _, err := cliCtx.QueryWithData(queryPath(r, storeName, types.QueryResult), bz)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusInternalServerError, err.Error())
 			return
@@ -142,6 +145,7 @@ func allCiphertextHandler(cliCtx context.CLIContext, storeName string) http.Hand
 			parsedRound, err := strconv.ParseUint(roundStr, 10, 64)
 			if err != nil {
 				rest.WriteErrorResponse(w, http.StatusBadRequest, fmt.Errorf("round %s not a valid uint, please input a valid round", roundStr).Error())
+				return
 			}
 			round = int64(parsedRound)
 		} else {
@@ -157,7 +161,7 @@ func allCiphertextHandler(cliCtx context.CLIContext, storeName string) http.Hand
 			return
 		}
 
-		res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", storeName, types.QueryAllCt), bz)
+		res, _, err := cliCtx.QueryWithData(queryPath(r, storeName, types.QueryAllCt), bz)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusNotFound, err.Error())
 			return
@@ -178,6 +182,7 @@ func allDecryptionSharesHandler(cliCtx context.CLIContext, storeName string) htt
 			parsedRound, err := strconv.ParseUint(roundStr, 10, 64)
 			if err != nil {
 				rest.WriteErrorResponse(w, http.StatusBadRequest, fmt.Errorf("round %s not a valid uint, please input a valid round", roundStr).Error())
+				return
 			}
 			round = int64(parsedRound)
 		} else {
@@ -193,7 +198,7 @@ func allDecryptionSharesHandler(cliCtx context.CLIContext, storeName string) htt
 			return
 		}
 
-		res, _, err := cliCtx.QueryWithData(fmt.Sprintf("custom/%s/%s", storeName, types.QueryAllDescryptionShares), bz)
+		res, _, err := cliCtx.QueryWithData(queryPath(r, storeName, types.QueryAllDescryptionShares), bz)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusNotFound, err.Error())
 			return
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"fmt"
+	"net/http"
 
 	"github.com/cosmos/cosmos-sdk/client/context"
 
@@ -47,3 +48,11 @@ func RegisterRoutes(cliCtx context.CLIContext, r *mux.Router, storeName string)
 		setDecryptionShareHandler(cliCtx),
 	).Methods("POST")
 }
+
+// queryPath returns the path of the herb query to the beacon instance set by the "instance" URL parameter
+func queryPath(r *http.Request, storeName string, query string) string {
+	if instance := r.URL.Query().Get("instance"); instance != "" {
+		return fmt.Sprintf("custom/%s/%s/%s", storeName, query, instance)
+	}
+	return fmt.Sprintf("custom/%s/%s", storeName, query)
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"encoding/base64"
 	"net/http"
 	"strings"
 
@@ -20,6 +21,8 @@ type setCiphertextShareReq struct {
 	Ciphertext      string       `json:"ciphertext"`
 	CEProof         string       `json:"ce_proof"`
 	EntropyProvider string       `json:"entropy_provider"`
+	Instance        string       `json:"instance"`
+	Round           *uint64      `json:"round"` // required, round the share is sent to
 }
 
 func setCiphertextShareHandler(cliCtx context.CLIContext) http.HandlerFunc {
@@ -59,15 +62,24 @@ func setCiphertextShareHandler(cliCtx context.CLIContext) http.HandlerFunc {
 			return
 		}
 		ct := elgamal.Ciphertext{PointA: pointA, PointB: pointB}
-		ctJSON, err := elgamal.NewCiphertextJSON(&ct, types.P256)
+		ctBytes, err1 := types.EncodeCiphertext(&ct)
+		if err1 != nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, err1.Error())
+			return
+		}
+
+		// CE proof is base64-encoded as in the ciphertext shares query
+		ceProof, err := base64.StdEncoding.DecodeString(req.CEProof)
 		if err != nil {
 			rest.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
 			return
 		}
 
-		ctShare := types.CiphertextShareJSON{*ctJSON, []byte(req.CEProof), entropyProvider}
-
-		msg := types.NewMsgSetCiphertextShare(ctShare, entropyProvider)
+		if req.Round == nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, "round is required")
+			return
+		}
+		msg := types.NewMsgSetCiphertextShare(req.Instance, *req.Round, ctBytes, ceProof, entropyProvider)
 
 		err = msg.ValidateBasic()
 		if err != nil {
@@ -82,8 +94,10 @@ func setCiphertextShareHandler(cliCtx context.CLIContext) http.HandlerFunc {
 type setDecryptionShareReq struct {
 	BaseReq         rest.BaseReq `jcon:"base_req"`
 	DecryptionShare string       `json:"decryption_share"`
-	DLEQProof        string       `json:"dleq_proof"`
+	DLEQProof       string       `json:"dleq_proof"`
 	KeyHolder       string       `json:"key_holder"`
+	Instance        string       `json:"instance"`
+	Round           *uint64      `json:"round"` // required, round the share is sent to
 }
 
 func setDecryptionShareHandler(cliCtx context.CLIContext) http.HandlerFunc {
@@ -107,8 +121,22 @@ func setDecryptionShareHandler(cliCtx context.CLIContext) http.HandlerFunc {
 			return
 		}
 
-		decShare := types.DecryptionShareJSON{DecShare: req.DecryptionShare, DLEQproof: req.DLEQProof, KeyHolderAddr: keyHolder}
-		msg := types.NewMsgSetDecryptionShare(decShare, keyHolder)
+		// decryption share point and DLEQ proof are base64-encoded, the share index is the key holder ID of the sender
+		decShare, err := base64.StdEncoding.DecodeString(req.DecryptionShare)
+		if err != nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		dleqProof, err := base64.StdEncoding.DecodeString(req.DLEQProof)
+		if err != nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Round == nil {
+			rest.WriteErrorResponse(w, http.StatusBadRequest, "round is required")
+			return
+		}
+		msg := types.NewMsgSetDecryptionShare(req.Instance, *req.Round, decShare, dleqProof, keyHolder)
 
 		err = msg.ValidateBasic()
 		if err != nil {
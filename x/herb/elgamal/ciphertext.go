@@ -1,46 +1,98 @@
 package elgamal
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/share"
 	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
 )
 
-//Ciphertext is usual ElGamal ciphertext C = (a, b)
-//Here a, b - the elliptic curve's points
+// Ciphertext is usual ElGamal ciphertext C = (a, b)
+// Here a, b - the elliptic curve's points
 type Ciphertext struct {
 	PointA kyber.Point `json:"point_a"`
 	PointB kyber.Point `json:"point_b"`
 }
 
-//IdentityCiphertext creates ciphertext which is neutral with respect to plaintext group operation (after ciphertext aggregation operation)
+// IdentityCiphertext creates ciphertext which is neutral with respect to plaintext group operation (after ciphertext aggregation operation)
 func IdentityCiphertext(group kyber.Group) Ciphertext {
 	return Ciphertext{group.Point().Null(), group.Point().Null()}
 }
 
-//Decrypt takes decryption shares from all participants and decrypt the ciphertext C
-func Decrypt(group kyber.Group, C Ciphertext, shares []*share.PubShare, n int) kyber.Point {
-	D, _ := share.RecoverCommit(group, shares, len(shares), n)
-	M := group.Point().Sub(C.PointB, D)
-	return M
+// InconsistentSharesError reports decryption shares beyond the threshold which don't match the interpolated polynomial
+type InconsistentSharesError struct {
+	Indices []int
 }
 
-//Equal compares two ciphertexts and returns true if ct = ct1
+func (e *InconsistentSharesError) Error() string {
+	return fmt.Sprintf("decryption shares %v are inconsistent with the shares interpolated first", e.Indices)
+}
+
+// Decrypt takes decryption shares of at least t key holders and decrypts the ciphertext C,
+// t is the decryption threshold of the key generation (polynomial degree + 1), n is the number of key holders.
+// The commitment is interpolated from t shares with the lowest indices, other shares are checked against it
+// and the InconsistentSharesError is returned if some of them don't match
+func Decrypt(group kyber.Group, C Ciphertext, shares []*share.PubShare, t int, n int) (kyber.Point, error) {
+	if t < 1 {
+		return nil, fmt.Errorf("decryption threshold must be positive, got %d", t)
+	}
+	if len(shares) < t {
+		return nil, fmt.Errorf("not enough decryption shares: %d, threshold: %d", len(shares), t)
+	}
+	indices := make(map[int]bool, len(shares))
+	for _, s := range shares {
+		if s == nil || s.V == nil {
+			return nil, errors.New("decryption share is missing")
+		}
+		if s.I < 0 || s.I >= n {
+			return nil, fmt.Errorf("decryption share index %d is out of range, number of key holders: %d", s.I, n)
+		}
+		if indices[s.I] {
+			return nil, fmt.Errorf("several decryption shares with index %d", s.I)
+		}
+		indices[s.I] = true
+	}
+	if len(shares) == t {
+		D, err := share.RecoverCommit(group, shares, t, n)
+		if err != nil {
+			return nil, err
+		}
+		return group.Point().Sub(C.PointB, D), nil
+	}
+	pubPoly, err := share.RecoverPubPoly(group, shares, t, n)
+	if err != nil {
+		return nil, err
+	}
+	var inconsistent []int
+	for _, s := range shares {
+		if !pubPoly.Eval(s.I).V.Equal(s.V) {
+			inconsistent = append(inconsistent, s.I)
+		}
+	}
+	if len(inconsistent) > 0 {
+		sort.Ints(inconsistent)
+		return nil, &InconsistentSharesError{Indices: inconsistent}
+	}
+	return group.Point().Sub(C.PointB, pubPoly.Commit()), nil
+}
+
+// Equal compares two ciphertexts and returns true if ct = ct1
 func (ct Ciphertext) Equal(ct1 Ciphertext) bool {
 	return ct.PointA.Equal(ct1.PointA) && ct.PointB.Equal(ct1.PointB)
 }
 
-//String
+// String
 func (ct Ciphertext) String() string {
 	str := fmt.Sprintf("A: %v, B: %v", ct.PointA.String(), ct.PointB.String())
 	return str
 }
 
-//AggregateCiphertext takes the set of ciphertextes shares:
-//shares[0] = (A0, B0), ..., shares[n] = (An, Bn)
-//and returns aggregated ciphertext C = (A1 + A2 + ... + An, B1 + B2 + ... + Bn)
+// AggregateCiphertext takes the set of ciphertextes shares:
+// shares[0] = (A0, B0), ..., shares[n] = (An, Bn)
+// and returns aggregated ciphertext C = (A1 + A2 + ... + An, B1 + B2 + ... + Bn)
 func AggregateCiphertext(group kyber.Group, shares []Ciphertext) Ciphertext {
 	if len(shares) == 0 {
 		return IdentityCiphertext(group)
@@ -55,7 +55,7 @@ func elGamalPositive(t *testing.T, shares []*kyberDKG.DistKeyShare, verkeys []*k
 
 	//verify all ciphertexts by parties[1]
 	for i := 0; i < n; i++ {
-		err := elgamal.CEVerify(curve, curve.Point().Base(), shares[i].Public(), publishedCiphertextes[i].PointA, publishedCiphertextes[i].PointB, CEproofs[i])
+		err := elgamal.CEVerify(curve, ceContext, curve.Point().Base(), shares[i].Public(), publishedCiphertextes[i].PointA, publishedCiphertextes[i].PointB, CEproofs[i])
 		if err != nil {
 			t.Errorf("CE proof isn't verified with error %v", err)
 		}
@@ -68,7 +68,7 @@ func elGamalPositive(t *testing.T, shares []*kyberDKG.DistKeyShare, verkeys []*k
 	decryptShares := make([]kyber.Point, tr)
 	DLEQproofs := make([]*dleq.Proof, tr)
 	for i := 0; i < tr; i++ {
-		decryptedMsg, dleqProof, err := elgamal.CreateDecShare(curve, commonCiphertext, shares[i].PriShare().V)
+		decryptedMsg, dleqProof, err := elgamal.CreateDecShare(curve, dleqContext, commonCiphertext, shares[i].PriShare().V)
 		if err != nil {
 			t.Errorf(fmt.Sprintf("Can't decrypt message, id: %v, err: %v", i, err))
 		}
@@ -77,7 +77,7 @@ func elGamalPositive(t *testing.T, shares []*kyberDKG.DistKeyShare, verkeys []*k
 	}
 	//verify decrypted shares
 	for i := 0; i < tr; i++ {
-		errDLEQ := elgamal.DLEQVerify(curve, DLEQproofs[i], curve.Point().Base(), commonCiphertext.PointA, *verkeys[i], decryptShares[i])
+		errDLEQ := elgamal.DLEQVerify(curve, dleqContext, DLEQproofs[i], curve.Point().Base(), commonCiphertext.PointA, *verkeys[i], decryptShares[i])
 		if errDLEQ != nil {
 			t.Errorf("DLEQ proof isn't verified with error %q", errDLEQ)
 		}
@@ -86,7 +86,10 @@ func elGamalPositive(t *testing.T, shares []*kyberDKG.DistKeyShare, verkeys []*k
 	for i := 0; i < tr; i++ {
 		pubShares = append(pubShares, &share.PubShare{I: i, V: decryptShares[i]})
 	}
-	decryptedMessage := elgamal.Decrypt(curve, commonCiphertext, pubShares, n)
+	decryptedMessage, err := elgamal.Decrypt(curve, commonCiphertext, pubShares, tr, n)
+	if err != nil {
+		t.Fatalf("can't decrypt: %v", err)
+	}
 
 	expectedMessage := curve.Point().Null()
 	for i := range newMessages {
@@ -99,6 +102,52 @@ func elGamalPositive(t *testing.T, shares []*kyberDKG.DistKeyShare, verkeys []*k
 	}
 }
 
+func Test_Decrypt_Threshold(t *testing.T) {
+	n, tr := 5, 3
+	curve := nist.NewBlakeSHA256P256()
+	keyShares, _, err := dkg.RabinDKGSimulator("P256", n, tr)
+	if err != nil {
+		t.Fatalf("DKG failed with error %q", err)
+	}
+	ct, message, _, err := encCiphertext(curve, keyShares[0].Public())
+	if err != nil {
+		t.Fatalf("can't encrypt message: %v", err)
+	}
+	pubShares := make([]*share.PubShare, n)
+	for i := range pubShares {
+		ds, _, err := elgamal.CreateDecShare(curve, dleqContext, ct, keyShares[i].PriShare().V)
+		if err != nil {
+			t.Fatalf("can't create decryption share: %v", err)
+		}
+		pubShares[i] = &share.PubShare{I: keyShares[i].PriShare().I, V: ds}
+	}
+
+	if _, err := elgamal.Decrypt(curve, ct, pubShares[:tr-1], tr, n); err == nil {
+		t.Errorf("decrypted with fewer shares than the threshold")
+	}
+	for _, shares := range [][]*share.PubShare{pubShares[:tr], pubShares[n-tr:], pubShares} {
+		decrypted, err := elgamal.Decrypt(curve, ct, shares, tr, n)
+		if err != nil {
+			t.Fatalf("can't decrypt with %d shares: %v", len(shares), err)
+		}
+		if !decrypted.Equal(message) {
+			t.Errorf("wrong message decrypted with %d shares", len(shares))
+		}
+	}
+	if _, err := elgamal.Decrypt(curve, ct, append(pubShares[:tr:tr], pubShares[0]), tr, n); err == nil {
+		t.Errorf("decrypted with duplicate shares")
+	}
+
+	tampered := make([]*share.PubShare, n)
+	copy(tampered, pubShares)
+	tampered[n-1] = &share.PubShare{I: pubShares[n-1].I, V: curve.Point().Add(pubShares[n-1].V, curve.Point().Base())}
+	_, err = elgamal.Decrypt(curve, ct, tampered, tr, n)
+	inconsistent, ok := err.(*elgamal.InconsistentSharesError)
+	if !ok || len(inconsistent.Indices) != 1 || inconsistent.Indices[0] != pubShares[n-1].I {
+		t.Errorf("inconsistent share isn't reported: %v", err)
+	}
+}
+
 func encCiphertext(group proof.Suite, commonKey kyber.Point) (ct elgamal.Ciphertext, M kyber.Point, CEproof []byte, err error) {
 	y := group.Scalar().Pick(random.New())
 	M = group.Point().Mul(y, nil)
@@ -106,8 +155,8 @@ func encCiphertext(group proof.Suite, commonKey kyber.Point) (ct elgamal.Ciphert
 	S := group.Point().Mul(r, commonKey)
 	A := group.Point().Mul(r, nil)
 	B := S.Add(group.Point().Mul(r, commonKey), M)
-	ct = elgamal.Ciphertext{A, B}
-	CEproof, err = elgamal.CE(group, group.Point().Base(), commonKey, ct.PointA, ct.PointB, r, y)
+	ct = elgamal.Ciphertext{PointA: A, PointB: B}
+	CEproof, err = elgamal.CE(group, ceContext, group.Point().Base(), commonKey, ct.PointA, ct.PointB, r, y)
 	if err != nil {
 		return
 	}
@@ -7,6 +7,12 @@ import (
 	"go.dedis.ch/kyber/v3/group/nist"
 )
 
+// contexts of the test proofs
+var (
+	ceContext   = elgamal.ProofContext(elgamal.ContextCE, "test-chain", "", 0, "sender")
+	dleqContext = elgamal.ProofContext(elgamal.ContextDLEQ, "test-chain", "", 0, "sender")
+)
+
 func Test_CEproof_Positive(t *testing.T) {
 	suite := nist.NewBlakeSHA256P256()
 	G := suite.Point().Base()
@@ -18,11 +24,11 @@ func Test_CEproof_Positive(t *testing.T) {
 			x := suite.Scalar().SetInt64(y - 1)
 			A := suite.Point().Mul(r, G)
 			B := suite.Point().Add(suite.Point().Mul(r, Q), suite.Point().Mul(x, G))
-			CEproof, err := elgamal.CE(suite, G, Q, A, B, r, x)
+			CEproof, err := elgamal.CE(suite, ceContext, G, Q, A, B, r, x)
 			if err != nil {
 				t.Errorf("can't doing ZKProof with error %q", err)
 			}
-			res := elgamal.CEVerify(suite, G, Q, A, B, CEproof)
+			res := elgamal.CEVerify(suite, ceContext, G, Q, A, B, CEproof)
 			if res != nil {
 				t.Errorf("Zkproof isn't valid because of %q", res)
 			}
@@ -38,14 +44,50 @@ func Test_DLEQproof_Positive(t *testing.T) {
 	for _, y := range testCases {
 		t.Run("start", func(t *testing.T) {
 			x := suite.Scalar().SetInt64(y)
-			DLEQproof, xB, xX, err := elgamal.DLEQ(suite, B, X, x)
+			DLEQproof, xB, xX, err := elgamal.DLEQ(suite, dleqContext, B, X, x)
 			if err != nil {
 				t.Errorf("can't doing ZKProof with error %q", err)
 			}
-			res := elgamal.DLEQVerify(suite, DLEQproof, B, X, xB, xX)
+			res := elgamal.DLEQVerify(suite, dleqContext, DLEQproof, B, X, xB, xX)
 			if res != nil {
 				t.Errorf("Zkproof isn't valid because of %q", res)
 			}
 		})
 	}
 }
+
+func Test_Proofs_WrongContext(t *testing.T) {
+	suite := nist.NewBlakeSHA256P256()
+	G := suite.Point().Base()
+	Q := suite.Point().Mul(suite.Scalar().SetInt64(25), G)
+	r := suite.Scalar().SetInt64(7)
+	x := suite.Scalar().SetInt64(11)
+	A := suite.Point().Mul(r, G)
+	B := suite.Point().Add(suite.Point().Mul(r, Q), suite.Point().Mul(x, G))
+	CEproof, err := elgamal.CE(suite, ceContext, G, Q, A, B, r, x)
+	if err != nil {
+		t.Fatalf("can't doing ZKProof with error %q", err)
+	}
+	DLEQproof, xG, xQ, err := elgamal.DLEQ(suite, dleqContext, G, Q, x)
+	if err != nil {
+		t.Fatalf("can't doing ZKProof with error %q", err)
+	}
+	wrongContexts := [][]byte{
+		elgamal.ProofContext(elgamal.ContextCE, "other-chain", "", 0, "sender"),
+		elgamal.ProofContext(elgamal.ContextCE, "test-chain", "other", 0, "sender"),
+		elgamal.ProofContext(elgamal.ContextCE, "test-chain", "", 1, "sender"),
+		elgamal.ProofContext(elgamal.ContextCE, "test-chain", "", 0, "other"),
+		dleqContext,
+	}
+	for _, context := range wrongContexts {
+		if elgamal.CEVerify(suite, context, G, Q, A, B, CEproof) == nil {
+			t.Errorf("CE proof is valid under the wrong context %q", context)
+		}
+	}
+	wrongContexts[len(wrongContexts)-1] = ceContext
+	for _, context := range wrongContexts {
+		if elgamal.DLEQVerify(suite, context, DLEQproof, G, Q, xG, xQ) == nil {
+			t.Errorf("DLEQ proof is valid under the wrong context %q", context)
+		}
+	}
+}
@@ -1,33 +1,95 @@
 package elgamal
 
 import (
+	"encoding/binary"
+	"errors"
+
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/proof"
 	"go.dedis.ch/kyber/v3/proof/dleq"
 )
 
-func DLEQ(group proof.Suite, B kyber.Point, X kyber.Point, x kyber.Scalar) (DLEQproof *dleq.Proof, xB kyber.Point, xX kyber.Point, err error) {
-	DLEQproof, xB, xX, err = dleq.NewDLEQProof(group, B, X, x)
-	return
+// proof kinds of the proof context
+const (
+	ContextCE   = "CE"
+	ContextDLEQ = "DLEQ"
+)
+
+// ProofContext returns the Fiat-Shamir context of the share proof, so the proof is valid only for the sender's share
+// of the given round in the given chain and beacon instance and can't be replayed by anyone else
+func ProofContext(kind string, chainID string, instance string, round uint64, sender string) []byte {
+	ctx := []byte("HERB/")
+	for _, field := range []string{kind, chainID, instance, sender} {
+		ctx = appendField(ctx, []byte(field))
+	}
+	roundBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(roundBytes, round)
+	return append(ctx, roundBytes...)
+}
+
+// appendField appends the length-prefixed field, so the fields can't be shifted into each other
+func appendField(bz []byte, field []byte) []byte {
+	lenBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(lenBytes, uint32(len(field)))
+	return append(append(bz, lenBytes...), field...)
 }
-func CE(group proof.Suite, G, Q, A, B kyber.Point, r, x kyber.Scalar) (CEproof []byte, err error) {
+
+// DLEQ proves that xB and xX have the same discrete logarithm x, the challenge is bound to the context
+func DLEQ(group proof.Suite, context []byte, B kyber.Point, X kyber.Point, x kyber.Scalar) (DLEQproof *dleq.Proof, xB kyber.Point, xX kyber.Point, err error) {
+	xB = group.Point().Mul(x, B)
+	xX = group.Point().Mul(x, X)
+	v := group.Scalar().Pick(group.RandomStream())
+	vB := group.Point().Mul(v, B)
+	vX := group.Point().Mul(v, X)
+	c, err := dleqChallenge(group, context, xB, xX, vB, vX)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	r := group.Scalar()
+	r.Mul(x, c).Sub(v, r)
+	return &dleq.Proof{C: c, R: r, VG: vB, VH: vX}, xB, xX, nil
+}
+
+func CE(group proof.Suite, context []byte, G, Q, A, B kyber.Point, r, x kyber.Scalar) (CEproof []byte, err error) {
 	predCE := proof.And(proof.Rep("A", "r", "G"), proof.Rep("B", "r", "Q", "x", "G"))
 	sval := map[string]kyber.Scalar{"r": r, "x": x}
 	pval := map[string]kyber.Point{"A": A, "G": G, "B": B, "Q": Q}
 	prover := predCE.Prover(group, sval, pval, nil)
-	CEproof, err = proof.HashProve(group, "CE", prover)
+	CEproof, err = proof.HashProve(group, string(context), prover)
 	return
 }
 
-func DLEQVerify(group proof.Suite, DLEQproof *dleq.Proof, B kyber.Point, X kyber.Point, xB kyber.Point, xX kyber.Point) (err error) {
-	err = DLEQproof.Verify(group, B, X, xB, xX)
-	return
+// DLEQVerify checks the DLEQ proof, the challenge is recomputed from the commitments and the context
+func DLEQVerify(group proof.Suite, context []byte, DLEQproof *dleq.Proof, B kyber.Point, X kyber.Point, xB kyber.Point, xX kyber.Point) (err error) {
+	if DLEQproof == nil || DLEQproof.C == nil || DLEQproof.R == nil || DLEQproof.VG == nil || DLEQproof.VH == nil {
+		return errors.New("DLEQ proof is incomplete")
+	}
+	c, err := dleqChallenge(group, context, xB, xX, DLEQproof.VG, DLEQproof.VH)
+	if err != nil {
+		return err
+	}
+	if !c.Equal(DLEQproof.C) {
+		return errors.New("DLEQ proof challenge doesn't match the context")
+	}
+	return DLEQproof.Verify(group, B, X, xB, xX)
 }
 
-func CEVerify(group proof.Suite, G, Q, A, B kyber.Point, CEproof []byte) (err error) {
+func CEVerify(group proof.Suite, context []byte, G, Q, A, B kyber.Point, CEproof []byte) (err error) {
 	predCE := proof.And(proof.Rep("A", "r", "G"), proof.Rep("B", "r", "Q", "x", "G"))
 	pval := map[string]kyber.Point{"A": A, "G": G, "B": B, "Q": Q}
 	verifier := predCE.Verifier(group, pval)
-	err = proof.HashVerify(group, "CE", verifier, CEproof)
+	err = proof.HashVerify(group, string(context), verifier, CEproof)
 	return
 }
+
+// dleqChallenge hashes the context with the statement and the commitments
+func dleqChallenge(group proof.Suite, context []byte, xB, xX, vB, vX kyber.Point) (kyber.Scalar, error) {
+	h := group.Hash()
+	h.Write(appendField(nil, context))
+	for _, p := range []kyber.Point{xB, xX, vB, vX} {
+		if _, err := p.MarshalTo(h); err != nil {
+			return nil, err
+		}
+	}
+	return group.Scalar().Pick(group.XOF(h.Sum(nil))), nil
+}
@@ -1,18 +1,19 @@
 package herb
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
-	"flag"
-	"log"
-	"net/http"
+	"fmt"
 
+	"github.com/corestario/HERB/x/herb/elgamal"
 	"github.com/corestario/HERB/x/herb/types"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	abci "github.com/tendermint/tendermint/abci/types"
 
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
 	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
 )
 
@@ -24,12 +25,23 @@ func NewGenesisState(thresholdCiphertexts uint64, thresholdDecryption uint64) Ge
 		CommonPublicKey:      P256.Point().String(),
 		KeyHolders:           []types.VerificationKeyJSON{},
 		RoundData:            []types.RoundData{},
+		Params:               types.DefaultParams(),
+		Requests:             []types.RandomnessRequest{},
+		EncryptionKeys:       []types.EncryptionKey{},
+		MissCounters:         []types.MissCounter{},
+		EntropyProviders:     []types.EntropyProvider{},
+		Instances:            []types.InstanceGenesisState{},
 	}
 }
 
 // ValidateGenesis validates the provided herb genesis state to ensure the
 // expected invariants holds.
 func ValidateGenesis(data GenesisState) error {
+	return validateGenesis(data, types.DefaultInstance)
+}
+
+// validateGenesis validates the genesis state of the beacon instance, share proofs are bound to the instance ID
+func validateGenesis(data GenesisState, instance string) error {
 	ctsThreshold := data.ThresholdCiphertexts
 	sharesThreshold := data.ThresholdDecryption
 	if ctsThreshold < 1 {
@@ -38,6 +50,9 @@ func ValidateGenesis(data GenesisState) error {
 	if sharesThreshold < 1 {
 		return errors.New("theshold for descryption shares must be positive")
 	}
+	if err := types.ValidateParams(data.Params); err != nil {
+		return err
+	}
 
 	if _, err := kyberenc.StringHexToPoint(types.P256, data.CommonPublicKey); err != nil {
 		return err
@@ -47,6 +62,446 @@ func ValidateGenesis(data GenesisState) error {
 			return errors.New(err2.Error())
 		}
 	}
+	if len(data.DKGParticipants) > 0 {
+		if len(data.KeyHolders) > 0 || len(data.KeyEpochs) > 0 {
+			return errors.New("genesis can't contain both key holders and DKG participants")
+		}
+		if sharesThreshold > uint64(len(data.DKGParticipants)) {
+			return fmt.Errorf("decryption threshold %d is greater than the number of DKG participants %d", sharesThreshold, len(data.DKGParticipants))
+		}
+		if _, err2 := types.DKGParticipantArrayDeserialize(data.DKGParticipants); err2 != nil {
+			return errors.New(err2.Error())
+		}
+	}
+	if err := validateRounds(data, instance); err != nil {
+		return err
+	}
+	if err := validateRequests(data); err != nil {
+		return err
+	}
+	if err := validateEncryptionKeys(data.EncryptionKeys); err != nil {
+		return err
+	}
+	if err := validateMissCounters(data.MissCounters); err != nil {
+		return err
+	}
+	if err := validateEntropyProviders(data); err != nil {
+		return err
+	}
+	if err := validateKeysChange(data); err != nil {
+		return err
+	}
+	if data.Refresh != nil {
+		if err := validateRefresh(data); err != nil {
+			return err
+		}
+	}
+	return validateInstances(data.Instances)
+}
+
+// validateRequests checks that the randomness requests are numbered from 0 and bound to the started or the next round,
+// requests of the failed rounds must be moved to the next round
+func validateRequests(data GenesisState) error {
+	for i, request := range data.Requests {
+		if request.ID != uint64(i) {
+			return fmt.Errorf("randomness request %v has ID %v", i, request.ID)
+		}
+		if request.Requester.Empty() {
+			return fmt.Errorf("randomness request %v has no requester", request.ID)
+		}
+		if !request.Fee.IsValid() {
+			return fmt.Errorf("randomness request %v has invalid fee %v", request.ID, request.Fee)
+		}
+		if len(data.RoundData) == 0 || request.Round > data.CurrentRound+1 {
+			return fmt.Errorf("randomness request %v is bound to round %v, current round is %v", request.ID, request.Round, data.CurrentRound)
+		}
+		if request.Round <= data.CurrentRound && data.RoundData[request.Round].Stage == stageFailed {
+			return fmt.Errorf("randomness request %v is bound to the failed round %v", request.ID, request.Round)
+		}
+	}
+	return nil
+}
+
+// validateEncryptionKeys checks that the encryption keys are valid points registered once per address
+func validateEncryptionKeys(keys []types.EncryptionKey) error {
+	addrs := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key.Address.Empty() {
+			return errors.New("encryption key has no address")
+		}
+		if addrs[key.Address.String()] {
+			return fmt.Errorf("duplicate encryption key of %v", key.Address)
+		}
+		addrs[key.Address.String()] = true
+		if err := types.ValidateEncryptionKey(key.Key); err != nil {
+			return fmt.Errorf("encryption key of %v: %v", key.Address, err)
+		}
+	}
+	return nil
+}
+
+// validateMissCounters checks that the miss counters are non-negative and stored once per address
+func validateMissCounters(counters []types.MissCounter) error {
+	addrs := make(map[string]bool, len(counters))
+	for _, counter := range counters {
+		if counter.Address.Empty() {
+			return errors.New("miss counter has no address")
+		}
+		if addrs[counter.Address.String()] {
+			return fmt.Errorf("duplicate miss counter of %v", counter.Address)
+		}
+		addrs[counter.Address.String()] = true
+		if counter.MissedRounds < 0 || counter.InvalidShares < 0 || counter.Penalties < 0 {
+			return fmt.Errorf("miss counter of %v is negative", counter.Address)
+		}
+	}
+	return nil
+}
+
+// validateEntropyProviders checks that the entropy providers are registered once with valid bonds and fit the registry size
+func validateEntropyProviders(data GenesisState) error {
+	if max := data.Params.MaxEntropyProviders; max > 0 && len(data.EntropyProviders) > int(max) {
+		return fmt.Errorf("%v entropy providers exceed the registry size %v", len(data.EntropyProviders), max)
+	}
+	addrs := make(map[string]bool, len(data.EntropyProviders))
+	for _, provider := range data.EntropyProviders {
+		if provider.Address.Empty() {
+			return errors.New("entropy provider has no address")
+		}
+		if addrs[provider.Address.String()] {
+			return fmt.Errorf("duplicate entropy provider %v", provider.Address)
+		}
+		addrs[provider.Address.String()] = true
+		if !provider.Bond.IsValid() {
+			return fmt.Errorf("entropy provider %v has invalid bond %v", provider.Address, provider.Bond)
+		}
+	}
+	return nil
+}
+
+// validateKeysChange checks the pending keys of the new key holders set and the key holders change in progress,
+// they can't be defined at the same time
+func validateKeysChange(data GenesisState) error {
+	if (data.PendingKeyEpoch != nil || data.KeyHoldersChange != nil) && len(data.KeyHolders) == 0 {
+		return errors.New("key holders change requires the current key holders")
+	}
+	if epoch := data.PendingKeyEpoch; epoch != nil {
+		if _, err := kyberenc.StringHexToPoint(types.P256, epoch.CommonPublicKey); err != nil {
+			return fmt.Errorf("pending key epoch: %v", err)
+		}
+		if _, err := types.VerificationKeyArrayDeserialize(epoch.KeyHolders); err != nil {
+			return fmt.Errorf("pending key epoch: %v", err)
+		}
+		if epoch.ThresholdCiphertexts < 1 || epoch.ThresholdDecryption < 1 || epoch.ThresholdDecryption > uint64(len(epoch.KeyHolders)) {
+			return fmt.Errorf("pending key epoch has wrong thresholds %v, %v", epoch.ThresholdCiphertexts, epoch.ThresholdDecryption)
+		}
+	}
+	if change := data.KeyHoldersChange; change != nil {
+		if data.PendingKeyEpoch != nil {
+			return errors.New("key holders change can't run while the new keys are pending")
+		}
+		if _, err := types.DKGParticipantArrayDeserialize(change.Participants); err != nil {
+			return fmt.Errorf("key holders change: %v", err)
+		}
+		if change.ThresholdCiphertexts < 1 || change.ThresholdDecryption < 1 || change.ThresholdDecryption > uint64(len(change.Participants)) {
+			return fmt.Errorf("key holders change has wrong thresholds %v, %v", change.ThresholdCiphertexts, change.ThresholdDecryption)
+		}
+	}
+	return nil
+}
+
+// validateRefresh checks the last key refresh, messages of the running refresh must come from the current key holders
+func validateRefresh(data GenesisState) error {
+	refresh := data.Refresh
+	if refresh.Number == 0 {
+		return errors.New("refresh number must be positive")
+	}
+	switch refresh.Phase {
+	case types.RefreshPhaseNone, types.RefreshPhaseDeals, types.RefreshPhaseComplaints, types.RefreshPhaseJustifications, types.RefreshPhasePending:
+	default:
+		return fmt.Errorf("unknown refresh phase %q", refresh.Phase)
+	}
+	if (refresh.Phase == types.RefreshPhasePending) != (len(refresh.PendingKeys) > 0) {
+		return fmt.Errorf("refreshed verification keys don't match the refresh phase %v", refresh.Phase)
+	}
+	if len(refresh.PendingKeys) > 0 {
+		if len(refresh.PendingKeys) != len(data.KeyHolders) {
+			return fmt.Errorf("%v refreshed verification keys for %v key holders", len(refresh.PendingKeys), len(data.KeyHolders))
+		}
+		if _, err := types.VerificationKeyArrayDeserialize(refresh.PendingKeys); err != nil {
+			return fmt.Errorf("refreshed verification keys: %v", err)
+		}
+	}
+	if refresh.Phase == types.RefreshPhaseNone {
+		return nil
+	}
+	if data.PendingKeyEpoch != nil || data.KeyHoldersChange != nil {
+		return errors.New("refresh can't run while the key holders set is changed")
+	}
+	holders := make(map[string]bool, len(data.KeyHolders))
+	for _, kh := range data.KeyHolders {
+		holders[kh.Sender.String()] = true
+	}
+	dealers := make(map[string]bool, len(refresh.Deals))
+	for _, deal := range refresh.Deals {
+		if !holders[deal.Dealer.String()] || dealers[deal.Dealer.String()] {
+			return fmt.Errorf("unexpected refresh deal of %v", deal.Dealer)
+		}
+		dealers[deal.Dealer.String()] = true
+		if _, err := deal.Deal.PubPoly(int(data.ThresholdDecryption), len(data.KeyHolders)); err != nil {
+			return fmt.Errorf("refresh deal of %v: %v", deal.Dealer, err)
+		}
+	}
+	senders := make(map[string]bool, len(refresh.Complaints))
+	for _, complaint := range refresh.Complaints {
+		if !holders[complaint.Sender.String()] || senders[complaint.Sender.String()] {
+			return fmt.Errorf("unexpected refresh complaint of %v", complaint.Sender)
+		}
+		senders[complaint.Sender.String()] = true
+		for _, dealer := range complaint.Dealers {
+			if !dealers[dealer.String()] {
+				return fmt.Errorf("refresh complaint of %v about %v who has no deal", complaint.Sender, dealer)
+			}
+		}
+	}
+	justified := make(map[string]bool, len(refresh.Justifications))
+	for _, j := range refresh.Justifications {
+		if !dealers[j.Dealer.String()] || justified[j.Dealer.String()] {
+			return fmt.Errorf("unexpected refresh justification of %v", j.Dealer)
+		}
+		justified[j.Dealer.String()] = true
+	}
+	return nil
+}
+
+// validateInstances checks the genesis states of the beacon instances other than the default one
+func validateInstances(instances []types.InstanceGenesisState) error {
+	ids := make(map[string]bool, len(instances))
+	for _, instance := range instances {
+		if err := types.ValidateInstanceID(instance.ID); err != nil {
+			return errors.New(err.Error())
+		}
+		if instance.ID == types.DefaultInstance {
+			return errors.New("the default beacon instance is defined by the genesis itself")
+		}
+		if ids[instance.ID] {
+			return fmt.Errorf("duplicate beacon instance %q", instance.ID)
+		}
+		ids[instance.ID] = true
+		if len(instance.State.Instances) > 0 {
+			return fmt.Errorf("beacon instance %q can't contain instances", instance.ID)
+		}
+		if err := validateGenesis(instance.State, instance.ID); err != nil {
+			return fmt.Errorf("beacon instance %q: %v", instance.ID, err)
+		}
+	}
+	return nil
+}
+
+// genesisEpoch is the key epoch with the parsed keys
+type genesisEpoch struct {
+	types.KeyEpoch
+	commonKey  kyber.Point
+	keyHolders map[string]*types.VerificationKey
+}
+
+// validateRounds checks the exported rounds: stages, shares with their proofs, aggregated ciphertexts and results
+func validateRounds(data GenesisState, instance string) error {
+	var epochs []*genesisEpoch
+	if len(data.KeyEpochs) > 0 {
+		var err error
+		if epochs, err = parseGenesisEpochs(data); err != nil {
+			return err
+		}
+	}
+	if len(data.RoundData) == 0 {
+		if data.CurrentRound != 0 || data.PrunedRounds != 0 {
+			return fmt.Errorf("current round %v has no round data", data.CurrentRound)
+		}
+		return nil
+	}
+	if len(data.DKGParticipants) > 0 {
+		return errors.New("genesis with DKG participants can't contain round data")
+	}
+	if uint64(len(data.RoundData)) != data.CurrentRound+1 {
+		return fmt.Errorf("round data must contain rounds from 0 to the current round %v, got %v rounds", data.CurrentRound, len(data.RoundData))
+	}
+	if data.PrunedRounds > data.CurrentRound {
+		return fmt.Errorf("pruned rounds %v exceed the current round %v", data.PrunedRounds, data.CurrentRound)
+	}
+	if len(epochs) == 0 {
+		return errors.New("round data requires key epochs")
+	}
+	stageOf := func(round uint64) string {
+		if round < uint64(len(data.RoundData)) {
+			return data.RoundData[round].Stage
+		}
+		return stageUnstarted
+	}
+	lastEpoch := uint64(0)
+	for i, rd := range data.RoundData {
+		if rd.Round != uint64(i) {
+			return fmt.Errorf("round data %v has round %v", i, rd.Round)
+		}
+		if int(rd.Epoch) >= len(epochs) {
+			return fmt.Errorf("round %v: key epoch %v doesn't exist", rd.Round, rd.Epoch)
+		}
+		epoch := epochs[rd.Epoch]
+		if rd.Epoch < lastEpoch || epoch.StartRound > rd.Round {
+			return fmt.Errorf("round %v can't use key epoch %v", rd.Round, rd.Epoch)
+		}
+		lastEpoch = rd.Epoch
+		if err := checkRoundStage(rd.Round, data.CurrentRound, stageOf, data.Params.OnDemandRounds); err != nil {
+			return err
+		}
+		// the round may have enough ciphertext shares while it waits for the decryption of the previous one
+		waiting := rd.Round > 0 && stageOf(rd.Round-1) == stageDSCollecting
+		if err := validateRoundData(rd, epoch, instance, rd.Round < data.PrunedRounds, waiting); err != nil {
+			return fmt.Errorf("round %v: %v", rd.Round, err)
+		}
+	}
+	return nil
+}
+
+// parseGenesisEpochs checks that the key epochs are numbered from 0 and the last one holds the current keys
+func parseGenesisEpochs(data GenesisState) ([]*genesisEpoch, error) {
+	epochs := make([]*genesisEpoch, len(data.KeyEpochs))
+	for i, e := range data.KeyEpochs {
+		if e.Number != uint64(i) {
+			return nil, fmt.Errorf("key epoch %v has number %v", i, e.Number)
+		}
+		if i > 0 && e.StartRound < data.KeyEpochs[i-1].StartRound {
+			return nil, fmt.Errorf("key epoch %v starts before the previous one", i)
+		}
+		commonKey, err := kyberenc.StringHexToPoint(types.P256, e.CommonPublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("key epoch %v: %v", i, err)
+		}
+		vkList, err1 := types.VerificationKeyArrayDeserialize(e.KeyHolders)
+		if err1 != nil {
+			return nil, fmt.Errorf("key epoch %v: %v", i, err1)
+		}
+		keyHolders := make(map[string]*types.VerificationKey, len(vkList))
+		for _, vk := range vkList {
+			keyHolders[vk.Sender.String()] = vk
+		}
+		epochs[i] = &genesisEpoch{KeyEpoch: e, commonKey: commonKey, keyHolders: keyHolders}
+	}
+	last := data.KeyEpochs[len(data.KeyEpochs)-1]
+	if last.CommonPublicKey != data.CommonPublicKey || len(last.KeyHolders) != len(data.KeyHolders) ||
+		last.ThresholdCiphertexts != data.ThresholdCiphertexts || last.ThresholdDecryption != data.ThresholdDecryption {
+		return nil, errors.New("the last key epoch doesn't match the current keys and thresholds")
+	}
+	return epochs, nil
+}
+
+// validateRoundData checks the round against the keys of its epoch, the pruned rounds keep the result and the transcript hash only
+func validateRoundData(rd types.RoundData, epoch *genesisEpoch, instance string, pruned bool, waiting bool) error {
+	if rd.Stage != stageFailed && rd.FailReason != "" {
+		return errors.New("fail reason of the round which isn't failed")
+	}
+	if rd.Stage != stageCompleted && len(rd.Result) > 0 {
+		return errors.New("result of the round which isn't completed")
+	}
+	if rd.Stage == stageCompleted && len(rd.Result) == 0 {
+		return errors.New("completed round has no result")
+	}
+	if pruned {
+		if len(rd.CiphertextShares) > 0 || len(rd.DecryptionShares) > 0 || rd.AggregatedCiphertext != nil {
+			return errors.New("pruned round can't contain shares")
+		}
+		return nil
+	}
+	if len(rd.TranscriptHash) > 0 {
+		return errors.New("transcript hash of the round which isn't pruned")
+	}
+
+	cts := make([]elgamal.Ciphertext, 0, len(rd.CiphertextShares))
+	providers := make(map[string]bool, len(rd.CiphertextShares))
+	accepted := make(map[string]bool, len(rd.CiphertextShares))
+	for _, ctJSON := range rd.CiphertextShares {
+		ct, err := ctJSON.Deserialize()
+		if err != nil {
+			return err
+		}
+		if providers[ct.EntropyProvider.String()] {
+			return fmt.Errorf("several ciphertext shares of %v", ct.EntropyProvider)
+		}
+		providers[ct.EntropyProvider.String()] = true
+		if accepted[ct.Ciphertext.String()] {
+			return fmt.Errorf("ciphertext of %v is sent several times", ct.EntropyProvider)
+		}
+		accepted[ct.Ciphertext.String()] = true
+		context := elgamal.ProofContext(elgamal.ContextCE, rd.ChainID, instance, rd.Round, ct.EntropyProvider.String())
+		if err := elgamal.CEVerify(P256, context, P256.Point().Base(), epoch.commonKey, ct.Ciphertext.PointA, ct.Ciphertext.PointB, ct.CEproof); err != nil {
+			return fmt.Errorf("CE proof of %v isn't correct: %v", ct.EntropyProvider, err)
+		}
+		cts = append(cts, ct.Ciphertext)
+	}
+	ctsCollected := uint64(len(cts)) >= epoch.ThresholdCiphertexts
+	if (rd.Stage == stageCtCollecting && ctsCollected && !waiting) || ((rd.Stage == stageDSCollecting || rd.Stage == stageCompleted) && !ctsCollected) {
+		return fmt.Errorf("%v ciphertext shares don't match the stage %v", len(cts), rd.Stage)
+	}
+	if len(cts) == 0 {
+		if rd.AggregatedCiphertext != nil {
+			return errors.New("aggregated ciphertext without ciphertext shares")
+		}
+		if len(rd.DecryptionShares) > 0 {
+			return errors.New("decryption shares without ciphertext shares")
+		}
+		return nil
+	}
+	if rd.AggregatedCiphertext == nil {
+		return errors.New("aggregated ciphertext is missing")
+	}
+	aggCt, err := rd.AggregatedCiphertext.Deserialize(P256)
+	if err != nil {
+		return fmt.Errorf("can't decode aggregated ciphertext: %v", err)
+	}
+	if !aggCt.Equal(elgamal.AggregateCiphertext(P256, cts)) {
+		return errors.New("aggregated ciphertext isn't the sum of the ciphertext shares")
+	}
+
+	if rd.Stage == stageCtCollecting && len(rd.DecryptionShares) > 0 {
+		return errors.New("decryption shares on the ciphertext collecting stage")
+	}
+	dsList := make([]*share.PubShare, 0, len(rd.DecryptionShares))
+	holders := make(map[string]bool, len(rd.DecryptionShares))
+	for _, dsJSON := range rd.DecryptionShares {
+		ds, err := dsJSON.Deserialize()
+		if err != nil {
+			return err
+		}
+		if holders[ds.KeyHolderAddr.String()] {
+			return fmt.Errorf("several decryption shares of %v", ds.KeyHolderAddr)
+		}
+		holders[ds.KeyHolderAddr.String()] = true
+		vk, ok := epoch.keyHolders[ds.KeyHolderAddr.String()]
+		if !ok {
+			return fmt.Errorf("%v isn't a key holder of the epoch %v", ds.KeyHolderAddr, epoch.Number)
+		}
+		if ds.DecShare.I != vk.KeyHolderID {
+			return fmt.Errorf("decryption share of %v has index %v, key holder ID is %v", ds.KeyHolderAddr, ds.DecShare.I, vk.KeyHolderID)
+		}
+		context := elgamal.ProofContext(elgamal.ContextDLEQ, rd.ChainID, instance, rd.Round, ds.KeyHolderAddr.String())
+		if err := elgamal.DLEQVerify(P256, context, ds.DLEQproof, P256.Point().Base(), aggCt.PointA, vk.Key, ds.DecShare.V); err != nil {
+			return fmt.Errorf("DLEQ proof of %v isn't correct: %v", ds.KeyHolderAddr, err)
+		}
+		dsList = append(dsList, &ds.DecShare)
+	}
+	dsCollected := uint64(len(dsList)) >= epoch.ThresholdDecryption
+	if (rd.Stage == stageDSCollecting && dsCollected) || (rd.Stage == stageCompleted && !dsCollected) {
+		return fmt.Errorf("%v decryption shares don't match the stage %v", len(dsList), rd.Stage)
+	}
+	if rd.Stage == stageCompleted {
+		result, err := decryptResult(*aggCt, dsList, epoch.ThresholdDecryption, len(epoch.KeyHolders))
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(result, rd.Result) {
+			return fmt.Errorf("result %X doesn't match the decrypted %X", rd.Result, result)
+		}
+	}
 	return nil
 }
 
@@ -58,63 +513,206 @@ func DefaultGenesisState() GenesisState {
 		CommonPublicKey:      P256.Point().String(),
 		KeyHolders:           []types.VerificationKeyJSON{},
 		RoundData:            []types.RoundData{},
+		Params:               types.DefaultParams(),
+		Requests:             []types.RandomnessRequest{},
+		EncryptionKeys:       []types.EncryptionKey{},
+		MissCounters:         []types.MissCounter{},
+		EntropyProviders:     []types.EntropyProvider{},
+		Instances:            []types.InstanceGenesisState{},
 	}
 }
 
 // InitGenesis sets the pool and parameters for the provided keeper.
 func InitGenesis(ctx sdk.Context, keeper Keeper, data GenesisState) []abci.ValidatorUpdate {
 	keyHolders := data.KeyHolders
+	keeper.setStoreVersion(ctx, storeVersion)
 
-	err := keeper.SetVerificationKeys(ctx, keyHolders)
-	if err != nil {
-		panic(err)
+	// with DKG participants keys and the number of key holders are set by the keeper after the DKG is completed
+	dkgMode := len(data.DKGParticipants) > 0
+	if dkgMode {
+		if err := keeper.StartDKG(ctx, data.DKGParticipants, data.ThresholdDecryption); err != nil {
+			panic(err)
+		}
+	} else {
+		err := keeper.SetVerificationKeys(ctx, keyHolders)
+		if err != nil {
+			panic(err)
+		}
 	}
-	var addr = flag.String("listen-address", ":8080", "The address to listen on for HTTP requests.")
-	srv := &http.Server{
-		Addr: *addr,
-		Handler: promhttp.InstrumentMetricHandler(
-			prometheus.DefaultRegisterer, promhttp.HandlerFor(
-				prometheus.DefaultGatherer,
-				promhttp.HandlerOpts{MaxRequestsInFlight: 3},
-			),
-		),
-	}
-	go func() {
-		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
-			log.Fatal(http.ListenAndServe(*addr, nil))
-		}
-	}()
-	keeper.SetKeyHoldersNumber(ctx, uint64(len(keyHolders)))
+	startMetricsServer()
+	keeper.SetParams(ctx, data.Params)
+	// creates the rewards pool module account if it doesn't exist
+	keeper.supplyKeeper.GetModuleAccount(ctx, ModuleName)
 	keeper.SetThreshold(ctx, data.ThresholdCiphertexts, data.ThresholdDecryption)
-	keeper.SetCommonPublicKey(ctx, data.CommonPublicKey)
-	keeper.setRound(ctx, uint64(0))
-	keeper.setStage(ctx, uint64(0), stageUnstarted)
+	if !dkgMode {
+		keeper.SetKeyHoldersNumber(ctx, uint64(len(keyHolders)))
+		keeper.SetCommonPublicKey(ctx, data.CommonPublicKey)
+		if len(data.KeyEpochs) == 0 {
+			keeper.setKeyEpoch(ctx, 0, 0)
+		}
+	}
+	importKeyEpochs(ctx, &keeper, data.KeyEpochs)
+	keeper.setRound(ctx, data.CurrentRound)
+	if len(data.RoundData) == 0 {
+		keeper.setStage(ctx, data.CurrentRound, stageUnstarted)
+	}
 	for _, rd := range data.RoundData {
-		for _, ctJSON := range rd.CiphertextShares {
-			ct, err := ctJSON.Deserialize()
-			if err != nil {
-				panic(err)
-			}
-			err = keeper.SetCiphertext(ctx, ct)
-			if err != nil {
-				panic(err)
-			}
+		importRound(ctx, &keeper, rd)
+	}
+	if data.PrunedRounds > 0 {
+		keeper.setPrunedRounds(ctx, data.PrunedRounds)
+	}
+	importRequests(ctx, &keeper, data.Requests)
+	for _, key := range data.EncryptionKeys {
+		keeper.setEncryptionKey(ctx, key.Address, key.Key)
+	}
+	for _, counter := range data.MissCounters {
+		keeper.setMissCounter(ctx, counter)
+	}
+	// bonds are imported with the bond pool account by the supply module
+	for _, provider := range data.EntropyProviders {
+		keeper.setEntropyProvider(ctx, provider)
+	}
+	keeper.setProvidersCount(ctx, uint64(len(data.EntropyProviders)))
+	if data.PendingKeyEpoch != nil {
+		keeper.store(ctx).Set([]byte(keyPendingEpoch), keeper.cdc.MustMarshalJSON(*data.PendingKeyEpoch))
+	}
+	if data.Refresh != nil {
+		importRefresh(ctx, &keeper, *data.Refresh)
+	}
+	// DKG messages aren't exported: the participants keep their DKG state in memory, so the DKG is started again
+	if change := data.KeyHoldersChange; change != nil {
+		if err := keeper.ChangeKeyHolders(ctx, change.Participants, change.ThresholdCiphertexts, change.ThresholdDecryption); err != nil {
+			panic(err)
 		}
-		for _, dsJSON := range rd.DecryptionShares {
-			ds, err := dsJSON.Deserialize()
-			if err != nil {
-				panic(err)
-			}
-			err = keeper.SetDecryptionShare(ctx, ds)
-			if err != nil {
-				panic(err)
-			}
+	}
+	for _, instance := range data.Instances {
+		if err := keeper.addInstance(ctx, instance.ID); err != nil {
+			panic(err)
 		}
+		InitGenesis(ctx, keeper.Instance(instance.ID), instance.State)
 	}
 	return []abci.ValidatorUpdate{}
 
 }
 
+// importKeyEpochs writes the exported key epochs, the last one becomes current
+func importKeyEpochs(ctx sdk.Context, k *Keeper, epochs []types.KeyEpoch) {
+	for _, epoch := range epochs {
+		k.storeKeyEpoch(ctx, epoch)
+	}
+	if len(epochs) > 0 && epochs[len(epochs)-1].StartRound > 0 {
+		roundBytes := make([]byte, 8)
+		binary.LittleEndian.PutUint64(roundBytes, epochs[len(epochs)-1].StartRound)
+		k.store(ctx).Set([]byte(keyVerificationKeysRound), roundBytes)
+	}
+}
+
+// importRequests writes the randomness requests with the requests index of their rounds
+func importRequests(ctx sdk.Context, k *Keeper, requests []types.RandomnessRequest) {
+	for _, request := range requests {
+		k.setRequest(ctx, request)
+		k.setRoundRequests(ctx, request.Round, append(k.roundRequests(ctx, request.Round), request.ID))
+	}
+	if len(requests) > 0 {
+		k.setNextRequestID(ctx, uint64(len(requests)))
+	}
+}
+
+// importRefresh writes the last key refresh with its messages, deadline of the refresh phase is counted from the genesis block
+func importRefresh(ctx sdk.Context, k *Keeper, refresh types.RefreshState) {
+	store := k.store(ctx)
+	numberBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(numberBytes, refresh.Number)
+	store.Set([]byte(keyRefreshNumber), numberBytes)
+	for _, deal := range refresh.Deals {
+		store.Set(createRefreshDealKey(deal.Dealer), k.cdc.MustMarshalJSON(deal.Deal))
+	}
+	for _, complaint := range refresh.Complaints {
+		dealers := complaint.Dealers
+		if dealers == nil {
+			dealers = []sdk.AccAddress{}
+		}
+		store.Set(createRefreshComplaintKey(complaint.Sender), k.cdc.MustMarshalJSON(dealers))
+	}
+	for _, j := range refresh.Justifications {
+		store.Set(createRefreshJustificationKey(j.Dealer), k.cdc.MustMarshalJSON(j.Justifications))
+	}
+	if len(refresh.QUAL) > 0 {
+		store.Set([]byte(keyRefreshQUAL), k.cdc.MustMarshalJSON(refresh.QUAL))
+	}
+	if len(refresh.PendingKeys) > 0 {
+		store.Set([]byte(keyPendingVerificationKeys), k.cdc.MustMarshalJSON(refresh.PendingKeys))
+	}
+	k.setRefreshPhase(ctx, refresh.Phase)
+}
+
+// importRound writes the exported round to the store as it is, proofs aren't replayed: the genesis is checked by ValidateGenesis.
+// Deadline of the round's stage is counted from the genesis block
+func importRound(ctx sdk.Context, k *Keeper, rd types.RoundData) {
+	store := k.store(ctx)
+	store.Set(createKeyBytesByRound(rd.Round, keyStage), []byte(rd.Stage))
+	heightBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(heightBytes, uint64(ctx.BlockHeight()))
+	store.Set(createKeyBytesByRound(rd.Round, keyStageHeight), heightBytes)
+	epochBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(epochBytes, rd.Epoch)
+	store.Set(createKeyBytesByRound(rd.Round, keyRoundEpoch), epochBytes)
+	if rd.ChainID != "" {
+		store.Set(createKeyBytesByRound(rd.Round, keyRoundChainID), []byte(rd.ChainID))
+	}
+	if rd.FailReason != "" {
+		store.Set(createKeyBytesByRound(rd.Round, keyFailReason), []byte(rd.FailReason))
+	}
+	if len(rd.TranscriptHash) > 0 {
+		store.Set(createKeyBytesByRound(rd.Round, keyTranscriptHash), rd.TranscriptHash)
+	}
+	if rd.Stage == stageCompleted {
+		store.Set(createKeyBytesByRound(rd.Round, keyRandomResult), rd.Result)
+		k.setLastCompletedRound(ctx, rd.Round)
+	}
+	if rd.AggregatedCiphertext != nil {
+		aggCt, err := rd.AggregatedCiphertext.Deserialize(P256)
+		if err != nil {
+			panic(err)
+		}
+		if err := k.SetAggregatedCiphertext(ctx, rd.Round, aggCt); err != nil {
+			panic(err)
+		}
+	}
+	ctStore := k.ctStore(ctx)
+	for _, ctJSON := range rd.CiphertextShares {
+		ct, err := ctJSON.Deserialize()
+		if err != nil {
+			panic(err)
+		}
+		ctBytes, err := types.EncodeCiphertextShare(ct)
+		if err != nil {
+			panic(err)
+		}
+		ctStore.Set(createKeyBytesByAddr(rd.Round, ct.EntropyProvider), ctBytes)
+		ciphertextBytes, err := types.EncodeCiphertext(&ct.Ciphertext)
+		if err != nil {
+			panic(err)
+		}
+		ctStore.Set(createCiphertextKey(rd.Round, ciphertextBytes), []byte{})
+		k.incSharesCount(ctStore, rd.Round)
+	}
+	dsStore := k.dsStore(ctx)
+	for _, dsJSON := range rd.DecryptionShares {
+		ds, err := dsJSON.Deserialize()
+		if err != nil {
+			panic(err)
+		}
+		dsBytes, err := types.EncodeDecryptionShare(ds)
+		if err != nil {
+			panic(err)
+		}
+		dsStore.Set(createKeyBytesByAddr(rd.Round, ds.KeyHolderAddr), dsBytes)
+		k.incSharesCount(dsStore, rd.Round)
+	}
+}
+
 // ExportGenesis returns a GenesisState for a given context and keeper.
 func ExportGenesis(ctx sdk.Context, k Keeper) GenesisState {
 	tp, err := k.GetThresholdCiphertexts(ctx)
@@ -125,6 +723,28 @@ func ExportGenesis(ctx sdk.Context, k Keeper) GenesisState {
 	if err != nil {
 		panic(err)
 	}
+	// the DKG is started from scratch if it wasn't completed and there are no keys yet
+	if !k.keysDefined(ctx) && k.GetDKGPhase(ctx) != types.DKGPhaseNone {
+		participants, err := k.GetDKGParticipants(ctx)
+		if err != nil {
+			panic(err)
+		}
+		providers, err := k.GetEntropyProviders(ctx)
+		if err != nil {
+			panic(err)
+		}
+		return GenesisState{
+			ThresholdCiphertexts: tp,
+			ThresholdDecryption:  k.GetDKGThreshold(ctx),
+			CommonPublicKey:      P256.Point().String(),
+			KeyHolders:           []types.VerificationKeyJSON{},
+			RoundData:            []types.RoundData{},
+			Params:               k.GetParams(ctx),
+			DKGParticipants:      participants,
+			EntropyProviders:     providers,
+			Instances:            exportInstances(ctx, k),
+		}
+	}
 	commonPK, err := k.GetCommonPublicKey(ctx)
 	if err != nil {
 		panic(err)
@@ -133,44 +753,190 @@ func ExportGenesis(ctx sdk.Context, k Keeper) GenesisState {
 	if err != nil {
 		panic(err)
 	}
-	var roundData []types.RoundData
-	lastRound := k.CurrentRound(ctx)
-	for i := uint64(0); i < lastRound; i++ {
-		var ctSharesJSON []*types.CiphertextShareJSON
-		ctShares, err := k.GetAllCiphertexts(ctx, i)
-		if err != nil {
-			panic(err)
-		}
-		for _, ct := range ctShares {
-			ctJSON, err := types.NewCiphertextShareJSON(ct)
-			if err != nil {
-				panic(err)
-			}
-			ctSharesJSON = append(ctSharesJSON, ctJSON)
-		}
-		var dSharesJSON []*types.DecryptionShareJSON
-		dShares, err := k.GetAllDecryptionShares(ctx, i)
+	var keyEpochs []types.KeyEpoch
+	for number := uint64(0); number <= k.CurrentKeyEpoch(ctx); number++ {
+		epoch, err := k.GetKeyEpoch(ctx, number)
 		if err != nil {
 			panic(err)
 		}
-		for _, ds := range dShares {
-			dsJSON, err := types.NewDecryptionShareJSON(ds)
-			if err != nil {
-				panic(err)
-			}
-			dSharesJSON = append(dSharesJSON, &dsJSON)
+		keyEpochs = append(keyEpochs, *epoch)
+	}
+	currentRound := k.CurrentRound(ctx)
+	roundData := []types.RoundData{}
+	// round 0 isn't started until the first ciphertext share
+	if k.GetStage(ctx, currentRound) != stageUnstarted {
+		for round := uint64(0); round <= currentRound; round++ {
+			roundData = append(roundData, exportRound(ctx, &k, round))
 		}
-		roundData = append(roundData, types.RoundData{ctSharesJSON, dSharesJSON})
 	}
 	cPK, err1 := kyberenc.PointToStringHex(P256, commonPK)
 	if err1 != nil {
 		panic(err1)
 	}
+	requests, err := k.GetAllRequests(ctx)
+	if err != nil {
+		panic(err)
+	}
+	providers, err := k.GetEntropyProviders(ctx)
+	if err != nil {
+		panic(err)
+	}
+	encKeys, err := k.GetEncryptionKeys(ctx)
+	if err != nil {
+		panic(err)
+	}
+	keyHoldersChange, err := exportKeyHoldersChange(ctx, &k)
+	if err != nil {
+		panic(err)
+	}
+	refresh, err := exportRefresh(ctx, &k)
+	if err != nil {
+		panic(err)
+	}
 	return GenesisState{
 		ThresholdCiphertexts: tp,
 		ThresholdDecryption:  td,
 		CommonPublicKey:      cPK,
 		KeyHolders:           keyHolders,
+		CurrentRound:         currentRound,
+		PrunedRounds:         k.prunedRounds(ctx),
+		KeyEpochs:            keyEpochs,
 		RoundData:            roundData,
+		Params:               k.GetParams(ctx),
+		Requests:             requests,
+		EncryptionKeys:       encKeys,
+		MissCounters:         k.getAllMissCounters(ctx),
+		EntropyProviders:     providers,
+		PendingKeyEpoch:      k.GetPendingKeyEpoch(ctx),
+		KeyHoldersChange:     keyHoldersChange,
+		Refresh:              refresh,
+		Instances:            exportInstances(ctx, k),
+	}
+}
+
+// exportKeyHoldersChange returns the new key holders set if its DKG is running, only the participants and thresholds are exported
+func exportKeyHoldersChange(ctx sdk.Context, k *Keeper) (*types.KeyHoldersChange, sdk.Error) {
+	if !k.dkgRunning(ctx) {
+		return nil, nil
+	}
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tBytes := k.store(ctx).Get([]byte(keyPendingThresholdCiphertexts))
+	if tBytes == nil {
+		return nil, sdk.ErrUnknownRequest("threshold for ciphertext shares of the new key holders isn't defined")
+	}
+	return &types.KeyHoldersChange{
+		Participants:         participants,
+		ThresholdCiphertexts: binary.LittleEndian.Uint64(tBytes),
+		ThresholdDecryption:  k.GetDKGThreshold(ctx),
+	}, nil
+}
+
+// exportRefresh returns the last key refresh with its messages, it's nil if there were no refreshes
+func exportRefresh(ctx sdk.Context, k *Keeper) (*types.RefreshState, sdk.Error) {
+	number := k.GetRefreshNumber(ctx)
+	if number == 0 {
+		return nil, nil
+	}
+	deals, err := k.GetRefreshDeals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	complaints, err := k.GetRefreshComplaints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	justifications, err := k.GetRefreshJustifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &types.RefreshState{
+		Number:         number,
+		Phase:          k.GetRefreshPhase(ctx),
+		Deals:          deals,
+		Complaints:     complaints,
+		Justifications: justifications,
+		QUAL:           k.GetRefreshQUAL(ctx),
+		PendingKeys:    k.GetPendingVerificationKeys(ctx),
+	}, nil
+}
+
+// exportInstances returns the genesis states of the beacon instances other than the default one,
+// it's empty when the keeper isn't the default instance's
+func exportInstances(ctx sdk.Context, k Keeper) []types.InstanceGenesisState {
+	instances := []types.InstanceGenesisState{}
+	if k.InstanceID() != types.DefaultInstance {
+		return instances
+	}
+	for _, id := range k.GetInstances(ctx)[1:] {
+		instances = append(instances, types.InstanceGenesisState{ID: id, State: ExportGenesis(ctx, k.Instance(id))})
+	}
+	return instances
+}
+
+// exportRound returns the round data, shares of the pruned round are replaced by its transcript hash
+func exportRound(ctx sdk.Context, k *Keeper, round uint64) types.RoundData {
+	stage := k.GetStage(ctx, round)
+	epochBytes := k.store(ctx).Get(createKeyBytesByRound(round, keyRoundEpoch))
+	if epochBytes == nil {
+		panic(fmt.Sprintf("round %v has no key epoch", round))
+	}
+	rd := types.RoundData{
+		Round:      round,
+		Stage:      stage,
+		Epoch:      binary.LittleEndian.Uint64(epochBytes),
+		ChainID:    k.roundChainID(ctx, round),
+		FailReason: k.FailReason(ctx, round),
+	}
+	if stage == stageCompleted {
+		result, err := k.RandomResult(ctx, round)
+		if err != nil {
+			panic(err)
+		}
+		rd.Result = result
+	}
+	if k.isRoundPruned(ctx, round) {
+		// the hash is missing if the transcript couldn't be hashed on pruning
+		rd.TranscriptHash, _ = k.GetTranscriptHash(ctx, round)
+		return rd
+	}
+	ctShares, err := k.GetAllCiphertexts(ctx, round)
+	if err != nil {
+		panic(err)
+	}
+	for _, ct := range ctShares {
+		ctJSON, err := types.NewCiphertextShareJSON(ct)
+		if err != nil {
+			panic(err)
+		}
+		rd.CiphertextShares = append(rd.CiphertextShares, ctJSON)
+	}
+	aggCt, err := k.GetAggregatedCiphertext(ctx, round)
+	if err != nil {
+		panic(err)
+	}
+	if aggCt != nil {
+		aggCtJSON, err := elgamal.NewCiphertextJSON(aggCt, P256)
+		if err != nil {
+			panic(err)
+		}
+		rd.AggregatedCiphertext = aggCtJSON
+	}
+	if stage == stageCtCollecting {
+		return rd
+	}
+	dShares, err := k.GetAllDecryptionShares(ctx, round)
+	if err != nil {
+		panic(err)
+	}
+	for _, ds := range dShares {
+		dsJSON, err := types.NewDecryptionShareJSON(ds)
+		if err != nil {
+			panic(err)
+		}
+		rd.DecryptionShares = append(rd.DecryptionShares, &dsJSON)
 	}
+	return rd
 }
@@ -2,6 +2,7 @@ package herb
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/corestario/HERB/x/herb/types"
 
@@ -9,13 +10,51 @@ import (
 )
 
 // NewHandler returns a handler for "herb" type messages.
-func NewHandler(keeper Keeper) sdk.Handler {
+// Messages are handled by the keeper of the beacon instance they are sent to
+func NewHandler(k Keeper) sdk.Handler {
 	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		instanceMsg, ok := msg.(types.InstanceMsg)
+		if !ok {
+			errMsg := fmt.Sprintf("unrecognized herb Msg type: %v", msg.Type())
+			return sdk.ErrUnknownRequest(errMsg).Result()
+		}
+		keeper, err := k.instanceKeeper(ctx, instanceMsg.GetInstance())
+		if err != nil {
+			return err.Result()
+		}
 		switch msg := msg.(type) {
 		case MsgSetCiphertextShare:
 			return handleMsgSetCiphertextShare(ctx, &keeper, msg)
 		case MsgSetDecryptionShare:
 			return handleMsgSetDecryptionShare(ctx, &keeper, msg)
+		case MsgDKGDeal:
+			return handleDKGMsg(ctx, &keeper, msg, keeper.SetDKGDeals(ctx, msg.Sender, msg.Deals))
+		case MsgDKGResponse:
+			return handleDKGMsg(ctx, &keeper, msg, keeper.SetDKGResponses(ctx, msg.Sender, msg.Responses))
+		case MsgDKGJustification:
+			return handleDKGMsg(ctx, &keeper, msg, keeper.SetDKGJustifications(ctx, msg.Sender, msg.Justifications))
+		case MsgDKGSecretCommits:
+			return handleDKGMsg(ctx, &keeper, msg, keeper.SetDKGSecretCommits(ctx, msg.Sender, msg.SecretCommits, msg.QUAL))
+		case MsgDKGComplaintCommits:
+			return handleDKGMsg(ctx, &keeper, msg, keeper.SetDKGComplaintCommits(ctx, msg.Sender, msg.Complaints))
+		case MsgDKGReconstructCommits:
+			return handleDKGMsg(ctx, &keeper, msg, keeper.SetDKGReconstructCommits(ctx, msg.Sender, msg.Reconstructs))
+		case MsgStartRefresh:
+			return handleRefreshMsg(ctx, &keeper, msg, keeper.StartRefresh(ctx, msg.Sender))
+		case MsgRefreshDeal:
+			return handleRefreshMsg(ctx, &keeper, msg, keeper.SetRefreshDeal(ctx, msg.Sender, msg.Deal))
+		case MsgRefreshComplaint:
+			return handleRefreshMsg(ctx, &keeper, msg, keeper.SetRefreshComplaint(ctx, msg.Sender, msg.Dealers))
+		case MsgRefreshJustification:
+			return handleRefreshMsg(ctx, &keeper, msg, keeper.SetRefreshJustification(ctx, msg.Sender, msg.Justifications))
+		case MsgSetEncryptionKey:
+			return handleRefreshMsg(ctx, &keeper, msg, keeper.SetEncryptionKey(ctx, msg.Sender, msg.Key))
+		case MsgRegisterEntropyProvider:
+			return handleProviderMsg(ctx, msg, keeper.RegisterEntropyProvider(ctx, msg.Sender))
+		case MsgDeregisterEntropyProvider:
+			return handleProviderMsg(ctx, msg, keeper.DeregisterEntropyProvider(ctx, msg.Sender))
+		case MsgRequestRandomness:
+			return handleMsgRequestRandomness(ctx, &keeper, msg)
 		default:
 			errMsg := fmt.Sprintf("unrecognized herb Msg type: %v", msg.Type())
 			return sdk.ErrUnknownRequest(errMsg).Result()
@@ -24,23 +63,79 @@ func NewHandler(keeper Keeper) sdk.Handler {
 }
 
 func handleMsgSetCiphertextShare(ctx sdk.Context, keeper *Keeper, msg types.MsgSetCiphertextShare) sdk.Result {
-	ctShare, err := msg.CiphertextShare.Deserialize()
+	ctShare, err := msg.CiphertextShare()
 	if err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't deserialize ciphertext share: %v", err)).Result()
 	}
-	if err := keeper.SetCiphertext(ctx, ctShare); err != nil {
+	if err := keeper.SetCiphertext(ctx, msg.Round, ctShare); err != nil {
 		return err.Result()
 	}
-	return sdk.Result{}
+	emitMessageEvent(ctx, msg.Sender)
+	return sdk.Result{Events: ctx.EventManager().Events()}
 }
 
 func handleMsgSetDecryptionShare(ctx sdk.Context, keeper *Keeper, msg types.MsgSetDecryptionShare) sdk.Result {
-	decryptionShare, err := msg.DecryptionShare.Deserialize()
+	decryptionShare, err := msg.DecShare()
 	if err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't deserialize decryption share: %v", err)).Result()
 	}
-	if err := keeper.SetDecryptionShare(ctx, decryptionShare); err != nil {
+	if err := keeper.SetDecryptionShare(ctx, msg.Round, decryptionShare); err != nil {
+		// the share is rejected, but the transaction must succeed to commit the key holder's penalty
+		if err.Codespace() == types.DefaultCodespace && err.Code() == types.CodeInvalidDecryptionShare {
+			return sdk.Result{Log: err.Error(), Events: ctx.EventManager().Events()}
+		}
+		return err.Result()
+	}
+	emitMessageEvent(ctx, msg.Sender)
+	return sdk.Result{Events: ctx.EventManager().Events()}
+}
+
+// handleMsgRequestRandomness returns the request ID in the result data, it's also emitted with the request event
+func handleMsgRequestRandomness(ctx sdk.Context, keeper *Keeper, msg types.MsgRequestRandomness) sdk.Result {
+	id, err := keeper.RequestRandomness(ctx, msg.Sender, msg.Fee)
+	if err != nil {
+		return err.Result()
+	}
+	emitMessageEvent(ctx, msg.Sender)
+	return sdk.Result{Data: []byte(strconv.FormatUint(id, 10)), Events: ctx.EventManager().Events()}
+}
+
+// emitMessageEvent emits the standard message event with the herb module and the sender
+func emitMessageEvent(ctx sdk.Context, sender sdk.AccAddress) {
+	ctx.EventManager().EmitEvent(
+		sdk.NewEvent(
+			sdk.EventTypeMessage,
+			sdk.NewAttribute(sdk.AttributeKeyModule, types.AttributeValueCategory),
+			sdk.NewAttribute(sdk.AttributeKeySender, sender.String()),
+		),
+	)
+}
+
+// handleDKGMsg converts result of the DKG message processing, the accepted message is reported with the DKG phase
+func handleDKGMsg(ctx sdk.Context, keeper *Keeper, msg sdk.Msg, err sdk.Error) sdk.Result {
+	if err != nil {
+		return err.Result()
+	}
+	keeper.emitDKGMessage(ctx, msg.Type(), msg.GetSigners()[0])
+	emitMessageEvent(ctx, msg.GetSigners()[0])
+	return sdk.Result{Events: ctx.EventManager().Events()}
+}
+
+// handleRefreshMsg converts result of the key refresh message processing, the accepted message is reported with the refresh phase
+func handleRefreshMsg(ctx sdk.Context, keeper *Keeper, msg sdk.Msg, err sdk.Error) sdk.Result {
+	if err != nil {
+		return err.Result()
+	}
+	keeper.emitRefreshMessage(ctx, msg.Type(), msg.GetSigners()[0])
+	emitMessageEvent(ctx, msg.GetSigners()[0])
+	return sdk.Result{Events: ctx.EventManager().Events()}
+}
+
+// handleProviderMsg converts result of the providers registry message processing, the registry change is reported by the keeper
+func handleProviderMsg(ctx sdk.Context, msg sdk.Msg, err sdk.Error) sdk.Result {
+	if err != nil {
 		return err.Result()
 	}
-	return sdk.Result{}
+	emitMessageEvent(ctx, msg.GetSigners()[0])
+	return sdk.Result{Events: ctx.EventManager().Events()}
 }
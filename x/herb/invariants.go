@@ -0,0 +1,219 @@
+package herb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+// RegisterInvariants registers all herb invariants, they are checked for every beacon instance
+func RegisterInvariants(ir sdk.InvariantRegistry, k Keeper) {
+	ir.RegisterRoute(ModuleName, "aggregated-ciphertext", InstancesInvariant(k, AggregatedCiphertextInvariant))
+	ir.RegisterRoute(ModuleName, "round-stages", InstancesInvariant(k, RoundStagesInvariant))
+	ir.RegisterRoute(ModuleName, "random-results", InstancesInvariant(k, RandomResultsInvariant))
+}
+
+// AllInvariants runs all invariants of the herb module for every beacon instance
+func AllInvariants(k Keeper) sdk.Invariant {
+	return InstancesInvariant(k, func(k Keeper) sdk.Invariant {
+		return func(ctx sdk.Context) (string, bool) {
+			if res, stop := AggregatedCiphertextInvariant(k)(ctx); stop {
+				return res, stop
+			}
+			if res, stop := RoundStagesInvariant(k)(ctx); stop {
+				return res, stop
+			}
+			return RandomResultsInvariant(k)(ctx)
+		}
+	})
+}
+
+// InstancesInvariant runs the invariant of the single instance for every beacon instance
+func InstancesInvariant(k Keeper, invariant func(k Keeper) sdk.Invariant) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var res string
+		broken := false
+		for _, id := range k.GetInstances(ctx) {
+			instanceRes, stop := invariant(k.Instance(id))(ctx)
+			if id != types.DefaultInstance {
+				instanceRes = fmt.Sprintf("instance %q: %s", id, instanceRes)
+			}
+			res += instanceRes
+			broken = broken || stop
+		}
+		return res, broken
+	}
+}
+
+// AggregatedCiphertextInvariant checks that the aggregated ciphertext of every open round is the sum of its ciphertext shares
+func AggregatedCiphertextInvariant(k Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var msg string
+		broken := false
+		for _, rs := range k.roundStages(ctx) {
+			round, stage := rs.round, rs.stage
+			if stage != stageCtCollecting && stage != stageDSCollecting {
+				continue
+			}
+			ctList, err := k.GetAllCiphertexts(ctx, round)
+			if err != nil {
+				broken = true
+				msg += fmt.Sprintf("\tround %v: can't get ciphertext shares: %v\n", round, err)
+				continue
+			}
+			aggCt, err := k.GetAggregatedCiphertext(ctx, round)
+			if err != nil {
+				broken = true
+				msg += fmt.Sprintf("\tround %v: can't get aggregated ciphertext: %v\n", round, err)
+				continue
+			}
+			if len(ctList) == 0 {
+				if aggCt != nil {
+					broken = true
+					msg += fmt.Sprintf("\tround %v: aggregated ciphertext is stored without shares\n", round)
+				}
+				continue
+			}
+			cts := make([]elgamal.Ciphertext, len(ctList))
+			for i, ct := range ctList {
+				cts[i] = ct.Ciphertext
+			}
+			expected := elgamal.AggregateCiphertext(P256, cts)
+			if aggCt == nil || !aggCt.Equal(expected) {
+				broken = true
+				msg += fmt.Sprintf("\tround %v: aggregated ciphertext %v, sum of %v shares %v\n", round, aggCt, len(ctList), expected)
+			}
+		}
+		return sdk.FormatInvariant(ModuleName, "aggregated ciphertext", msg), broken
+	}
+}
+
+// RoundStagesInvariant checks that only the current round and, with pipelined rounds, the previous one are collecting shares
+// and all earlier rounds are finished
+func RoundStagesInvariant(k Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var msg string
+		broken := false
+		current := k.CurrentRound(ctx)
+		stages := k.roundStages(ctx)
+		started := make(map[uint64]string, len(stages))
+		for _, rs := range stages {
+			started[rs.round] = rs.stage
+		}
+		stageOf := func(round uint64) string {
+			if stage, ok := started[round]; ok {
+				return stage
+			}
+			return stageUnstarted
+		}
+		for _, rs := range stages {
+			if err := checkRoundStage(rs.round, current, stageOf, k.GetParams(ctx).OnDemandRounds); err != nil {
+				broken = true
+				msg += fmt.Sprintf("\t%v\n", err)
+			}
+		}
+		for round := uint64(0); round < current; round++ {
+			if _, ok := started[round]; !ok {
+				broken = true
+				msg += fmt.Sprintf("\tround %v isn't finished, current round is %v\n", round, current)
+			}
+		}
+		// round 0 isn't started until the first ciphertext share
+		if _, ok := started[current]; !ok && !(current == 0 && len(stages) == 0) {
+			broken = true
+			msg += fmt.Sprintf("\tcurrent round %v isn't started\n", current)
+		}
+		return sdk.FormatInvariant(ModuleName, "round stages", msg), broken
+	}
+}
+
+// checkRoundStage checks the stage of the started round against the current round: the current round collects shares,
+// the previous one may collect decryption shares while the current one collects ciphertext shares or is aborted, earlier rounds are finished.
+// In the on-demand mode the current round stays finished until the next one is requested
+func checkRoundStage(round uint64, current uint64, stageOf func(round uint64) string, onDemand bool) error {
+	stage := stageOf(round)
+	previousDecrypting := round > 0 && stageOf(round-1) == stageDSCollecting
+	switch stage {
+	case stageCtCollecting:
+		if round != current {
+			return fmt.Errorf("round %v is on the stage %v, current round is %v", round, stage, current)
+		}
+	case stageDSCollecting:
+		if round != current && (round+1 != current || stageOf(current) == stageDSCollecting) {
+			return fmt.Errorf("round %v is on the stage %v, current round is %v", round, stage, current)
+		}
+	case stageCompleted, stageFailed:
+		if round > current || (round == current && !previousDecrypting && !onDemand) {
+			return fmt.Errorf("round %v is finished, current round is %v", round, current)
+		}
+	default:
+		return fmt.Errorf("round %v has unknown stage %v", round, stage)
+	}
+	return nil
+}
+
+// RandomResultsInvariant checks that every stored result is the hash of the decryption with the round's shares,
+// results of the pruned rounds are skipped
+func RandomResultsInvariant(k Keeper) sdk.Invariant {
+	return func(ctx sdk.Context) (string, bool) {
+		var msg string
+		broken := false
+		results := make(map[uint64][]byte)
+		var rounds []uint64
+		store := k.store(ctx)
+		iterator := sdk.KVStorePrefixIterator(store, []byte{prefixRound})
+		for ; iterator.Valid(); iterator.Next() {
+			if round, name := splitRoundKey(iterator.Key()); name == keyRandomResult {
+				rounds = append(rounds, round)
+				results[round] = iterator.Value()
+			}
+		}
+		iterator.Close()
+		for _, round := range rounds {
+			if k.isRoundPruned(ctx, round) {
+				continue
+			}
+			expected, err := k.computeRandomResult(ctx, round)
+			if err != nil {
+				broken = true
+				msg += fmt.Sprintf("\tround %v: can't decrypt the aggregated ciphertext: %v\n", round, err)
+				continue
+			}
+			if !bytes.Equal(results[round], expected) {
+				broken = true
+				msg += fmt.Sprintf("\tround %v: result %X, decrypted %X\n", round, results[round], expected)
+			}
+		}
+		return sdk.FormatInvariant(ModuleName, "random results", msg), broken
+	}
+}
+
+type roundStage struct {
+	round uint64
+	stage string
+}
+
+// roundStages returns stages of all rounds which have the stage stored, ordered by round
+func (k *Keeper) roundStages(ctx sdk.Context) []roundStage {
+	var stages []roundStage
+	store := k.store(ctx)
+	iterator := sdk.KVStorePrefixIterator(store, []byte{prefixRound})
+	defer iterator.Close()
+	for ; iterator.Valid(); iterator.Next() {
+		round, name := splitRoundKey(iterator.Key())
+		if name == keyStage {
+			stages = append(stages, roundStage{round, string(iterator.Value())})
+		}
+	}
+	return stages
+}
+
+// splitRoundKey returns round and item name of the round item key
+func splitRoundKey(key []byte) (uint64, string) {
+	return binary.BigEndian.Uint64(key[1:9]), string(key[9:])
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/corestario/HERB/x/herb/types"
 	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/params"
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/share"
 )
@@ -20,14 +21,22 @@ type Keeper struct {
 	group                    kyber.Group
 	storeCiphertextSharesKey *sdk.KVStoreKey
 	storeDecryptionSharesKey *sdk.KVStoreKey
+	stakingKeeper            types.StakingKeeper
+	slashingKeeper           types.SlashingKeeper
+	supplyKeeper             types.SupplyKeeper
+	bankKeeper               types.BankKeeper
+	hooks                    types.HerbHooks
+	paramSpace               params.Subspace
 	cdc                      *codec.Codec
 	randmetric               *Metrics
 	resTime                  time.Time
-	verificationKeys         map[string]types.VerificationKey
+	vkCache                  *vkCache // parsed verification keys, shared by the keeper copies
+	instance                 string   // beacon instance the keeper works on, see Instance
 }
 
 // NewKeeper creates new instances of the HERB Keeper
-func NewKeeper(storeKey sdk.StoreKey, storeCiphertextShares *sdk.KVStoreKey, storeDecryptionShares *sdk.KVStoreKey, cdc *codec.Codec) Keeper {
+func NewKeeper(storeKey sdk.StoreKey, storeCiphertextShares *sdk.KVStoreKey, storeDecryptionShares *sdk.KVStoreKey,
+	stakingKeeper types.StakingKeeper, slashingKeeper types.SlashingKeeper, supplyKeeper types.SupplyKeeper, bankKeeper types.BankKeeper, paramSpace params.Subspace, cdc *codec.Codec) Keeper {
 	randmetric := PrometheusMetrics()
 	t := time.Now().UTC()
 	return Keeper{
@@ -35,77 +44,89 @@ func NewKeeper(storeKey sdk.StoreKey, storeCiphertextShares *sdk.KVStoreKey, sto
 		group:                    P256,
 		storeCiphertextSharesKey: storeCiphertextShares,
 		storeDecryptionSharesKey: storeDecryptionShares,
+		stakingKeeper:            stakingKeeper,
+		slashingKeeper:           slashingKeeper,
+		supplyKeeper:             supplyKeeper,
+		bankKeeper:               bankKeeper,
+		paramSpace:               paramSpace.WithKeyTable(types.ParamKeyTable()),
 		cdc:                      cdc,
 		randmetric:               randmetric,
 		resTime:                  t,
+		vkCache:                  newVKCache(),
 	}
 }
 
-// SetCiphertext store the ciphertext from the entropyProvider to the kv-store
-func (k *Keeper) SetCiphertext(ctx sdk.Context, ctShare *types.CiphertextShare) sdk.Error {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("PANIC:", r)
-		}
-	}()
+// SetHooks sets the herb hooks, multiple hooks sets are combined
+// Hooks must be set before the keeper is passed to the module
+func (k *Keeper) SetHooks(hooks ...types.HerbHooks) *Keeper {
+	if k.hooks != nil {
+		panic("cannot set herb hooks twice")
+	}
+	k.hooks = types.NewMultiHerbHooks(hooks...)
+	return k
+}
+
+// recoverError turns the panic into the message error, so the state written before the panic isn't committed
+func recoverError(sdkErr *sdk.Error) {
+	if r := recover(); r != nil {
+		log.Println("PANIC:", r)
+		*sdkErr = sdk.ErrInternal(fmt.Sprintf("herb keeper panic: %v", r))
+	}
+}
+
+// SetCiphertext store the ciphertext from the entropyProvider for the collecting round to the kv-store
+func (k *Keeper) SetCiphertext(ctx sdk.Context, round uint64, ctShare *types.CiphertextShare) (sdkErr sdk.Error) {
+	defer recoverError(&sdkErr)
 	if ctShare.EntropyProvider.Empty() {
 		return sdk.ErrInvalidAddress("entropy provider can't be empty!")
 	}
-	round := k.CurrentRound(ctx)
+	if k.dkgRunning(ctx) && !k.keysDefined(ctx) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("distributed key generation isn't completed. Current phase: %v", k.GetDKGPhase(ctx)))
+	}
+	if k.GetParams(ctx).RestrictedProviders && !k.IsEntropyProvider(ctx, ctShare.EntropyProvider) {
+		return sdk.ErrUnauthorized(fmt.Sprintf("%v isn't a registered entropy provider", ctShare.EntropyProvider))
+	}
+	if err := k.checkCiphertextRound(ctx, round); err != nil {
+		return err
+	}
 	stage := k.GetStage(ctx, round)
 	pubKey, err1 := k.GetCommonPublicKey(ctx)
 	if err1 != nil {
 		return err1
 	}
-	err := elgamal.CEVerify(P256, k.group.Point().Base(), pubKey, ctShare.Ciphertext.PointA, ctShare.Ciphertext.PointB, ctShare.CEproof)
+	context := k.proofContext(ctx, elgamal.ContextCE, round, ctShare.EntropyProvider)
+	err := elgamal.CEVerify(P256, context, k.group.Point().Base(), pubKey, ctShare.Ciphertext.PointA, ctShare.Ciphertext.PointB, ctShare.CEproof)
 	if err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("CE proof isn't correct: %v", err))
 	}
 
-	if k.CurrentRound(ctx) == 0 && stage == stageUnstarted {
-		err1 = k.InitializeVerificationKeys(ctx)
-		if err1 != nil {
+	if round == 0 && stage == stageUnstarted {
+		if err1 = k.startFirstRound(ctx); err1 != nil {
 			return err1
 		}
 		stage = stageCtCollecting
-		k.setStage(ctx, round, stage)
 	}
 
 	if stage != stageCtCollecting {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("round is not on the ciphertext collecting stage. Current stage: %v", stage))
 	}
-	ctStore := ctx.KVStore(k.storeCiphertextSharesKey)
-	keyBytesAllCt := []byte(fmt.Sprintf("rd_%d", round))
-	t, err1 := k.GetThresholdCiphertexts(ctx)
-	if err1 != nil {
-		return err1
-	}
-
-	var addrList []string
-	if ctStore.Has(keyBytesAllCt) {
-		addrListBytes := ctStore.Get(keyBytesAllCt)
-		err := k.cdc.UnmarshalJSON(addrListBytes, &addrList)
-		if err != nil {
-			return sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal list of all addresses from the store: %v", err))
-		}
-	}
-	addrList = append(addrList, ctShare.EntropyProvider.String())
-	newAddrListBytes, err := k.cdc.MarshalJSON(addrList)
-	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal list of all addresses: %v", err))
-	}
-
+	ctStore := k.ctStore(ctx)
 	keyBytesCt := createKeyBytesByAddr(round, ctShare.EntropyProvider)
 	if ctStore.Has(keyBytesCt) {
 		return sdk.ErrInvalidAddress("entropy provider has already sentf ciphertext share")
 	}
-	ctJSON, err := types.NewCiphertextShareJSON(ctShare)
+	ctBytes, err := types.EncodeCiphertextShare(ctShare)
 	if err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't serialize ctShare: %v", err))
 	}
-	ctBytes, err := k.cdc.MarshalJSON(ctJSON)
+	// a ciphertext is accepted once per round, so a replayed share doesn't change the aggregated ciphertext
+	ciphertextBytes, err := types.EncodeCiphertext(&ctShare.Ciphertext)
 	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshall ctShare: %v", err))
+		return sdk.ErrUnknownRequest(fmt.Sprintf("can't serialize ciphertext: %v", err))
+	}
+	keyBytesCiphertext := createCiphertextKey(round, ciphertextBytes)
+	if ctStore.Has(keyBytesCiphertext) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("ciphertext has already been sent in round %v", round))
 	}
 	aggregatedCt, err1 := k.GetAggregatedCiphertext(ctx, round)
 	if err1 != nil {
@@ -122,105 +143,80 @@ func (k *Keeper) SetCiphertext(ctx sdk.Context, ctShare *types.CiphertextShare)
 		return err1
 	}
 	ctStore.Set(keyBytesCt, ctBytes)
-	ctStore.Set(keyBytesAllCt, newAddrListBytes)
-
-	if uint64(len(addrList)) >= t {
-		k.setStage(ctx, round, stageDSCollecting)
-	}
+	ctStore.Set(keyBytesCiphertext, []byte{})
+	count := k.incSharesCount(ctStore, round)
+	k.emitShareAccepted(ctx, round, types.AttributeValueCiphertext, ctShare.EntropyProvider, int(count))
+	k.advanceRounds(ctx)
 	return nil
 }
 
-func (k *Keeper) SetAggregatedCiphertext(ctx sdk.Context, round uint64, ct *elgamal.Ciphertext) sdk.Error {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("PANIC:", r)
-		}
-	}()
-	store := ctx.KVStore(k.storeKey)
+func (k *Keeper) SetAggregatedCiphertext(ctx sdk.Context, round uint64, ct *elgamal.Ciphertext) (sdkErr sdk.Error) {
+	defer recoverError(&sdkErr)
+	store := k.store(ctx)
 	keyBytes := createKeyBytesByRound(round, keyAggregatedCiphertext)
 
-	ctJSON, err := elgamal.NewCiphertextJSON(ct, P256)
+	ctBytes, err := types.EncodeCiphertext(ct)
 	if err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't serialize aggregated ct: %v", err))
 	}
-	ctBytes, err := k.cdc.MarshalJSON(ctJSON)
-	if err != nil {
-		return sdk.ErrInternal(fmt.Sprintf("can't marhsal aggregated ct: %v", err))
-	}
 	store.Set(keyBytes, ctBytes)
 	return nil
 }
 
-// SetDecryptionShare stores decryption share for the current round
-func (k *Keeper) SetDecryptionShare(ctx sdk.Context, ds *types.DecryptionShare) sdk.Error {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("PANIC:", r)
-		}
-	}()
+// SetDecryptionShare stores decryption share for the decrypting round
+// The share index is always the key holder ID of the sender, the index set by the caller is ignored
+func (k *Keeper) SetDecryptionShare(ctx sdk.Context, round uint64, ds *types.DecryptionShare) (sdkErr sdk.Error) {
+	defer recoverError(&sdkErr)
 	if ds.KeyHolderAddr.Empty() {
 		return sdk.ErrInvalidAddress("key Holder can't be empty!")
 	}
 
-	round := k.CurrentRound(ctx)
-	stage := k.GetStage(ctx, round)
-	if stage != stageDSCollecting {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("wrong round stage: %v. round: %v", stage, round))
+	if err := k.checkDecryptionRound(ctx, round); err != nil {
+		return err
 	}
 	aggCiphertext, err1 := k.GetAggregatedCiphertext(ctx, round)
 	if err1 != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't get aggregated ciphertext: %v", err1))
 	}
 
-	vkOwner, ok := k.verificationKeys[ds.KeyHolderAddr.String()]
-	if !ok {
-		return sdk.ErrUnknownRequest("verification key isn't exist")
+	vkOwner, err1 := k.GetVerificationKey(ctx, ds.KeyHolderAddr)
+	if err1 != nil {
+		return err1
 	}
 
-	err := elgamal.DLEQVerify(P256, ds.DLEQproof, k.group.Point().Base(), aggCiphertext.PointA, vkOwner.Key, ds.DecShare.V)
+	context := k.proofContext(ctx, elgamal.ContextDLEQ, round, vkOwner.Sender)
+	err := elgamal.DLEQVerify(P256, context, ds.DLEQproof, k.group.Point().Base(), aggCiphertext.PointA, vkOwner.Key, ds.DecShare.V)
 	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("DLEQ proof isn't correct: %v", err))
-	}
-
-	dsStore := ctx.KVStore(k.storeDecryptionSharesKey)
-	keyBytes := createKeyBytesByAddr(round, vkOwner.Sender)
-	keyAllShares := []byte(fmt.Sprintf("rd_%d", round))
-
-	var addrList []string
-	if dsStore.Has(keyAllShares) {
-		addrListBytes := dsStore.Get(keyAllShares)
-		err = k.cdc.UnmarshalJSON(addrListBytes, &addrList)
-		if err != nil {
-			return sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal list of all addresses from the store: %v", err))
-		}
+		k.punishInvalidShare(ctx, round, vkOwner.Sender)
+		return types.ErrInvalidDecryptionShare(fmt.Sprintf("DLEQ proof isn't correct: %v", err))
 	}
-	addrList = append(addrList, ds.KeyHolderAddr.String())
-	newAddrListBytes, err := k.cdc.MarshalJSON(addrList)
-	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal list of all addresses: %v", err))
+	// the proof doesn't cover the index, a share under another holder's index would break the interpolation
+	ds = &types.DecryptionShare{
+		DecShare:      share.PubShare{I: vkOwner.KeyHolderID, V: ds.DecShare.V},
+		DLEQproof:     ds.DLEQproof,
+		KeyHolderAddr: vkOwner.Sender,
 	}
 
+	dsStore := k.dsStore(ctx)
+	keyBytes := createKeyBytesByAddr(round, vkOwner.Sender)
 	if dsStore.Has(keyBytes) {
 		return sdk.ErrInvalidAddress("key holder has already send decryption share")
 	}
-	dsJSON, err1 := types.NewDecryptionShareJSON(ds)
+	dsBytes, err1 := types.EncodeDecryptionShare(ds)
 	if err1 != nil {
 		return err1
 	}
-	dsBytes, err := k.cdc.MarshalJSON(dsJSON)
-	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshall decryption share: %v", err))
-	}
 
 	dsStore.Set(keyBytes, dsBytes)
-	dsStore.Set(keyAllShares, newAddrListBytes)
+	count := k.incSharesCount(dsStore, round)
+	k.emitShareAccepted(ctx, round, types.AttributeValueDecryptionShare, vkOwner.Sender, int(count))
 
 	t, err1 := k.GetThresholdDecryption(ctx)
 	if err1 != nil {
 		return err1
 	}
 
-	if uint64(len(addrList)) >= t {
+	if count >= t {
 		err := k.SetRandomResult(ctx, round)
 		if err != nil {
 			return err
@@ -236,16 +232,180 @@ func (k *Keeper) SetDecryptionShare(ctx sdk.Context, ds *types.DecryptionShare)
 		}
 		k.randmetric.CountRandom.Inc()
 		k.setStage(ctx, round, stageCompleted)
-		k.increaseCurrentRound(ctx)
-		k.setStage(ctx, k.CurrentRound(ctx), stageCtCollecting)
+		k.setLastCompletedRound(ctx, round)
+		k.payRewards(ctx, round)
+		if k.hooks != nil {
+			result, err := k.RandomResult(ctx, round)
+			if err != nil {
+				return err
+			}
+			k.hooks.AfterRoundCompleted(ctx, k.instance, round, result)
+		}
+		k.advanceRounds(ctx)
+	}
+
+	return nil
+}
+
+// AbortRound marks the round collecting shares as failed with the given reason and opens the next round
+func (k *Keeper) AbortRound(ctx sdk.Context, round uint64, reason string) {
+	stage := k.GetStage(ctx, round)
+	if stage != stageCtCollecting && stage != stageDSCollecting {
+		return
+	}
+	store := k.store(ctx)
+	store.Set(createKeyBytesByRound(round, keyFailReason), []byte(reason))
+	k.setStage(ctx, round, stageFailed)
+	// key holders can't be blamed if the round is aborted before the decryption shares collecting.
+	// Misses are counted only for the aborted rounds: the completed round stops at the threshold, so the slower holders aren't blamed
+	if stage == stageDSCollecting {
+		k.trackMissedShares(ctx, round)
+	}
+	k.randmetric.CountFailed.Inc()
+	k.moveRequests(ctx, round)
+	k.advanceRounds(ctx)
+}
+
+// FailReason returns the reason why the given round was aborted
+func (k *Keeper) FailReason(ctx sdk.Context, round uint64) string {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyFailReason)
+	if !store.Has(keyBytes) {
+		return ""
 	}
+	return string(store.Get(keyBytes))
+}
+
+// advanceRounds moves the rounds forward after a share is accepted or a round is finished:
+// the current round starts collecting decryption shares when it has enough ciphertext shares and no other round is decrypted,
+// the next round is opened when the current one is finished or, with pipelined rounds, when it starts collecting decryption shares.
+// In the on-demand mode the next round is opened only if it's requested
+func (k *Keeper) advanceRounds(ctx sdk.Context) {
+	for {
+		round := k.CurrentRound(ctx)
+		_, decrypting := k.DecryptingRound(ctx)
+		switch k.GetStage(ctx, round) {
+		case stageCtCollecting:
+			t, err := k.GetThresholdCiphertexts(ctx)
+			if decrypting || err != nil || k.sharesCount(k.ctStore(ctx), round) < t {
+				return
+			}
+			k.setStage(ctx, round, stageDSCollecting)
+		case stageDSCollecting:
+			if !k.canOpenPipelinedRound(ctx) || !k.nextRoundRequested(ctx, round) {
+				return
+			}
+			k.startNextRound(ctx)
+		case stageCompleted, stageFailed:
+			if (decrypting && !k.canOpenPipelinedRound(ctx)) || !k.nextRoundRequested(ctx, round) {
+				return
+			}
+			k.startNextRound(ctx)
+		default:
+			return
+		}
+	}
+}
+
+// canOpenPipelinedRound returns true if the next round can be opened while the previous one is decrypted.
+// Keys can't change until the decryption is finished, so the next round waits for it if new keys are pending
+func (k *Keeper) canOpenPipelinedRound(ctx sdk.Context) bool {
+	return k.GetParams(ctx).PipelinedRounds && k.GetPendingKeyEpoch(ctx) == nil &&
+		k.GetRefreshPhase(ctx) != types.RefreshPhasePending
+}
+
+// CollectingRound returns the round collecting ciphertext shares, false if the current round doesn't collect them
+func (k *Keeper) CollectingRound(ctx sdk.Context) (uint64, bool) {
+	round := k.CurrentRound(ctx)
+	stage := k.GetStage(ctx, round)
+	// round 0 is started by the first ciphertext share or, in the on-demand mode, by the first request
+	return round, stage == stageCtCollecting || (stage == stageUnstarted && !k.GetParams(ctx).OnDemandRounds)
+}
+
+// DecryptingRound returns the round collecting decryption shares, it's the current round
+// or, with pipelined rounds, the previous one. False is returned if no round collects decryption shares
+func (k *Keeper) DecryptingRound(ctx sdk.Context) (uint64, bool) {
+	round := k.CurrentRound(ctx)
+	if k.GetStage(ctx, round) == stageDSCollecting {
+		return round, true
+	}
+	if round > 0 && k.GetStage(ctx, round-1) == stageDSCollecting {
+		return round - 1, true
+	}
+	return 0, false
+}
+
+// checkCiphertextRound returns ErrWrongRound if the round doesn't collect ciphertext shares
+func (k *Keeper) checkCiphertextRound(ctx sdk.Context, round uint64) sdk.Error {
+	if collecting, ok := k.CollectingRound(ctx); !ok || round != collecting {
+		return types.ErrWrongRound(round, fmt.Sprintf("round doesn't collect ciphertext shares, current round: %v, stage: %v",
+			k.CurrentRound(ctx), k.GetStage(ctx, k.CurrentRound(ctx))))
+	}
+	return nil
+}
+
+// checkDecryptionRound returns ErrWrongRound if the round doesn't collect decryption shares
+func (k *Keeper) checkDecryptionRound(ctx sdk.Context, round uint64) sdk.Error {
+	if decrypting, ok := k.DecryptingRound(ctx); !ok || round != decrypting {
+		return types.ErrWrongRound(round, fmt.Sprintf("round doesn't collect decryption shares, stage: %v", k.GetStage(ctx, round)))
+	}
+	return nil
+}
 
+// startFirstRound opens round 0 for the ciphertext shares
+func (k *Keeper) startFirstRound(ctx sdk.Context) sdk.Error {
+	if err := k.InitializeVerificationKeys(ctx); err != nil {
+		return err
+	}
+	k.setRoundEpoch(ctx, 0)
+	k.setRoundChainID(ctx, 0)
+	k.setStage(ctx, 0, stageCtCollecting)
 	return nil
 }
 
+// startNextRound increments current round and opens it for the ciphertext shares
+// Keys of the new key holders set or refreshed verification keys are applied here and the periodic key refresh is started
+func (k *Keeper) startNextRound(ctx sdk.Context) {
+	k.increaseCurrentRound(ctx)
+	round := k.CurrentRound(ctx)
+	k.applyKeyEpoch(ctx, round)
+	if k.GetRefreshPhase(ctx) == types.RefreshPhasePending {
+		k.applyRefresh(ctx, round)
+	}
+	if epoch := k.GetParams(ctx).RefreshEpoch; epoch > 0 && round%uint64(epoch) == 0 && k.GetRefreshPhase(ctx) == types.RefreshPhaseNone {
+		if err := k.startRefresh(ctx); err != nil {
+			ctx.Logger().Error(fmt.Sprintf("herb key refresh isn't started: %v", err))
+		}
+	}
+	k.setRoundEpoch(ctx, round)
+	k.setRoundChainID(ctx, round)
+	k.setStage(ctx, round, stageCtCollecting)
+}
+
+// proofContext returns the context the share proofs of the sender in the given round are bound to
+func (k *Keeper) proofContext(ctx sdk.Context, kind string, round uint64, sender sdk.AccAddress) []byte {
+	return elgamal.ProofContext(kind, k.roundChainID(ctx, round), k.instance, round, sender.String())
+}
+
+// roundChainID returns the chain ID the round's proofs are bound to, it's fixed at the round start
+// so the round's shares stay valid after the chain is restarted from the exported genesis with the new chain ID
+func (k *Keeper) roundChainID(ctx sdk.Context, round uint64) string {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyRoundChainID)
+	if !store.Has(keyBytes) {
+		return ctx.ChainID()
+	}
+	return string(store.Get(keyBytes))
+}
+
+func (k *Keeper) setRoundChainID(ctx sdk.Context, round uint64) {
+	store := k.store(ctx)
+	store.Set(createKeyBytesByRound(round, keyRoundChainID), []byte(ctx.ChainID()))
+}
+
 // CurrentRound returns current generation round as uint64
 func (k *Keeper) CurrentRound(ctx sdk.Context) uint64 {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	keyBytes := []byte(keyCurrentRound)
 	if !store.Has(keyBytes) {
 		roundBytes := make([]byte, 8)
@@ -264,7 +424,7 @@ func (k *Keeper) increaseCurrentRound(ctx sdk.Context) {
 	currentRound := k.CurrentRound(ctx)
 	currentRound = currentRound + 1
 
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 
 	roundBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(roundBytes, currentRound)
@@ -273,45 +433,23 @@ func (k *Keeper) increaseCurrentRound(ctx sdk.Context) {
 
 // GetAllCiphertexts returns all ciphertext shares for the given round as go-slice
 func (k *Keeper) GetAllCiphertexts(ctx sdk.Context, round uint64) ([]*types.CiphertextShare, sdk.Error) {
-	ctStore := ctx.KVStore(k.storeCiphertextSharesKey)
+	if err := k.checkRoundPruned(ctx, round); err != nil {
+		return nil, err
+	}
+	ctStore := k.ctStore(ctx)
 	stage := k.GetStage(ctx, round)
 
 	if stage == stageUnstarted {
 		return nil, sdk.ErrUnknownRequest("round hasn't started yet")
 	}
 
-	keyBytesAllCt := []byte(fmt.Sprintf("rd_%d", round))
-
-	//if store doesn't have such key -> no cts was added
-	if !ctStore.Has(keyBytesAllCt) {
-		return []*types.CiphertextShare{}, nil
-	}
-
-	addrListBytes := ctStore.Get(keyBytesAllCt)
-	var addrList []string
-	err := k.cdc.UnmarshalJSON(addrListBytes, &addrList)
-	if err != nil {
-		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal list of all adderesses from store: %v", err))
-	}
-	ctList := make([]*types.CiphertextShare, 0, len(addrList))
-	for _, addrStr := range addrList {
-		addr, err := sdk.AccAddressFromBech32(addrStr)
-		if err != nil {
-			return nil, sdk.ErrUnknownAddress(fmt.Sprintf("can't get address from bench32: %v", err))
-		}
-		key := createKeyBytesByAddr(round, addr)
-		if !ctStore.Has(key) {
-			return nil, sdk.ErrUnknownRequest("addresses list and real ciphertext providers doesn't meet")
-		}
-		ctBytes := ctStore.Get(key)
-		var ctJSON types.CiphertextShareJSON
-		err = k.cdc.UnmarshalJSON(ctBytes, &ctJSON)
+	iterator := sdk.KVStorePrefixIterator(ctStore, createSharesPrefix(round))
+	defer iterator.Close()
+	ctList := make([]*types.CiphertextShare, 0)
+	for ; iterator.Valid(); iterator.Next() {
+		ct, err := types.DecodeCiphertextShare(iterator.Value(), addrFromSharesKey(iterator.Key()))
 		if err != nil {
-			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarthsal ciphertext: %v", err))
-		}
-		ct, err1 := ctJSON.Deserialize()
-		if err1 != nil {
-			return nil, err1
+			return nil, err
 		}
 		ctList = append(ctList, ct)
 	}
@@ -320,19 +458,17 @@ func (k *Keeper) GetAllCiphertexts(ctx sdk.Context, round uint64) ([]*types.Ciph
 
 // GetAggregatedCiphertext aggregate all sended ciphertext shares in one ciphertext and returns it
 func (k *Keeper) GetAggregatedCiphertext(ctx sdk.Context, round uint64) (*elgamal.Ciphertext, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	if err := k.checkRoundPruned(ctx, round); err != nil {
+		return nil, err
+	}
+	store := k.store(ctx)
 	keyBytes := createKeyBytesByRound(round, keyAggregatedCiphertext)
 	if !store.Has(keyBytes) {
 		return nil, nil
 	}
 
 	result := store.Get(keyBytes)
-	var newaCtSer *elgamal.CiphertextJSON
-	err := k.cdc.UnmarshalJSON(result, &newaCtSer)
-	if err != nil {
-		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal aggregated ciphertext: %v", err))
-	}
-	newCt, err := newaCtSer.Deserialize(P256)
+	newCt, err := types.DecodeCiphertext(result)
 	if err != nil {
 		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't deserialize aggregated ciphertext: %v", err))
 	}
@@ -341,53 +477,49 @@ func (k *Keeper) GetAggregatedCiphertext(ctx sdk.Context, round uint64) (*elgama
 
 // GetAllDecryptionShares returns all decryption shares for the given round
 func (k *Keeper) GetAllDecryptionShares(ctx sdk.Context, round uint64) ([]*types.DecryptionShare, sdk.Error) {
+	if err := k.checkRoundPruned(ctx, round); err != nil {
+		return nil, err
+	}
 	stage := k.GetStage(ctx, round)
-	if stage != stageDSCollecting && stage != stageCompleted {
+	if stage != stageDSCollecting && stage != stageCompleted && stage != stageFailed {
 		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("wrong round stage: %v. round: %v", stage, round))
 	}
 
-	dsStore := ctx.KVStore(k.storeDecryptionSharesKey)
-
-	keyAllShares := []byte(fmt.Sprintf("rd_%d", round))
-
-	if !dsStore.Has(keyAllShares) {
-		return []*types.DecryptionShare{}, nil
-	}
-
-	addrListBytes := dsStore.Get(keyAllShares)
-	var addrList []string
-	err := k.cdc.UnmarshalJSON(addrListBytes, &addrList)
-	if err != nil {
-		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal list of all adderesses from store: %v", err))
-	}
-	dsList := make([]*types.DecryptionShare, 0, len(addrList))
-	for _, addrStr := range addrList {
-		addr, err := sdk.AccAddressFromBech32(addrStr)
-		if err != nil {
-			return nil, sdk.ErrUnknownAddress(fmt.Sprintf("can't get address from bench32: %v", err))
-		}
-		key := createKeyBytesByAddr(round, addr)
-		if !dsStore.Has(key) {
-			return nil, sdk.ErrUnknownRequest("addresses list and real decryption share sender doesn't meet")
-		}
-		dsBytes := dsStore.Get(key)
-		var dsJSON types.DecryptionShareJSON
-		err = k.cdc.UnmarshalJSON(dsBytes, &dsJSON)
+	dsStore := k.dsStore(ctx)
+	iterator := sdk.KVStorePrefixIterator(dsStore, createSharesPrefix(round))
+	defer iterator.Close()
+	dsList := make([]*types.DecryptionShare, 0)
+	for ; iterator.Valid(); iterator.Next() {
+		ds, err := types.DecodeDecryptionShare(iterator.Value(), addrFromSharesKey(iterator.Key()))
 		if err != nil {
-			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarthsal decryption share: %v", err))
-		}
-		ds, err1 := dsJSON.Deserialize()
-		if err1 != nil {
-			return nil, err1
+			return nil, err
 		}
 		dsList = append(dsList, ds)
 	}
 	return dsList, nil
 }
 
+// sharesCount returns number of the round's shares in the shares store
+func (k *Keeper) sharesCount(sharesStore sdk.KVStore, round uint64) uint64 {
+	keyBytes := createSharesCountKey(round)
+	if !sharesStore.Has(keyBytes) {
+		return 0
+	}
+	return binary.BigEndian.Uint64(sharesStore.Get(keyBytes))
+}
+
+// incSharesCount increments number of the round's shares and returns the new number
+func (k *Keeper) incSharesCount(sharesStore sdk.KVStore, round uint64) uint64 {
+	count := k.sharesCount(sharesStore, round) + 1
+	countBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, count)
+	sharesStore.Set(createSharesCountKey(round), countBytes)
+	return count
+}
+
 // GetStage returns stage of the given round
 func (k *Keeper) GetStage(ctx sdk.Context, round uint64) string {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	keyBytes := createKeyBytesByRound(round, keyStage)
 	if !store.Has(keyBytes) {
 		return stageUnstarted
@@ -397,27 +529,60 @@ func (k *Keeper) GetStage(ctx sdk.Context, round uint64) string {
 }
 
 func (k *Keeper) setStage(ctx sdk.Context, round uint64, stage string) {
-	store := ctx.KVStore(k.storeKey)
+	oldStage := k.GetStage(ctx, round)
+	store := k.store(ctx)
 	keyBytes := createKeyBytesByRound(round, keyStage)
 	store.Set(keyBytes, []byte(stage))
+	heightBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(heightBytes, uint64(ctx.BlockHeight()))
+	store.Set(createKeyBytesByRound(round, keyStageHeight), heightBytes)
+	k.emitStageChanged(ctx, round, oldStage, stage)
+	if k.hooks == nil {
+		return
+	}
+	switch stage {
+	case stageDSCollecting:
+		k.hooks.AfterCiphertextStageClosed(ctx, k.instance, round)
+	case stageFailed:
+		k.hooks.AfterRoundFailed(ctx, k.instance, round)
+	}
+}
+
+// stageHeight returns the block height at which the current stage of the given round was set
+func (k *Keeper) stageHeight(ctx sdk.Context, round uint64) int64 {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyStageHeight)
+	if !store.Has(keyBytes) {
+		return ctx.BlockHeight()
+	}
+	return int64(binary.LittleEndian.Uint64(store.Get(keyBytes)))
 }
 func (k *Keeper) setRound(ctx sdk.Context, round uint64) {
 	currentRound := round
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	roundBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(roundBytes, currentRound)
 	store.Set([]byte(keyCurrentRound), roundBytes)
 }
 
-func (k *Keeper) SetRandomResult(ctx sdk.Context, round uint64) sdk.Error {
-	defer func() {
-		if r := recover(); r != nil {
-			log.Println("PANIC:", r)
-		}
-	}()
+func (k *Keeper) SetRandomResult(ctx sdk.Context, round uint64) (sdkErr sdk.Error) {
+	defer recoverError(&sdkErr)
+	result, err := k.computeRandomResult(ctx, round)
+	if err != nil {
+		return err
+	}
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyRandomResult)
+	store.Set(keyBytes, result)
+	k.emitRandomResult(ctx, round, result)
+	return nil
+}
+
+// computeRandomResult returns hash of the aggregated ciphertext decrypted with the round's decryption shares
+func (k *Keeper) computeRandomResult(ctx sdk.Context, round uint64) ([]byte, sdk.Error) {
 	dsList, err := k.GetAllDecryptionShares(ctx, round)
 	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("can't get all decryption shares from store: %v", err))
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't get all decryption shares from store: %v", err))
 	}
 	ds := make([]*share.PubShare, 0, len(dsList))
 	for _, decShare := range dsList {
@@ -425,28 +590,51 @@ func (k *Keeper) SetRandomResult(ctx sdk.Context, round uint64) sdk.Error {
 	}
 	aggCt, err := k.GetAggregatedCiphertext(ctx, round)
 	if err != nil {
-		return sdk.ErrUnknownRequest(fmt.Sprintf("can't get aggregated ciphertext from store: %v", err))
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't get aggregated ciphertext from store: %v", err))
 	}
 
+	t, n, err := k.decryptionThresholds(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+
+	return decryptResult(*aggCt, ds, t, n)
+}
+
+// decryptionThresholds returns the decryption threshold and the number of key holders of the round's key epoch,
+// the current ones are used for the rounds started before the key epochs
+func (k *Keeper) decryptionThresholds(ctx sdk.Context, round uint64) (uint64, int, sdk.Error) {
+	if epoch, err := k.GetRoundEpoch(ctx, round); err == nil {
+		return epoch.ThresholdDecryption, len(epoch.KeyHolders), nil
+	}
+	t, err := k.GetThresholdDecryption(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
 	n, err := k.GetKeyHoldersNumber(ctx)
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
+	return t, int(n), nil
+}
 
-	resultPoint := elgamal.Decrypt(P256, *aggCt, ds, int(n))
+// decryptResult returns hash of the aggregated ciphertext decrypted with the decryption shares
+func decryptResult(aggCt elgamal.Ciphertext, ds []*share.PubShare, t uint64, n int) ([]byte, sdk.Error) {
+	resultPoint, err := elgamal.Decrypt(P256, aggCt, ds, int(t), n)
+	if err != nil {
+		return nil, sdk.ErrInternal(fmt.Sprintf("can't decrypt the aggregated ciphertext: %v", err))
+	}
 	hash := P256.Hash()
-	_, err2 := resultPoint.MarshalTo(hash)
-	if err2 != nil {
-		return sdk.ErrInternal(fmt.Sprintf("failed to marshal result point to hash: %v", err))
+	_, err = resultPoint.MarshalTo(hash)
+	if err != nil {
+		return nil, sdk.ErrInternal(fmt.Sprintf("failed to marshal result point to hash: %v", err))
 	}
 	result := hash.Sum(nil)
-	store := ctx.KVStore(k.storeKey)
-	keyBytes := createKeyBytesByRound(round, keyRandomResult)
-	store.Set(keyBytes, result)
-	return nil
+	return result, nil
 }
+
 func (k *Keeper) RandomResult(ctx sdk.Context, round uint64) ([]byte, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	keyBytes := createKeyBytesByRound(round, keyRandomResult)
 	if !store.Has(keyBytes) {
 		return nil, sdk.ErrUnknownRequest("can't get random result from store: %v")
@@ -457,14 +645,14 @@ func (k *Keeper) RandomResult(ctx sdk.Context, round uint64) ([]byte, sdk.Error)
 
 // for tests purposes
 func (k *Keeper) forceRoundStage(ctx sdk.Context, round uint64, stage string) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	keyBytes := createKeyBytesByRound(round, keyStage)
 	store.Set(keyBytes, []byte(stage))
 }
 
 // for tests purposes
 func (k *Keeper) forceCurrentRound(ctx sdk.Context, round uint64) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	roundBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(roundBytes, round)
 	store.Set([]byte(keyCurrentRound), roundBytes)
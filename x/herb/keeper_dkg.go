@@ -0,0 +1,671 @@
+package herb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	rabin "go.dedis.ch/kyber/v3/share/dkg/rabin"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+)
+
+//this file defines the on-chain distributed key generation (Rabin DKG) functions
+//participants keep DistKeyGenerator state locally and publish DKG messages as transactions,
+//the keeper only checks messages origin and signatures and computes the common key at the end
+
+// StartDKG stores the DKG participants and threshold and opens the deals phase
+func (k *Keeper) StartDKG(ctx sdk.Context, participants []types.DKGParticipantJSON, t uint64) sdk.Error {
+	store := k.store(ctx)
+	if store.Has([]byte(keyDKGParticipants)) {
+		return sdk.ErrUnknownRequest("DKG participants already exist")
+	}
+	if t < 1 || t > uint64(len(participants)) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("invalid DKG threshold %v for %v participants", t, len(participants)))
+	}
+	participantsBytes, err := k.cdc.MarshalJSON(participants)
+	if err != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal DKG participants: %v", err))
+	}
+	store.Set([]byte(keyDKGParticipants), participantsBytes)
+	tBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(tBytes, t)
+	store.Set([]byte(keyDKGThreshold), tBytes)
+	k.setDKGPhase(ctx, types.DKGPhaseDeals)
+	return nil
+}
+
+// GetDKGParticipants returns DKG participants, participant's index is its position in the list
+func (k *Keeper) GetDKGParticipants(ctx sdk.Context) ([]types.DKGParticipantJSON, sdk.Error) {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyDKGParticipants)) {
+		return []types.DKGParticipantJSON{}, nil
+	}
+	var participants []types.DKGParticipantJSON
+	err := k.cdc.UnmarshalJSON(store.Get([]byte(keyDKGParticipants)), &participants)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal DKG participants: %v", err))
+	}
+	return participants, nil
+}
+
+// GetDKGThreshold returns threshold of the distributed key
+func (k *Keeper) GetDKGThreshold(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyDKGThreshold)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyDKGThreshold)))
+}
+
+// GetDKGPhase returns current DKG phase
+func (k *Keeper) GetDKGPhase(ctx sdk.Context) string {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyDKGPhase)) {
+		return types.DKGPhaseNone
+	}
+	return string(store.Get([]byte(keyDKGPhase)))
+}
+
+func (k *Keeper) setDKGPhase(ctx sdk.Context, phase string) {
+	oldPhase := k.GetDKGPhase(ctx)
+	store := k.store(ctx)
+	store.Set([]byte(keyDKGPhase), []byte(phase))
+	heightBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(heightBytes, uint64(ctx.BlockHeight()))
+	store.Set([]byte(keyDKGPhaseHeight), heightBytes)
+	k.emitDKGPhaseChanged(ctx, oldPhase, phase)
+}
+
+// dkgPhaseHeight returns the block height at which the current DKG phase was started
+func (k *Keeper) dkgPhaseHeight(ctx sdk.Context) int64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyDKGPhaseHeight)) {
+		return ctx.BlockHeight()
+	}
+	return int64(binary.LittleEndian.Uint64(store.Get([]byte(keyDKGPhaseHeight))))
+}
+
+// dkgRunning returns true if the DKG was started and isn't finished yet
+func (k *Keeper) dkgRunning(ctx sdk.Context) bool {
+	phase := k.GetDKGPhase(ctx)
+	return phase != types.DKGPhaseNone && phase != types.DKGPhaseCompleted && phase != types.DKGPhaseFailed
+}
+
+// dkgParticipant returns index and deserialized participant for the given address
+func (k *Keeper) dkgParticipant(ctx sdk.Context, addr sdk.AccAddress) (uint32, *types.DKGParticipant, sdk.Error) {
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	for i, p := range participants {
+		if p.Address.Equals(addr) {
+			participant, err := p.Deserialize()
+			if err != nil {
+				return 0, nil, err
+			}
+			return uint32(i), participant, nil
+		}
+	}
+	return 0, nil, sdk.ErrUnauthorized(fmt.Sprintf("%v isn't a DKG participant", addr))
+}
+
+// SetDKGDeals stores the dealer's deals for other participants
+func (k *Keeper) SetDKGDeals(ctx sdk.Context, sender sdk.AccAddress, deals []types.DKGDeal) sdk.Error {
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return err
+	}
+	dealer, _, err := k.dkgParticipant(ctx, sender)
+	if err != nil {
+		return err
+	}
+	n := uint32(len(participants))
+	recipients := make(map[uint32]bool)
+	data := make([][]byte, len(deals))
+	for i, deal := range deals {
+		if deal.Recipient >= n {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("deal recipient %v is out of bounds", deal.Recipient))
+		}
+		if deal.Recipient == dealer {
+			return sdk.ErrUnknownRequest("dealer can't send deal to itself")
+		}
+		if recipients[deal.Recipient] {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("duplicate deal for recipient %v", deal.Recipient))
+		}
+		recipients[deal.Recipient] = true
+		data[i] = deal.Data
+	}
+
+	_, err = k.setDKGMessages(ctx, types.DKGPhaseDeals, sender, data, func(index uint32, _ kyber.Point, bz []byte) error {
+		var deal rabin.Deal
+		if err := types.DecodeDKGMessage(bz, &deal); err != nil {
+			return err
+		}
+		if deal.Index != index {
+			return fmt.Errorf("deal dealer index %v doesn't match sender index %v", deal.Index, index)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	store := k.store(ctx)
+	for _, deal := range deals {
+		store.Set(createDKGDealKey(deal.Recipient, dealer), deal.Data)
+	}
+	k.tryAdvanceDKGPhase(ctx, types.DKGPhaseDeals)
+	return nil
+}
+
+// GetDKGDeals returns all deals addressed to the recipient
+func (k *Keeper) GetDKGDeals(ctx sdk.Context, recipient uint32) ([][]byte, sdk.Error) {
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := k.store(ctx)
+	deals := make([][]byte, 0, len(participants))
+	for dealer := range participants {
+		key := createDKGDealKey(recipient, uint32(dealer))
+		if store.Has(key) {
+			deals = append(deals, store.Get(key))
+		}
+	}
+	return deals, nil
+}
+
+// SetDKGResponses stores the participant's responses to the received deals
+func (k *Keeper) SetDKGResponses(ctx sdk.Context, sender sdk.AccAddress, responses [][]byte) sdk.Error {
+	_, err := k.setDKGMessages(ctx, types.DKGPhaseResponses, sender, responses, func(index uint32, _ kyber.Point, bz []byte) error {
+		var resp rabin.Response
+		if err := types.DecodeDKGMessage(bz, &resp); err != nil {
+			return err
+		}
+		if resp.Response == nil || resp.Response.Index != index {
+			return fmt.Errorf("response verifier index doesn't match sender index %v", index)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	k.tryAdvanceDKGPhase(ctx, types.DKGPhaseResponses)
+	return nil
+}
+
+// SetDKGJustifications stores the dealer's justifications
+func (k *Keeper) SetDKGJustifications(ctx sdk.Context, sender sdk.AccAddress, justifications [][]byte) sdk.Error {
+	_, err := k.setDKGMessages(ctx, types.DKGPhaseJustify, sender, justifications, func(index uint32, _ kyber.Point, bz []byte) error {
+		var j rabin.Justification
+		if err := types.DecodeDKGMessage(bz, &j); err != nil {
+			return err
+		}
+		if j.Index != index {
+			return fmt.Errorf("justification dealer index %v doesn't match sender index %v", j.Index, index)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	k.tryAdvanceDKGPhase(ctx, types.DKGPhaseJustify)
+	return nil
+}
+
+// SetDKGSecretCommits stores the dealer's secret commits and the QUAL set seen by the dealer
+func (k *Keeper) SetDKGSecretCommits(ctx sdk.Context, sender sdk.AccAddress, secretCommits []byte, qual []uint32) sdk.Error {
+	t := k.GetDKGThreshold(ctx)
+	var data [][]byte
+	if len(secretCommits) > 0 {
+		data = [][]byte{secretCommits}
+	}
+	index, err := k.setDKGMessages(ctx, types.DKGPhaseCommits, sender, data, func(index uint32, pub kyber.Point, bz []byte) error {
+		var sc rabin.SecretCommits
+		if err := types.DecodeDKGMessage(bz, &sc); err != nil {
+			return err
+		}
+		if sc.Index != index {
+			return fmt.Errorf("secret commits dealer index %v doesn't match sender index %v", sc.Index, index)
+		}
+		if uint64(len(sc.Commitments)) != t {
+			return fmt.Errorf("wrong number of commitments: %v, expected: %v", len(sc.Commitments), t)
+		}
+		return schnorr.Verify(P256, pub, sc.Hash(P256), sc.Signature)
+	})
+	if err != nil {
+		return err
+	}
+	qualBytes, err2 := k.cdc.MarshalJSON(qual)
+	if err2 != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal QUAL: %v", err2))
+	}
+	store := k.store(ctx)
+	store.Set(createDKGMessageKey(keyDKGQUAL, index), qualBytes)
+	k.tryAdvanceDKGPhase(ctx, types.DKGPhaseCommits)
+	return nil
+}
+
+// SetDKGComplaintCommits stores the participant's complaints about invalid secret commits
+func (k *Keeper) SetDKGComplaintCommits(ctx sdk.Context, sender sdk.AccAddress, complaints [][]byte) sdk.Error {
+	_, err := k.setDKGMessages(ctx, types.DKGPhaseComplaints, sender, complaints, func(index uint32, pub kyber.Point, bz []byte) error {
+		var cc rabin.ComplaintCommits
+		if err := types.DecodeDKGMessage(bz, &cc); err != nil {
+			return err
+		}
+		if cc.Index != index {
+			return fmt.Errorf("complaint issuer index %v doesn't match sender index %v", cc.Index, index)
+		}
+		return schnorr.Verify(P256, pub, cc.Hash(P256), cc.Signature)
+	})
+	if err != nil {
+		return err
+	}
+	k.tryAdvanceDKGPhase(ctx, types.DKGPhaseComplaints)
+	return nil
+}
+
+// SetDKGReconstructCommits stores the participant's shares of the dealers which got complaints
+func (k *Keeper) SetDKGReconstructCommits(ctx sdk.Context, sender sdk.AccAddress, reconstructs [][]byte) sdk.Error {
+	_, err := k.setDKGMessages(ctx, types.DKGPhaseReconstructs, sender, reconstructs, func(index uint32, pub kyber.Point, bz []byte) error {
+		var rc rabin.ReconstructCommits
+		if err := types.DecodeDKGMessage(bz, &rc); err != nil {
+			return err
+		}
+		if rc.Index != index {
+			return fmt.Errorf("reconstruct commits issuer index %v doesn't match sender index %v", rc.Index, index)
+		}
+		if rc.Share == nil {
+			return fmt.Errorf("reconstruct commits share is empty")
+		}
+		if rc.Share.I != int(index) {
+			return fmt.Errorf("reconstruct commits share index %v doesn't match sender index %v", rc.Share.I, index)
+		}
+		return schnorr.Verify(P256, pub, rc.Hash(P256), rc.Signature)
+	})
+	if err != nil {
+		return err
+	}
+	k.tryAdvanceDKGPhase(ctx, types.DKGPhaseReconstructs)
+	return nil
+}
+
+// setDKGMessages checks DKG phase and sender, verifies and stores the participant's messages for the phase
+// Each participant can send messages only once per phase
+func (k *Keeper) setDKGMessages(ctx sdk.Context, phase string, sender sdk.AccAddress, data [][]byte,
+	verify func(index uint32, pub kyber.Point, bz []byte) error) (uint32, sdk.Error) {
+	if currentPhase := k.GetDKGPhase(ctx); currentPhase != phase {
+		return 0, sdk.ErrUnknownRequest(fmt.Sprintf("wrong DKG phase: %v, expected: %v", currentPhase, phase))
+	}
+	index, participant, err := k.dkgParticipant(ctx, sender)
+	if err != nil {
+		return 0, err
+	}
+	store := k.store(ctx)
+	key := createDKGMessageKey(phase, index)
+	if store.Has(key) {
+		return 0, sdk.ErrUnknownRequest(fmt.Sprintf("participant %v has already sent messages at phase %v", index, phase))
+	}
+	for _, bz := range data {
+		if err := verify(index, participant.PubKey, bz); err != nil {
+			return 0, sdk.ErrUnknownRequest(fmt.Sprintf("invalid DKG message: %v", err))
+		}
+	}
+	if data == nil {
+		data = [][]byte{}
+	}
+	dataBytes, err2 := k.cdc.MarshalJSON(data)
+	if err2 != nil {
+		return 0, sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal DKG messages: %v", err2))
+	}
+	store.Set(key, dataBytes)
+	return index, nil
+}
+
+// GetDKGMessages returns all messages sent at the DKG phase
+func (k *Keeper) GetDKGMessages(ctx sdk.Context, phase string) ([]types.DKGMessages, sdk.Error) {
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := k.store(ctx)
+	messages := make([]types.DKGMessages, 0, len(participants))
+	for i := range participants {
+		key := createDKGMessageKey(phase, uint32(i))
+		if !store.Has(key) {
+			continue
+		}
+		var data [][]byte
+		if err := k.cdc.UnmarshalJSON(store.Get(key), &data); err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal DKG messages: %v", err))
+		}
+		messages = append(messages, types.DKGMessages{Index: uint32(i), Data: data})
+	}
+	return messages, nil
+}
+
+// tryAdvanceDKGPhase moves the DKG to the next phase as soon as all participants have sent their messages
+func (k *Keeper) tryAdvanceDKGPhase(ctx sdk.Context, phase string) {
+	messages, err := k.GetDKGMessages(ctx, phase)
+	if err != nil {
+		return
+	}
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return
+	}
+	if len(messages) == len(participants) {
+		k.advanceDKGPhase(ctx)
+	}
+}
+
+// advanceDKGPhase moves the DKG to the next phase, after the last phase the common key is computed
+func (k *Keeper) advanceDKGPhase(ctx sdk.Context) {
+	phase := k.GetDKGPhase(ctx)
+	var next string
+	for i, p := range types.DKGPhases {
+		if p == phase && i+1 < len(types.DKGPhases) {
+			next = types.DKGPhases[i+1]
+		}
+	}
+	if next == "" {
+		return
+	}
+	if next == types.DKGPhaseCompleted {
+		if err := k.finishDKG(ctx); err != nil {
+			ctx.Logger().Error(fmt.Sprintf("herb DKG failed: %v", err))
+			k.setDKGPhase(ctx, types.DKGPhaseFailed)
+			return
+		}
+	}
+	k.setDKGPhase(ctx, next)
+}
+
+// dkgQUAL returns the QUAL set reported by the majority of participants
+func (k *Keeper) dkgQUAL(ctx sdk.Context, n int) ([]uint32, error) {
+	store := k.store(ctx)
+	votes := make(map[string]int)
+	sets := make(map[string][]uint32)
+	for i := 0; i < n; i++ {
+		key := createDKGMessageKey(keyDKGQUAL, uint32(i))
+		if !store.Has(key) {
+			continue
+		}
+		var qual []uint32
+		if err := k.cdc.UnmarshalJSON(store.Get(key), &qual); err != nil {
+			return nil, err
+		}
+		sort.Slice(qual, func(a, b int) bool { return qual[a] < qual[b] })
+		qualStr := fmt.Sprint(qual)
+		votes[qualStr]++
+		sets[qualStr] = qual
+	}
+	var best string
+	for qualStr, v := range votes {
+		if v > votes[best] || (v == votes[best] && qualStr < best) {
+			best = qualStr
+		}
+	}
+	if votes[best]*2 <= n {
+		return nil, fmt.Errorf("participants haven't agreed on QUAL set")
+	}
+	return sets[best], nil
+}
+
+// finishDKG computes the distributed public polynomial and writes common key, verification keys and thresholds
+// If the keys already exist they are stored as the pending key epoch
+func (k *Keeper) finishDKG(ctx sdk.Context) error {
+	participantsJSON, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return err
+	}
+	n := len(participantsJSON)
+	t := int(k.GetDKGThreshold(ctx))
+	qual, err2 := k.dkgQUAL(ctx, n)
+	if err2 != nil {
+		return err2
+	}
+	if len(qual) < t {
+		return fmt.Errorf("QUAL set is too small: %v, threshold: %v", len(qual), t)
+	}
+
+	commits := make(map[uint32]*rabin.SecretCommits)
+	commitsMessages, err := k.GetDKGMessages(ctx, types.DKGPhaseCommits)
+	if err != nil {
+		return err
+	}
+	for _, msg := range commitsMessages {
+		for _, bz := range msg.Data {
+			var sc rabin.SecretCommits
+			if err := types.DecodeDKGMessage(bz, &sc); err != nil {
+				return err
+			}
+			commits[sc.Index] = &sc
+		}
+	}
+
+	verifiers := make([]kyber.Point, n)
+	for i, p := range participantsJSON {
+		participant, err := p.Deserialize()
+		if err != nil {
+			return err
+		}
+		verifiers[i] = participant.PubKey
+	}
+	sessionIDs, err2 := k.dkgSessionIDs(ctx)
+	if err2 != nil {
+		return err2
+	}
+	inQUAL := make(map[uint32]bool)
+	for _, idx := range qual {
+		inQUAL[idx] = true
+	}
+
+	// a valid complaint proves the dealer's secret commits don't match its deal, such commits are dropped
+	// and the dealer's polynomial has to be reconstructed from the participants' shares
+	disputed := make(map[uint32][]byte)
+	complaintMessages, err := k.GetDKGMessages(ctx, types.DKGPhaseComplaints)
+	if err != nil {
+		return err
+	}
+	for _, msg := range complaintMessages {
+		for _, bz := range msg.Data {
+			var cc rabin.ComplaintCommits
+			if err := types.DecodeDKGMessage(bz, &cc); err != nil {
+				return err
+			}
+			if _, ok := disputed[cc.DealerIndex]; ok {
+				continue
+			}
+			if err := checkComplaintCommits(&cc, verifiers, inQUAL, sessionIDs, commits, t); err != nil {
+				ctx.Logger().Info(fmt.Sprintf("herb%s complaint of participant %v is rejected: %v", k.logInstance(), cc.Index, err))
+				continue
+			}
+			delete(commits, cc.DealerIndex)
+			disputed[cc.DealerIndex] = cc.Deal.SessionID
+		}
+	}
+
+	// shares of the disputed dealers' polynomials, one share per issuer
+	reconstructShares := make(map[uint32][]*share.PriShare)
+	issuers := make(map[uint32]map[uint32]bool)
+	reconstructMessages, err := k.GetDKGMessages(ctx, types.DKGPhaseReconstructs)
+	if err != nil {
+		return err
+	}
+	for _, msg := range reconstructMessages {
+		for _, bz := range msg.Data {
+			var rc rabin.ReconstructCommits
+			if err := types.DecodeDKGMessage(bz, &rc); err != nil {
+				return err
+			}
+			sid, ok := disputed[rc.DealerIndex]
+			if !ok || !bytes.Equal(sid, rc.SessionID) || int(rc.Index) >= n || rc.Share == nil || rc.Share.V == nil {
+				ctx.Logger().Info(fmt.Sprintf("herb%s reconstruct share of participant %v is rejected", k.logInstance(), rc.Index))
+				continue
+			}
+			if issuers[rc.DealerIndex] == nil {
+				issuers[rc.DealerIndex] = make(map[uint32]bool)
+			}
+			if issuers[rc.DealerIndex][rc.Index] {
+				continue
+			}
+			issuers[rc.DealerIndex][rc.Index] = true
+			reconstructShares[rc.DealerIndex] = append(reconstructShares[rc.DealerIndex], rc.Share)
+		}
+	}
+
+	var pubPoly *share.PubPoly
+	for _, dealer := range qual {
+		var poly *share.PubPoly
+		if _, ok := disputed[dealer]; ok {
+			shares := reconstructShares[dealer]
+			if len(shares) < t {
+				return fmt.Errorf("not enough shares to reconstruct dealer %v polynomial: %v, threshold: %v", dealer, len(shares), t)
+			}
+			priPoly, err := share.RecoverPriPoly(P256, shares, t, n)
+			if err != nil {
+				return fmt.Errorf("can't reconstruct dealer %v polynomial: %v", dealer, err)
+			}
+			poly = priPoly.Commit(P256.Point().Base())
+		} else if sc, ok := commits[dealer]; ok {
+			poly = share.NewPubPoly(P256, P256.Point().Base(), sc.Commitments)
+		} else {
+			return fmt.Errorf("commitments of dealer %v are missing", dealer)
+		}
+		if pubPoly == nil {
+			pubPoly = poly
+			continue
+		}
+		if pubPoly, err2 = pubPoly.Add(poly); err2 != nil {
+			return err2
+		}
+	}
+
+	vkList := make([]*types.VerificationKey, n)
+	for i, p := range participantsJSON {
+		vkList[i] = &types.VerificationKey{Key: pubPoly.Eval(i).V, KeyHolderID: i, Sender: p.Address}
+	}
+	vkJSONList, err := types.VerificationKeyArraySerialize(vkList)
+	if err != nil {
+		return err
+	}
+	commonKey, err2 := kyberenc.PointToStringHex(P256, pubPoly.Commit())
+	if err2 != nil {
+		return err2
+	}
+	// long-term keys of the participants encrypt the refresh deals until the key holders register other keys
+	for _, p := range participantsJSON {
+		k.setEncryptionKey(ctx, p.Address, p.PubKey)
+	}
+	// the key holders set is changed, new keys wait for the round boundary
+	if k.keysDefined(ctx) {
+		return k.setPendingKeyEpoch(ctx, commonKey, vkJSONList, uint64(t))
+	}
+	thresholdCiphertexts, err := k.GetThresholdCiphertexts(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := k.SetVerificationKeys(ctx, vkJSONList); err != nil {
+		return err
+	}
+	k.SetCommonPublicKey(ctx, commonKey)
+	k.SetKeyHoldersNumber(ctx, uint64(n))
+	k.SetThreshold(ctx, thresholdCiphertexts, uint64(t))
+	k.setKeyEpoch(ctx, 0, k.CurrentRound(ctx))
+	return nil
+}
+
+// dkgSessionIDs returns the VSS session IDs approved by the verifiers, indexed by dealer and verifier
+func (k *Keeper) dkgSessionIDs(ctx sdk.Context) (map[uint32]map[uint32][]byte, error) {
+	messages, err := k.GetDKGMessages(ctx, types.DKGPhaseResponses)
+	if err != nil {
+		return nil, err
+	}
+	sessionIDs := make(map[uint32]map[uint32][]byte)
+	for _, msg := range messages {
+		for _, bz := range msg.Data {
+			var resp rabin.Response
+			if err := types.DecodeDKGMessage(bz, &resp); err != nil {
+				return nil, err
+			}
+			if !resp.Response.Approved {
+				continue
+			}
+			if sessionIDs[resp.Index] == nil {
+				sessionIDs[resp.Index] = make(map[uint32][]byte)
+			}
+			sessionIDs[resp.Index][resp.Response.Index] = resp.Response.SessionID
+		}
+	}
+	return sessionIDs, nil
+}
+
+// checkComplaintCommits verifies the complaint as rabin.DistKeyGenerator.ProcessComplaintCommits does:
+// the issuer's deal must verify against the deal commitments approved by the issuer and must not verify against the secret commits
+func checkComplaintCommits(cc *rabin.ComplaintCommits, verifiers []kyber.Point, inQUAL map[uint32]bool,
+	sessionIDs map[uint32]map[uint32][]byte, commits map[uint32]*rabin.SecretCommits, t int) error {
+	if !inQUAL[cc.Index] {
+		return fmt.Errorf("issuer %v isn't in QUAL", cc.Index)
+	}
+	if int(cc.DealerIndex) >= len(verifiers) {
+		return fmt.Errorf("unknown dealer %v", cc.DealerIndex)
+	}
+	deal := cc.Deal
+	if deal == nil || deal.SecShare == nil || deal.RndShare == nil || deal.SecShare.V == nil || deal.RndShare.V == nil {
+		return fmt.Errorf("deal is empty")
+	}
+	if int(deal.T) != t {
+		return fmt.Errorf("wrong deal threshold: %v, expected: %v", deal.T, t)
+	}
+	if deal.SecShare.I != int(cc.Index) || deal.RndShare.I != int(cc.Index) {
+		return fmt.Errorf("deal share index doesn't match issuer index %v", cc.Index)
+	}
+	sid := dkgSessionID(verifiers[cc.DealerIndex], verifiers, deal.Commitments, t)
+	if !bytes.Equal(sid, deal.SessionID) || !bytes.Equal(sid, sessionIDs[cc.DealerIndex][cc.Index]) {
+		return fmt.Errorf("deal session ID doesn't match the session approved by the issuer")
+	}
+	// deal check: SecShare*G + RndShare*H must be the evaluation of the deal commitments
+	ci := P256.Point().Add(P256.Point().Mul(deal.SecShare.V, nil), P256.Point().Mul(deal.RndShare.V, dkgDeriveH(verifiers)))
+	if !ci.Equal(share.NewPubPoly(P256, nil, deal.Commitments).Eval(deal.SecShare.I).V) {
+		return fmt.Errorf("deal doesn't verify against its commitments")
+	}
+	sc, ok := commits[cc.DealerIndex]
+	if !ok {
+		return fmt.Errorf("commitments of dealer %v are missing", cc.DealerIndex)
+	}
+	if share.NewPubPoly(P256, P256.Point().Base(), sc.Commitments).Check(deal.SecShare) {
+		return fmt.Errorf("deal verifies against secret commits of dealer %v", cc.DealerIndex)
+	}
+	return nil
+}
+
+// dkgDeriveH returns the second Pedersen commitment base used by the rabin VSS
+func dkgDeriveH(verifiers []kyber.Point) kyber.Point {
+	var b bytes.Buffer
+	for _, v := range verifiers {
+		_, _ = v.MarshalTo(&b)
+	}
+	return P256.Point().Pick(P256.XOF(b.Bytes()))
+}
+
+// dkgSessionID returns the rabin VSS session ID of the dealer's deals
+func dkgSessionID(dealer kyber.Point, verifiers, commitments []kyber.Point, t int) []byte {
+	h := P256.Hash()
+	_, _ = dealer.MarshalTo(h)
+	for _, v := range verifiers {
+		_, _ = v.MarshalTo(h)
+	}
+	for _, c := range commitments {
+		_, _ = c.MarshalTo(h)
+	}
+	_ = binary.Write(h, binary.LittleEndian, uint32(t))
+	return h.Sum(nil)
+}
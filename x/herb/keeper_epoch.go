@@ -0,0 +1,189 @@
+package herb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines key epochs. Key holders set is replaced via governance proposal:
+//new key holders run the DKG while rounds continue with the old keys,
+//keys produced by the DKG are applied at the next round boundary and start a new epoch.
+//Each round records its epoch, so results of the old rounds can be verified with the keys in force at the time.
+
+// ChangeKeyHolders starts the DKG for the new key holders set
+func (k *Keeper) ChangeKeyHolders(ctx sdk.Context, participants []types.DKGParticipantJSON, thresholdCiphertexts uint64, thresholdDecryption uint64) sdk.Error {
+	if k.dkgRunning(ctx) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("distributed key generation is already running, phase: %v", k.GetDKGPhase(ctx)))
+	}
+	if phase := k.GetRefreshPhase(ctx); phase != types.RefreshPhaseNone {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("key refresh is running, phase: %v", phase))
+	}
+	if k.GetPendingKeyEpoch(ctx) != nil {
+		return sdk.ErrUnknownRequest("new key holders set is already pending")
+	}
+	if thresholdCiphertexts < 1 {
+		return sdk.ErrUnknownRequest("threshold for ciphertext shares must be positive")
+	}
+	if _, err := types.DKGParticipantArrayDeserialize(participants); err != nil {
+		return err
+	}
+	if err := k.clearDKG(ctx); err != nil {
+		return err
+	}
+	if err := k.StartDKG(ctx, participants, thresholdDecryption); err != nil {
+		return err
+	}
+	store := k.store(ctx)
+	tBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(tBytes, thresholdCiphertexts)
+	store.Set([]byte(keyPendingThresholdCiphertexts), tBytes)
+	return nil
+}
+
+// clearDKG removes participants and messages of the previous DKG
+func (k *Keeper) clearDKG(ctx sdk.Context) sdk.Error {
+	participants, err := k.GetDKGParticipants(ctx)
+	if err != nil {
+		return err
+	}
+	store := k.store(ctx)
+	for i := range participants {
+		for j := range participants {
+			store.Delete(createDKGDealKey(uint32(i), uint32(j)))
+		}
+		for _, phase := range types.DKGPhases {
+			store.Delete(createDKGMessageKey(phase, uint32(i)))
+		}
+		store.Delete(createDKGMessageKey(keyDKGQUAL, uint32(i)))
+	}
+	store.Delete([]byte(keyDKGParticipants))
+	store.Delete([]byte(keyDKGThreshold))
+	store.Delete([]byte(keyPendingThresholdCiphertexts))
+	return nil
+}
+
+// keysDefined returns true if the common key and verification keys are already set
+func (k *Keeper) keysDefined(ctx sdk.Context) bool {
+	store := k.store(ctx)
+	return store.Has([]byte(keyVerificationKeys))
+}
+
+// setPendingKeyEpoch stores keys produced by the key holders change DKG
+func (k *Keeper) setPendingKeyEpoch(ctx sdk.Context, commonKey string, keyHolders []types.VerificationKeyJSON, thresholdDecryption uint64) error {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyPendingThresholdCiphertexts)) {
+		return fmt.Errorf("threshold for ciphertext shares of the new key holders isn't defined")
+	}
+	epoch := types.KeyEpoch{
+		CommonPublicKey:      commonKey,
+		KeyHolders:           keyHolders,
+		ThresholdCiphertexts: binary.LittleEndian.Uint64(store.Get([]byte(keyPendingThresholdCiphertexts))),
+		ThresholdDecryption:  thresholdDecryption,
+	}
+	epochBytes, err := k.cdc.MarshalJSON(epoch)
+	if err != nil {
+		return err
+	}
+	store.Set([]byte(keyPendingEpoch), epochBytes)
+	return nil
+}
+
+// GetPendingKeyEpoch returns keys of the new key holders set which are waiting for the round boundary
+func (k *Keeper) GetPendingKeyEpoch(ctx sdk.Context) *types.KeyEpoch {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyPendingEpoch)) {
+		return nil
+	}
+	var epoch types.KeyEpoch
+	k.cdc.MustUnmarshalJSON(store.Get([]byte(keyPendingEpoch)), &epoch)
+	return &epoch
+}
+
+// applyKeyEpoch replaces common key, verification keys and thresholds with the pending ones, it's called at the round boundary
+func (k *Keeper) applyKeyEpoch(ctx sdk.Context, round uint64) {
+	epoch := k.GetPendingKeyEpoch(ctx)
+	if epoch == nil {
+		return
+	}
+	store := k.store(ctx)
+	k.setVerificationKeysBytes(ctx, k.cdc.MustMarshalJSON(epoch.KeyHolders))
+	k.SetCommonPublicKey(ctx, epoch.CommonPublicKey)
+	k.SetKeyHoldersNumber(ctx, uint64(len(epoch.KeyHolders)))
+	k.SetThreshold(ctx, epoch.ThresholdCiphertexts, epoch.ThresholdDecryption)
+	roundBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(roundBytes, round)
+	store.Set([]byte(keyVerificationKeysRound), roundBytes)
+	store.Delete([]byte(keyPendingEpoch))
+	store.Delete([]byte(keyPendingThresholdCiphertexts))
+	k.setKeyEpoch(ctx, k.CurrentKeyEpoch(ctx)+1, round)
+}
+
+// setKeyEpoch saves current keys and thresholds as the key epoch data and makes the epoch current
+func (k *Keeper) setKeyEpoch(ctx sdk.Context, number uint64, startRound uint64) {
+	store := k.store(ctx)
+	keyHolders, _ := k.GetVerificationKeys(ctx)
+	tCt, _ := k.GetThresholdCiphertexts(ctx)
+	tDec, _ := k.GetThresholdDecryption(ctx)
+	epoch := types.KeyEpoch{
+		Number:               number,
+		StartRound:           startRound,
+		CommonPublicKey:      string(store.Get([]byte(keyCommonKey))),
+		KeyHolders:           keyHolders,
+		ThresholdCiphertexts: tCt,
+		ThresholdDecryption:  tDec,
+	}
+	k.storeKeyEpoch(ctx, epoch)
+}
+
+// storeKeyEpoch stores the key epoch data and makes the epoch current
+func (k *Keeper) storeKeyEpoch(ctx sdk.Context, epoch types.KeyEpoch) {
+	store := k.store(ctx)
+	store.Set(createEpochKey(epoch.Number), k.cdc.MustMarshalJSON(epoch))
+	numberBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(numberBytes, epoch.Number)
+	store.Set([]byte(keyKeyEpoch), numberBytes)
+}
+
+// CurrentKeyEpoch returns number of the key epoch used by new rounds
+func (k *Keeper) CurrentKeyEpoch(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyKeyEpoch)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyKeyEpoch)))
+}
+
+// GetKeyEpoch returns keys and thresholds of the given key epoch
+func (k *Keeper) GetKeyEpoch(ctx sdk.Context, number uint64) (*types.KeyEpoch, sdk.Error) {
+	store := k.store(ctx)
+	keyBytes := createEpochKey(number)
+	if !store.Has(keyBytes) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("key epoch %v doesn't exist", number))
+	}
+	var epoch types.KeyEpoch
+	if err := k.cdc.UnmarshalJSON(store.Get(keyBytes), &epoch); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal key epoch: %v", err))
+	}
+	return &epoch, nil
+}
+
+// setRoundEpoch records the key epoch used by the round
+func (k *Keeper) setRoundEpoch(ctx sdk.Context, round uint64) {
+	store := k.store(ctx)
+	epochBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(epochBytes, k.CurrentKeyEpoch(ctx))
+	store.Set(createKeyBytesByRound(round, keyRoundEpoch), epochBytes)
+}
+
+// GetRoundEpoch returns the key epoch used by the given round
+func (k *Keeper) GetRoundEpoch(ctx sdk.Context, round uint64) (*types.KeyEpoch, sdk.Error) {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyRoundEpoch)
+	if !store.Has(keyBytes) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("round %v hasn't started yet", round))
+	}
+	return k.GetKeyEpoch(ctx, binary.LittleEndian.Uint64(store.Get(keyBytes)))
+}
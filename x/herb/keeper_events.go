@@ -0,0 +1,117 @@
+package herb
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines events emitted on the herb state changes, so indexers and subscribers can follow the beacon without polling
+
+func (k *Keeper) emitShareAccepted(ctx sdk.Context, round uint64, shareType string, sender sdk.AccAddress, count int) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeShareAccepted,
+			sdk.NewAttribute(types.AttributeKeyRound, strconv.FormatUint(round, 10)),
+			sdk.NewAttribute(types.AttributeKeyShareType, shareType),
+			sdk.NewAttribute(types.AttributeKeySender, sender.String()),
+			sdk.NewAttribute(types.AttributeKeyShareCount, strconv.Itoa(count)),
+		),
+	)
+}
+
+func (k *Keeper) emitStageChanged(ctx sdk.Context, round uint64, oldStage string, newStage string) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeStageChanged,
+			sdk.NewAttribute(types.AttributeKeyRound, strconv.FormatUint(round, 10)),
+			sdk.NewAttribute(types.AttributeKeyOldStage, oldStage),
+			sdk.NewAttribute(types.AttributeKeyNewStage, newStage),
+		),
+	)
+}
+
+func (k *Keeper) emitRandomResult(ctx sdk.Context, round uint64, result []byte) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeRandomResult,
+			sdk.NewAttribute(types.AttributeKeyRound, strconv.FormatUint(round, 10)),
+			sdk.NewAttribute(types.AttributeKeyResult, hex.EncodeToString(result)),
+		),
+	)
+}
+
+func (k *Keeper) emitRequest(ctx sdk.Context, request types.RandomnessRequest) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeRequest,
+			sdk.NewAttribute(types.AttributeKeyRequestID, strconv.FormatUint(request.ID, 10)),
+			sdk.NewAttribute(types.AttributeKeyRound, strconv.FormatUint(request.Round, 10)),
+			sdk.NewAttribute(types.AttributeKeySender, request.Requester.String()),
+		),
+	)
+}
+
+func (k *Keeper) emitDKGMessage(ctx sdk.Context, msgType string, sender sdk.AccAddress) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeDKGMessage,
+			sdk.NewAttribute(types.AttributeKeyMessageType, msgType),
+			sdk.NewAttribute(types.AttributeKeySender, sender.String()),
+			sdk.NewAttribute(types.AttributeKeyPhase, k.GetDKGPhase(ctx)),
+		),
+	)
+}
+
+func (k *Keeper) emitDKGPhaseChanged(ctx sdk.Context, oldPhase string, newPhase string) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeDKGPhaseChanged,
+			sdk.NewAttribute(types.AttributeKeyOldPhase, oldPhase),
+			sdk.NewAttribute(types.AttributeKeyNewPhase, newPhase),
+		),
+	)
+}
+
+func (k *Keeper) emitRefreshMessage(ctx sdk.Context, msgType string, sender sdk.AccAddress) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeRefreshMessage,
+			sdk.NewAttribute(types.AttributeKeyMessageType, msgType),
+			sdk.NewAttribute(types.AttributeKeySender, sender.String()),
+			sdk.NewAttribute(types.AttributeKeyPhase, k.GetRefreshPhase(ctx)),
+			sdk.NewAttribute(types.AttributeKeyRefreshNumber, strconv.FormatUint(k.GetRefreshNumber(ctx), 10)),
+		),
+	)
+}
+
+func (k *Keeper) emitRefreshPhaseChanged(ctx sdk.Context, oldPhase string, newPhase string) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			types.EventTypeRefreshPhaseChanged,
+			sdk.NewAttribute(types.AttributeKeyOldPhase, oldPhase),
+			sdk.NewAttribute(types.AttributeKeyNewPhase, newPhase),
+			sdk.NewAttribute(types.AttributeKeyRefreshNumber, strconv.FormatUint(k.GetRefreshNumber(ctx), 10)),
+		),
+	)
+}
+
+func (k *Keeper) emitProviderEvent(ctx sdk.Context, eventType string, provider types.EntropyProvider) {
+	ctx.EventManager().EmitEvent(
+		k.newEvent(
+			eventType,
+			sdk.NewAttribute(types.AttributeKeySender, provider.Address.String()),
+			sdk.NewAttribute(types.AttributeKeyBond, provider.Bond.String()),
+		),
+	)
+}
+
+// newEvent creates the event with the instance attribute for the instances besides the default one
+func (k *Keeper) newEvent(eventType string, attrs ...sdk.Attribute) sdk.Event {
+	if k.instance != types.DefaultInstance {
+		attrs = append(attrs, sdk.NewAttribute(types.AttributeKeyInstance, k.instance))
+	}
+	return sdk.NewEvent(eventType, attrs...)
+}
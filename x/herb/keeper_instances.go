@@ -0,0 +1,111 @@
+package herb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cosmos/cosmos-sdk/store/prefix"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+//this file defines beacon instances. Every instance is a separate beacon with its own key holders, thresholds,
+//params, rounds and shares. The default instance keeps its state at the root of the module stores, so the chains
+//started before instances keep their layout. Other instances are defined in the genesis and keep their state
+//under the instance prefix. Keeper methods work on the keeper's instance, Instance returns the keeper of the other one.
+
+// Instance returns the keeper of the given beacon instance, it shares the hooks of the keeper
+func (k *Keeper) Instance(id string) Keeper {
+	instance := *k
+	instance.instance = id
+	return instance
+}
+
+// InstanceID returns ID of the keeper's beacon instance
+func (k *Keeper) InstanceID() string {
+	return k.instance
+}
+
+// instanceKeeper returns the keeper of the existing beacon instance
+func (k *Keeper) instanceKeeper(ctx sdk.Context, id string) (Keeper, sdk.Error) {
+	if !k.HasInstance(ctx, id) {
+		return Keeper{}, types.ErrUnknownInstance(id)
+	}
+	return k.Instance(id), nil
+}
+
+// HasInstance returns true if the beacon instance exists, the default instance always exists
+func (k *Keeper) HasInstance(ctx sdk.Context, id string) bool {
+	if id == types.DefaultInstance {
+		return true
+	}
+	for _, instance := range k.GetInstances(ctx) {
+		if instance == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GetInstances returns IDs of all beacon instances in order, the default instance goes first
+func (k *Keeper) GetInstances(ctx sdk.Context) []string {
+	instances := []string{types.DefaultInstance}
+	store := ctx.KVStore(k.storeKey)
+	if !store.Has([]byte(keyInstances)) {
+		return instances
+	}
+	var ids []string
+	k.cdc.MustUnmarshalJSON(store.Get([]byte(keyInstances)), &ids)
+	return append(instances, ids...)
+}
+
+// addInstance registers the beacon instance
+func (k *Keeper) addInstance(ctx sdk.Context, id string) sdk.Error {
+	if err := types.ValidateInstanceID(id); err != nil {
+		return err
+	}
+	if k.HasInstance(ctx, id) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("beacon instance %q already exists", id))
+	}
+	ids := append(k.GetInstances(ctx)[1:], id)
+	sort.Strings(ids)
+	ctx.KVStore(k.storeKey).Set([]byte(keyInstances), k.cdc.MustMarshalJSON(ids))
+	return nil
+}
+
+// store returns the main store of the keeper's instance
+func (k *Keeper) store(ctx sdk.Context) sdk.KVStore {
+	return k.instanceStore(ctx, k.storeKey)
+}
+
+// ctStore returns the ciphertext shares store of the keeper's instance
+func (k *Keeper) ctStore(ctx sdk.Context) sdk.KVStore {
+	return k.instanceStore(ctx, k.storeCiphertextSharesKey)
+}
+
+// dsStore returns the decryption shares store of the keeper's instance
+func (k *Keeper) dsStore(ctx sdk.Context) sdk.KVStore {
+	return k.instanceStore(ctx, k.storeDecryptionSharesKey)
+}
+
+func (k *Keeper) instanceStore(ctx sdk.Context, key sdk.StoreKey) sdk.KVStore {
+	store := ctx.KVStore(key)
+	if k.instance == types.DefaultInstance {
+		return store
+	}
+	return prefix.NewStore(store, instancePrefix(k.instance))
+}
+
+// instancePrefix returns prefix of the instance state, instance IDs can't contain '/'
+func instancePrefix(id string) []byte {
+	return append(append([]byte{prefixInstance}, id...), '/')
+}
+
+// logInstance returns the instance ID for the log messages, it's empty for the default instance
+func (k *Keeper) logInstance() string {
+	if k.instance == types.DefaultInstance {
+		return ""
+	}
+	return fmt.Sprintf(" instance %q", k.instance)
+}
@@ -1,7 +1,9 @@
 package herb
 
 import (
-	"strconv"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
@@ -11,28 +13,165 @@ const (
 	keyAggregatedCiphertext = "keyAggregatedCiphertext" // aggregated ciphertext
 	keyRandomResult         = "keyRandomResult"         // result
 	keyStage                = "keyStage"
-	keyCommonKey            = "keyCommonKey"        //public key
-	keyVerificationKeys     = "keyVerificationKeys" //verification keys with id
-	keyCurrentRound         = "keyCurentRound"      //current generation round
-	keyKeyHoldersNumber     = "keyKeyHoldersNumber" //number of key holders
+	keyStageHeight          = "keyStageHeight" // block height at which the round stage was set
+	keyFailReason           = "keyFailReason"  // why the round was aborted
+	keyLastCompletedRound   = "keyLastCompletedRound"
+	keyTranscriptHash       = "keyTranscriptHash"       // hash of the pruned round's transcript
+	keyRoundChainID         = "keyRoundChainID"         // chain ID the round's share proofs are bound to
+	keyPrunedRounds         = "keyPrunedRounds"         // rounds below this number are pruned
+	keyCommonKey            = "keyCommonKey"            //public key
+	keyVerificationKeys     = "keyVerificationKeys"     //verification keys with id
+	keyVerificationKeysHash = "keyVerificationKeysHash" // sha256 of the stored verification keys
+	keyCurrentRound         = "keyCurentRound"          //current generation round
+	keyKeyHoldersNumber     = "keyKeyHoldersNumber"     //number of key holders
 	keyThresholdCiphertexts = "keyThresholdCiphertexts"
 	keyThresholdDecrypt     = "keyThresholdDecrypt"
 
-	//round stages: ciphertext shares collecting, descryption shares collecting, fresh random number
+	// distributed key generation keys
+	keyDKGParticipants = "keyDKGParticipants" // participants with long-term public keys
+	keyDKGThreshold    = "keyDKGThreshold"
+	keyDKGPhase        = "keyDKGPhase"
+	keyDKGPhaseHeight  = "keyDKGPhaseHeight" // block height at which the DKG phase was started
+	keyDKGQUAL         = "keyDKGQUAL"        // QUAL sets reported by participants
+
+	// key holders' shares refresh keys
+	keyRefreshPhase            = "keyRefreshPhase"
+	keyRefreshPhaseHeight      = "keyRefreshPhaseHeight" // block height at which the refresh phase was started
+	keyRefreshNumber           = "keyRefreshNumber"      // number of started refreshes
+	keyRefreshQUAL             = "keyRefreshQUAL"        // dealers whose deals are applied
+	keyPendingVerificationKeys = "keyPendingVerificationKeys"
+	keyVerificationKeysRound   = "keyVerificationKeysRound" // round since which the current verification keys are used
+	keyEncryptionKeyPrefix     = "encryptionKey_"           // key holders' encryption keys for the refresh deals
+
+	// key epochs keys
+	keyKeyEpoch                    = "keyKeyEpoch"                    // current key epoch number
+	keyRoundEpoch                  = "keyRoundEpoch"                  // key epoch used by the round
+	keyPendingEpoch                = "keyPendingEpoch"                // keys of the new key holders set, applied at the next round boundary
+	keyPendingThresholdCiphertexts = "keyPendingThresholdCiphertexts" // ciphertext threshold of the proposed key holders set
+
+	// key holders' penalties keys
+	keyMissCounterPrefix = "missCounter_" // miss counters of the current and former key holders
+
+	// beacon instances keys
+	keyInstances      = "keyInstances"      // IDs of the beacon instances besides the default one
+	keyInstanceParams = "keyInstanceParams" // params of the beacon instance besides the default one
+
+	// entropy providers registry keys
+	keyProviderPrefix = "provider_"         // registered entropy providers with their bonds
+	keyProvidersCount = "keyProvidersCount" // number of registered entropy providers
+
+	// randomness requests keys
+	keyRequestPrefix = "request_"         // randomness requests by ID
+	keyNextRequestID = "keyNextRequestID" // ID of the next randomness request
+	keyRoundRequests = "keyRoundRequests" // IDs of the requests served by the round
+
+	//round stages: ciphertext shares collecting, descryption shares collecting, fresh random number, aborted round
 	stageCtCollecting = "stageCtCollecting"
 	stageDSCollecting = "stageDSCollecting"
 	stageCompleted    = "stageCompleted"
+	stageFailed       = "stageFailed"
 	stageUnstarted    = "stageUnstarted"
 )
 
+// binary key prefixes, string keys of the main store never start with these bytes
+const (
+	prefixRound       byte = 0x00 // round items in the main store: prefix | round | item name
+	prefixShare       byte = 0x01 // shares in the ciphertext and decryption shares stores: prefix | round | sender
+	prefixSharesCount byte = 0x02 // number of the round's shares: prefix | round
+	prefixInstance    byte = 0x03 // state of the beacon instance besides the default one: prefix | instance ID | '/' | key
+	prefixCiphertext  byte = 0x04 // accepted ciphertexts in the ciphertext shares store: prefix | round | sha256 of the ciphertext
+)
+
+// roundBytes returns fixed-width big-endian round, so the keys of the round have the common prefix
+// and the rounds are iterated in order
+func roundBytes(round uint64) []byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, round)
+	return bz
+}
+
 func createKeyBytesByRound(round uint64, keyPrefix string) []byte {
-	roundStr := strconv.FormatUint(round, 10)
-	keyStr := roundStr + keyPrefix
-	keyBytes := []byte(keyStr)
-	return keyBytes
+	key := append([]byte{prefixRound}, roundBytes(round)...)
+	return append(key, keyPrefix...)
+}
+
+// createSharesPrefix returns prefix of all shares of the round in the shares store
+func createSharesPrefix(round uint64) []byte {
+	return append([]byte{prefixShare}, roundBytes(round)...)
 }
 
+// createKeyBytesByAddr returns key of the sender's share in the shares store
 func createKeyBytesByAddr(round uint64, addr sdk.AccAddress) []byte {
-	keyStr := strconv.FormatUint(round, 10) + addr.String()
-	return []byte(keyStr)
+	return append(createSharesPrefix(round), addr...)
+}
+
+// addrFromSharesKey returns the sender's address from the key of the share
+func addrFromSharesKey(key []byte) sdk.AccAddress {
+	return sdk.AccAddress(key[len(createSharesPrefix(0)):])
+}
+
+// createCiphertextsPrefix returns prefix of all accepted ciphertexts of the round in the ciphertext shares store
+func createCiphertextsPrefix(round uint64) []byte {
+	return append([]byte{prefixCiphertext}, roundBytes(round)...)
+}
+
+// createCiphertextKey returns key of the accepted ciphertext in the ciphertext shares store
+func createCiphertextKey(round uint64, ctBytes []byte) []byte {
+	hash := sha256.Sum256(ctBytes)
+	return append(createCiphertextsPrefix(round), hash[:]...)
+}
+
+// createSharesCountKey returns key of the round's shares number in the shares store
+func createSharesCountKey(round uint64) []byte {
+	return append([]byte{prefixSharesCount}, roundBytes(round)...)
+}
+
+// createDKGMessageKey returns key of the participant's messages sent at the DKG phase
+func createDKGMessageKey(phase string, index uint32) []byte {
+	return []byte(fmt.Sprintf("%s_%d", phase, index))
+}
+
+// createDKGDealKey returns key of the deal from dealer to the recipient
+func createDKGDealKey(recipient uint32, dealer uint32) []byte {
+	return []byte(fmt.Sprintf("dkgDeal_%d_%d", recipient, dealer))
+}
+
+// createRefreshDealKey returns key of the dealer's refresh deal
+func createRefreshDealKey(dealer sdk.AccAddress) []byte {
+	return []byte("refreshDeal_" + dealer.String())
+}
+
+// createRefreshComplaintKey returns key of the key holder's refresh complaint
+func createRefreshComplaintKey(sender sdk.AccAddress) []byte {
+	return []byte("refreshComplaint_" + sender.String())
+}
+
+// createRefreshJustificationKey returns key of the dealer's refresh justification
+func createRefreshJustificationKey(dealer sdk.AccAddress) []byte {
+	return []byte("refreshJustification_" + dealer.String())
+}
+
+// createEncryptionKeyKey returns key of the key holder's encryption key for the refresh deals
+func createEncryptionKeyKey(addr sdk.AccAddress) []byte {
+	return []byte(keyEncryptionKeyPrefix + addr.String())
+}
+
+// createEpochKey returns key of the key epoch data
+func createEpochKey(epoch uint64) []byte {
+	return []byte(fmt.Sprintf("epoch_%d", epoch))
+}
+
+// createMissCounterKey returns key of the key holder's miss counter
+func createMissCounterKey(addr sdk.AccAddress) []byte {
+	return []byte(keyMissCounterPrefix + addr.String())
+}
+
+// createProviderKey returns key of the registered entropy provider
+func createProviderKey(addr sdk.AccAddress) []byte {
+	return []byte(keyProviderPrefix + addr.String())
+}
+
+// createRequestKey returns key of the randomness request
+func createRequestKey(id uint64) []byte {
+	return []byte(fmt.Sprintf("%s%d", keyRequestPrefix, id))
 }
@@ -0,0 +1,293 @@
+package herb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"strconv"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.dedis.ch/kyber/v3/proof/dleq"
+	"go.dedis.ch/kyber/v3/share"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+//this file defines migrations of the herb store layout. Version 0 keys are built by the string concatenation
+//of the decimal round and the item name, shares of the round are listed in the JSON addresses list "rd_<round>".
+//Version 1 uses binary keys with fixed-width big-endian rounds, shares are iterated by the round prefix.
+//Version 2 stores shares and aggregated ciphertexts in the canonical binary encoding instead of JSON,
+//decryption shares and DLEQ proofs were gob-encoded inside JSON before.
+//Version 3 stores the hash of the verification keys list which is used by the verification keys lookup
+//Version 4 sets the params missing in the subspace, creates the key epoch 0 from the stored keys
+//and records the key epoch and chain ID of the rounds started before the key epochs
+
+const (
+	keyStoreVersion = "keyStoreVersion"
+
+	// storeVersion is the current store layout version
+	storeVersion uint64 = 4
+)
+
+// legacyRoundKeys are names of the round items which are moved to the binary keys
+var legacyRoundKeys = []string{
+	keyAggregatedCiphertext,
+	keyRandomResult,
+	keyStage,
+	keyStageHeight,
+	keyFailReason,
+	keyRoundEpoch,
+	keyTranscriptHash,
+}
+
+func legacyKeyBytesByRound(round uint64, keyPrefix string) []byte {
+	return []byte(strconv.FormatUint(round, 10) + keyPrefix)
+}
+
+func legacyKeyBytesByAddr(round uint64, addr sdk.AccAddress) []byte {
+	return []byte(strconv.FormatUint(round, 10) + addr.String())
+}
+
+func legacySharesListKey(round uint64) []byte {
+	return []byte(fmt.Sprintf("rd_%d", round))
+}
+
+// migrateStore moves the store to the current layout, it's called at the beginning of the block,
+// so transactions always see the current layout
+func (k *Keeper) migrateStore(ctx sdk.Context) {
+	version := k.getStoreVersion(ctx)
+	if version >= storeVersion {
+		return
+	}
+	if version < 1 {
+		if err := k.migrateBinaryKeys(ctx); err != nil {
+			panic(fmt.Sprintf("herb store migration to version 1 failed: %v", err))
+		}
+	}
+	if version < 2 {
+		if err := k.migrateBinaryValues(ctx); err != nil {
+			panic(fmt.Sprintf("herb store migration to version 2 failed: %v", err))
+		}
+	}
+	if version < 3 {
+		k.migrateVerificationKeysHash(ctx)
+	}
+	if version < 4 {
+		k.migrateKeyEpochs(ctx)
+	}
+	k.setStoreVersion(ctx, storeVersion)
+	ctx.Logger().Info(fmt.Sprintf("herb store is migrated from version %v to %v", version, storeVersion))
+}
+
+// migrateBinaryKeys moves round items and shares of all rounds from the version 0 keys
+func (k *Keeper) migrateBinaryKeys(ctx sdk.Context) error {
+	store := ctx.KVStore(k.storeKey)
+	current := k.CurrentRound(ctx)
+	for round := uint64(0); round <= current; round++ {
+		for _, name := range legacyRoundKeys {
+			oldKey := legacyKeyBytesByRound(round, name)
+			if !store.Has(oldKey) {
+				continue
+			}
+			store.Set(createKeyBytesByRound(round, name), store.Get(oldKey))
+			store.Delete(oldKey)
+		}
+		if err := k.migrateRoundShares(ctx.KVStore(k.storeCiphertextSharesKey), round); err != nil {
+			return err
+		}
+		if err := k.migrateRoundShares(ctx.KVStore(k.storeDecryptionSharesKey), round); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateRoundShares moves the round's shares listed in the JSON addresses list to the binary keys
+func (k *Keeper) migrateRoundShares(sharesStore sdk.KVStore, round uint64) error {
+	listKey := legacySharesListKey(round)
+	if !sharesStore.Has(listKey) {
+		return nil
+	}
+	var addrList []string
+	if err := k.cdc.UnmarshalJSON(sharesStore.Get(listKey), &addrList); err != nil {
+		return fmt.Errorf("can't unmarshal addresses list of round %v: %v", round, err)
+	}
+	for _, addrStr := range addrList {
+		addr, err := sdk.AccAddressFromBech32(addrStr)
+		if err != nil {
+			return fmt.Errorf("can't decode address %v of round %v: %v", addrStr, round, err)
+		}
+		oldKey := legacyKeyBytesByAddr(round, addr)
+		if !sharesStore.Has(oldKey) {
+			return fmt.Errorf("share of %v for round %v doesn't exist", addrStr, round)
+		}
+		sharesStore.Set(createKeyBytesByAddr(round, addr), sharesStore.Get(oldKey))
+		sharesStore.Delete(oldKey)
+	}
+	countBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(countBytes, uint64(len(addrList)))
+	sharesStore.Set(createSharesCountKey(round), countBytes)
+	sharesStore.Delete(listKey)
+	return nil
+}
+
+// migrateBinaryValues re-encodes aggregated ciphertexts and shares of all rounds from JSON
+func (k *Keeper) migrateBinaryValues(ctx sdk.Context) error {
+	store := ctx.KVStore(k.storeKey)
+	ctStore := ctx.KVStore(k.storeCiphertextSharesKey)
+	dsStore := ctx.KVStore(k.storeDecryptionSharesKey)
+	current := k.CurrentRound(ctx)
+	for round := uint64(0); round <= current; round++ {
+		aggKey := createKeyBytesByRound(round, keyAggregatedCiphertext)
+		if store.Has(aggKey) {
+			var ctJSON elgamal.CiphertextJSON
+			if err := k.cdc.UnmarshalJSON(store.Get(aggKey), &ctJSON); err != nil {
+				return fmt.Errorf("can't unmarshal aggregated ciphertext of round %v: %v", round, err)
+			}
+			ct, err := ctJSON.Deserialize(P256)
+			if err != nil {
+				return fmt.Errorf("can't decode aggregated ciphertext of round %v: %v", round, err)
+			}
+			ctBytes, err1 := types.EncodeCiphertext(ct)
+			if err1 != nil {
+				return err1
+			}
+			store.Set(aggKey, ctBytes)
+		}
+
+		err := k.migrateRoundValues(ctStore, round, func(value []byte) ([]byte, error) {
+			var ctJSON types.CiphertextShareJSON
+			if err := k.cdc.UnmarshalJSON(value, &ctJSON); err != nil {
+				return nil, err
+			}
+			ctShare, err := ctJSON.Deserialize()
+			if err != nil {
+				return nil, err
+			}
+			return types.EncodeCiphertextShare(ctShare)
+		})
+		if err != nil {
+			return fmt.Errorf("can't migrate ciphertext shares of round %v: %v", round, err)
+		}
+		err = k.migrateRoundValues(dsStore, round, func(value []byte) ([]byte, error) {
+			var dsJSON legacyDecryptionShareJSON
+			if err := k.cdc.UnmarshalJSON(value, &dsJSON); err != nil {
+				return nil, err
+			}
+			ds, err := dsJSON.deserialize()
+			if err != nil {
+				return nil, err
+			}
+			return types.EncodeDecryptionShare(ds)
+		})
+		if err != nil {
+			return fmt.Errorf("can't migrate decryption shares of round %v: %v", round, err)
+		}
+	}
+	return nil
+}
+
+// migrateRoundValues re-encodes the round's shares with the given function
+func (k *Keeper) migrateRoundValues(sharesStore sdk.KVStore, round uint64, encode func(value []byte) ([]byte, error)) error {
+	var keys, values [][]byte
+	iterator := sdk.KVStorePrefixIterator(sharesStore, createSharesPrefix(round))
+	for ; iterator.Valid(); iterator.Next() {
+		keys = append(keys, iterator.Key())
+		values = append(values, iterator.Value())
+	}
+	iterator.Close()
+	for i, key := range keys {
+		value, err := encode(values[i])
+		if err != nil {
+			return fmt.Errorf("share of %v: %v", addrFromSharesKey(key), err)
+		}
+		sharesStore.Set(key, value)
+	}
+	return nil
+}
+
+// migrateVerificationKeysHash stores the hash of the verification keys if they are defined
+func (k *Keeper) migrateVerificationKeysHash(ctx sdk.Context) {
+	store := ctx.KVStore(k.storeKey)
+	if !store.Has([]byte(keyVerificationKeys)) {
+		return
+	}
+	k.setVerificationKeysBytes(ctx, store.Get([]byte(keyVerificationKeys)))
+}
+
+// migrateKeyEpochs sets the missing params to the defaults and binds the existing rounds to the key epoch 0
+// and the current chain ID, the rounds started after the key epochs already have them
+func (k *Keeper) migrateKeyEpochs(ctx sdk.Context) {
+	defaults := types.DefaultParams()
+	for _, pair := range defaults.ParamSetPairs() {
+		if !k.paramSpace.Has(ctx, pair.Key) {
+			k.paramSpace.Set(ctx, pair.Key, pair.Value)
+		}
+	}
+	// without the keys there are no rounds, the epoch 0 is created by the DKG
+	if !k.keysDefined(ctx) {
+		return
+	}
+	store := ctx.KVStore(k.storeKey)
+	if !store.Has(createEpochKey(0)) {
+		k.setKeyEpoch(ctx, 0, 0)
+	}
+	epochBytes := make([]byte, 8)
+	current := k.CurrentRound(ctx)
+	for round := uint64(0); round <= current; round++ {
+		if k.GetStage(ctx, round) == stageUnstarted {
+			continue
+		}
+		if key := createKeyBytesByRound(round, keyRoundEpoch); !store.Has(key) {
+			store.Set(key, epochBytes)
+		}
+		if key := createKeyBytesByRound(round, keyRoundChainID); !store.Has(key) {
+			store.Set(key, []byte(ctx.ChainID()))
+		}
+	}
+}
+
+// legacyDecryptionShareJSON is the decryption share stored before version 2, share and proof are base64 of gob
+type legacyDecryptionShareJSON struct {
+	DecShare      string         `json:"decryption_share"`
+	DLEQproof     string         `json:"dleq_proof"`
+	KeyHolderAddr sdk.AccAddress `json:"key_holder"`
+}
+
+func (dsJSON legacyDecryptionShareJSON) deserialize() (*types.DecryptionShare, error) {
+	dsBytes, err := base64.StdEncoding.DecodeString(dsJSON.DecShare)
+	if err != nil {
+		return nil, err
+	}
+	decShare := share.PubShare{I: 0, V: P256.Point().Base()}
+	if err := gob.NewDecoder(bytes.NewBuffer(dsBytes)).Decode(&decShare); err != nil {
+		return nil, fmt.Errorf("failed to decode decryption share: %v", err)
+	}
+	dleqBytes, err := base64.StdEncoding.DecodeString(dsJSON.DLEQproof)
+	if err != nil {
+		return nil, err
+	}
+	proof := dleq.Proof{C: P256.Scalar().Zero(), R: P256.Scalar().Zero(), VG: P256.Point().Base(), VH: P256.Point().Base()}
+	if err := gob.NewDecoder(bytes.NewBuffer(dleqBytes)).Decode(&proof); err != nil {
+		return nil, fmt.Errorf("failed to decode DLEQ proof: %v", err)
+	}
+	return &types.DecryptionShare{DecShare: decShare, DLEQproof: &proof, KeyHolderAddr: dsJSON.KeyHolderAddr}, nil
+}
+
+func (k *Keeper) getStoreVersion(ctx sdk.Context) uint64 {
+	store := ctx.KVStore(k.storeKey)
+	if !store.Has([]byte(keyStoreVersion)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyStoreVersion)))
+}
+
+func (k *Keeper) setStoreVersion(ctx sdk.Context, version uint64) {
+	store := ctx.KVStore(k.storeKey)
+	versionBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(versionBytes, version)
+	store.Set([]byte(keyStoreVersion), versionBytes)
+}
@@ -0,0 +1,113 @@
+package herb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines the entropy providers registry. Entropy providers are registered separately from the key holders,
+//registration takes the bond defined by the params, the bond is kept in the separate module account
+//and returned when the provider is deregistered. In the restricted mode only registered providers can send ciphertext shares
+
+// RegisterEntropyProvider adds the address to the entropy providers and takes its bond
+func (k *Keeper) RegisterEntropyProvider(ctx sdk.Context, addr sdk.AccAddress) sdk.Error {
+	if addr.Empty() {
+		return sdk.ErrInvalidAddress("entropy provider can't be empty")
+	}
+	if k.IsEntropyProvider(ctx, addr) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("%v is already registered as an entropy provider", addr))
+	}
+	params := k.GetParams(ctx)
+	count := k.getProvidersCount(ctx)
+	if params.MaxEntropyProviders > 0 && count >= uint64(params.MaxEntropyProviders) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("entropy providers registry is full, max size: %v", params.MaxEntropyProviders))
+	}
+	bond := params.EntropyProviderBond
+	if !bond.Empty() {
+		if err := k.supplyKeeper.SendCoinsFromAccountToModule(ctx, addr, types.ProviderBondPoolName, bond); err != nil {
+			return err
+		}
+	}
+	provider := types.EntropyProvider{Address: addr, Bond: bond}
+	k.setEntropyProvider(ctx, provider)
+	k.setProvidersCount(ctx, count+1)
+	k.emitProviderEvent(ctx, types.EventTypeProviderRegistered, provider)
+	return nil
+}
+
+func (k *Keeper) setEntropyProvider(ctx sdk.Context, provider types.EntropyProvider) {
+	store := k.store(ctx)
+	store.Set(createProviderKey(provider.Address), k.cdc.MustMarshalJSON(provider))
+}
+
+// DeregisterEntropyProvider removes the address from the entropy providers and returns its bond
+func (k *Keeper) DeregisterEntropyProvider(ctx sdk.Context, addr sdk.AccAddress) sdk.Error {
+	provider, err := k.GetEntropyProvider(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if !provider.Bond.Empty() {
+		if err := k.supplyKeeper.SendCoinsFromModuleToAccount(ctx, types.ProviderBondPoolName, addr, provider.Bond); err != nil {
+			return err
+		}
+	}
+	store := k.store(ctx)
+	store.Delete(createProviderKey(addr))
+	k.setProvidersCount(ctx, k.getProvidersCount(ctx)-1)
+	k.emitProviderEvent(ctx, types.EventTypeProviderDeregistered, *provider)
+	return nil
+}
+
+// IsEntropyProvider returns true if the address is a registered entropy provider
+func (k *Keeper) IsEntropyProvider(ctx sdk.Context, addr sdk.AccAddress) bool {
+	store := k.store(ctx)
+	return store.Has(createProviderKey(addr))
+}
+
+// GetEntropyProvider returns the registered entropy provider
+func (k *Keeper) GetEntropyProvider(ctx sdk.Context, addr sdk.AccAddress) (*types.EntropyProvider, sdk.Error) {
+	store := k.store(ctx)
+	keyBytes := createProviderKey(addr)
+	if !store.Has(keyBytes) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("%v isn't a registered entropy provider", addr))
+	}
+	var provider types.EntropyProvider
+	if err := k.cdc.UnmarshalJSON(store.Get(keyBytes), &provider); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal entropy provider: %v", err))
+	}
+	return &provider, nil
+}
+
+// GetEntropyProviders returns all registered entropy providers
+func (k *Keeper) GetEntropyProviders(ctx sdk.Context) ([]types.EntropyProvider, sdk.Error) {
+	store := k.store(ctx)
+	iterator := sdk.KVStorePrefixIterator(store, []byte(keyProviderPrefix))
+	defer iterator.Close()
+	providers := []types.EntropyProvider{}
+	for ; iterator.Valid(); iterator.Next() {
+		var provider types.EntropyProvider
+		if err := k.cdc.UnmarshalJSON(iterator.Value(), &provider); err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal entropy provider: %v", err))
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+func (k *Keeper) getProvidersCount(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyProvidersCount)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyProvidersCount)))
+}
+
+func (k *Keeper) setProvidersCount(ctx sdk.Context, count uint64) {
+	store := k.store(ctx)
+	countBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(countBytes, count)
+	store.Set([]byte(keyProvidersCount), countBytes)
+}
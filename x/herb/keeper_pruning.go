@@ -0,0 +1,116 @@
+package herb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines pruning of the old rounds. Shares and aggregated ciphertexts of the rounds
+//older than the retention param are deleted, the result and the transcript hash are kept
+
+// maxPrunedRoundsPerBlock limits pruning work of a single block, e.g. when the retention param is set on the long chain
+const maxPrunedRoundsPerBlock = 100
+
+// pruneRounds prunes the rounds which are out of the retention window
+func (k *Keeper) pruneRounds(ctx sdk.Context) {
+	retention := k.GetParams(ctx).RoundsRetention
+	current := k.CurrentRound(ctx)
+	if retention == 0 || current <= uint64(retention) {
+		return
+	}
+	pruneBefore := current - uint64(retention)
+	pruned := k.prunedRounds(ctx)
+	if pruned >= pruneBefore {
+		return
+	}
+	if pruneBefore-pruned > maxPrunedRoundsPerBlock {
+		pruneBefore = pruned + maxPrunedRoundsPerBlock
+	}
+	for round := pruned; round < pruneBefore; round++ {
+		k.pruneRound(ctx, round)
+	}
+	k.setPrunedRounds(ctx, pruneBefore)
+}
+
+// pruneRound saves the transcript hash and deletes the round's shares
+func (k *Keeper) pruneRound(ctx sdk.Context, round uint64) {
+	store := k.store(ctx)
+	if hash, err := k.transcriptHash(ctx, round); err != nil {
+		ctx.Logger().Error(fmt.Sprintf("herb can't hash transcript of the pruned round %v: %v", round, err))
+	} else {
+		store.Set(createKeyBytesByRound(round, keyTranscriptHash), hash)
+	}
+	k.deleteRoundShares(k.ctStore(ctx), round)
+	k.deleteRoundShares(k.dsStore(ctx), round)
+	store.Delete(createKeyBytesByRound(round, keyAggregatedCiphertext))
+}
+
+// deleteRoundShares deletes shares of the round, their number and the index of the accepted ciphertexts
+func (k *Keeper) deleteRoundShares(sharesStore sdk.KVStore, round uint64) {
+	var keys [][]byte
+	for _, prefix := range [][]byte{createSharesPrefix(round), createCiphertextsPrefix(round)} {
+		iterator := sdk.KVStorePrefixIterator(sharesStore, prefix)
+		for ; iterator.Valid(); iterator.Next() {
+			keys = append(keys, iterator.Key())
+		}
+		iterator.Close()
+	}
+	for _, key := range keys {
+		sharesStore.Delete(key)
+	}
+	sharesStore.Delete(createSharesCountKey(round))
+}
+
+// transcriptHash returns hash of the round transcript in the light verifier format
+func (k *Keeper) transcriptHash(ctx sdk.Context, round uint64) ([]byte, error) {
+	transcript, err := k.GetRoundTranscript(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	lt, err1 := transcript.LightTranscript()
+	if err1 != nil {
+		return nil, err1
+	}
+	return lt.Hash()
+}
+
+// GetTranscriptHash returns the transcript hash of the pruned round
+func (k *Keeper) GetTranscriptHash(ctx sdk.Context, round uint64) ([]byte, sdk.Error) {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyTranscriptHash)
+	if !store.Has(keyBytes) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("round %v has no transcript hash", round))
+	}
+	return store.Get(keyBytes), nil
+}
+
+// isRoundPruned returns true if shares of the round are deleted
+func (k *Keeper) isRoundPruned(ctx sdk.Context, round uint64) bool {
+	return round < k.prunedRounds(ctx)
+}
+
+func (k *Keeper) prunedRounds(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyPrunedRounds)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyPrunedRounds)))
+}
+
+func (k *Keeper) setPrunedRounds(ctx sdk.Context, rounds uint64) {
+	store := k.store(ctx)
+	roundsBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(roundsBytes, rounds)
+	store.Set([]byte(keyPrunedRounds), roundsBytes)
+}
+
+// checkRoundPruned returns the pruned round error for the pruned round
+func (k *Keeper) checkRoundPruned(ctx sdk.Context, round uint64) sdk.Error {
+	if k.isRoundPruned(ctx, round) {
+		return types.ErrRoundPruned(round)
+	}
+	return nil
+}
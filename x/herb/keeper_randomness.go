@@ -0,0 +1,40 @@
+package herb
+
+import (
+	"encoding/binary"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file implements the read-only randomness API for the consumer modules
+
+var _ types.RandomnessKeeper = (*Keeper)(nil)
+
+// GetRandomness returns randomness of the completed round derived for the module and purpose
+func (k *Keeper) GetRandomness(ctx sdk.Context, moduleName string, purpose string, round uint64) ([]byte, sdk.Error) {
+	if stage := k.GetStage(ctx, round); stage != stageCompleted {
+		return nil, types.ErrRoundNotCompleted(round, stage)
+	}
+	result, err := k.RandomResult(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	return types.DeriveRandomness(result, moduleName, purpose, round), nil
+}
+
+// LatestCompletedRound returns the last round with the finalized result
+func (k *Keeper) LatestCompletedRound(ctx sdk.Context) (uint64, sdk.Error) {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyLastCompletedRound)) {
+		return 0, types.ErrRoundNotCompleted(0, k.GetStage(ctx, 0))
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyLastCompletedRound))), nil
+}
+
+func (k *Keeper) setLastCompletedRound(ctx sdk.Context, round uint64) {
+	store := k.store(ctx)
+	roundBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(roundBytes, round)
+	store.Set([]byte(keyLastCompletedRound), roundBytes)
+}
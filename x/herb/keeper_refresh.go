@@ -0,0 +1,549 @@
+package herb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.dedis.ch/kyber/v3/share"
+)
+
+//this file defines the proactive refresh of the key holders' shares
+//each key holder shares zero with a random polynomial, so the common secret and the common key stay the same,
+//but every key holder's share and verification key are replaced. New keys are applied at the next round boundary.
+//Shares are encrypted with the key holders' long-term encryption keys, a dealer who got complaints reveals
+//the complained shares and is excluded only if it fails to.
+
+// StartRefresh starts the key holders' shares refresh on the key holder's request
+func (k *Keeper) StartRefresh(ctx sdk.Context, sender sdk.AccAddress) sdk.Error {
+	if _, err := k.refreshKeyHolderPos(ctx, sender); err != nil {
+		return err
+	}
+	return k.startRefresh(ctx)
+}
+
+func (k *Keeper) startRefresh(ctx sdk.Context) sdk.Error {
+	if k.dkgRunning(ctx) {
+		return sdk.ErrUnknownRequest("distributed key generation isn't completed")
+	}
+	if phase := k.GetRefreshPhase(ctx); phase != types.RefreshPhaseNone {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("refresh is already running, phase: %v", phase))
+	}
+	if k.GetPendingKeyEpoch(ctx) != nil {
+		return sdk.ErrUnknownRequest("new key holders set is pending")
+	}
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := k.refreshEncryptionKeys(ctx, vkJSONList); err != nil {
+		return err
+	}
+	store := k.store(ctx)
+	for _, vk := range vkJSONList {
+		store.Delete(createRefreshDealKey(vk.Sender))
+		store.Delete(createRefreshComplaintKey(vk.Sender))
+		store.Delete(createRefreshJustificationKey(vk.Sender))
+	}
+	store.Delete([]byte(keyRefreshQUAL))
+	numberBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(numberBytes, k.GetRefreshNumber(ctx)+1)
+	store.Set([]byte(keyRefreshNumber), numberBytes)
+	k.setRefreshPhase(ctx, types.RefreshPhaseDeals)
+	return nil
+}
+
+// GetRefreshPhase returns current refresh phase
+func (k *Keeper) GetRefreshPhase(ctx sdk.Context) string {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyRefreshPhase)) {
+		return types.RefreshPhaseNone
+	}
+	return string(store.Get([]byte(keyRefreshPhase)))
+}
+
+func (k *Keeper) setRefreshPhase(ctx sdk.Context, phase string) {
+	oldPhase := k.GetRefreshPhase(ctx)
+	store := k.store(ctx)
+	store.Set([]byte(keyRefreshPhase), []byte(phase))
+	heightBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(heightBytes, uint64(ctx.BlockHeight()))
+	store.Set([]byte(keyRefreshPhaseHeight), heightBytes)
+	k.emitRefreshPhaseChanged(ctx, oldPhase, phase)
+}
+
+// refreshPhaseHeight returns the block height at which the current refresh phase was started
+func (k *Keeper) refreshPhaseHeight(ctx sdk.Context) int64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyRefreshPhaseHeight)) {
+		return ctx.BlockHeight()
+	}
+	return int64(binary.LittleEndian.Uint64(store.Get([]byte(keyRefreshPhaseHeight))))
+}
+
+// GetRefreshNumber returns the number of started refreshes
+func (k *Keeper) GetRefreshNumber(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyRefreshNumber)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyRefreshNumber)))
+}
+
+// GetVerificationKeysRound returns the round since which the current verification keys are used
+func (k *Keeper) GetVerificationKeysRound(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyVerificationKeysRound)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyVerificationKeysRound)))
+}
+
+// refreshKeyHolderPos returns position of the key holder in the verification keys list
+func (k *Keeper) refreshKeyHolderPos(ctx sdk.Context, addr sdk.AccAddress) (int, sdk.Error) {
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i, vk := range vkJSONList {
+		if vk.Sender.Equals(addr) {
+			return i, nil
+		}
+	}
+	return 0, sdk.ErrUnauthorized(fmt.Sprintf("%v isn't a key holder", addr))
+}
+
+// SetEncryptionKey registers the key holder's long-term encryption key for the refresh deals
+// The key can't be changed while the refresh is collecting messages
+func (k *Keeper) SetEncryptionKey(ctx sdk.Context, sender sdk.AccAddress, key string) sdk.Error {
+	if _, err := k.refreshKeyHolderPos(ctx, sender); err != nil {
+		return err
+	}
+	if phase := k.GetRefreshPhase(ctx); phase != types.RefreshPhaseNone && phase != types.RefreshPhasePending {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("refresh is running, phase: %v", phase))
+	}
+	if err := types.ValidateEncryptionKey(key); err != nil {
+		return sdk.ErrUnknownRequest(err.Error())
+	}
+	k.setEncryptionKey(ctx, sender, key)
+	return nil
+}
+
+func (k *Keeper) setEncryptionKey(ctx sdk.Context, addr sdk.AccAddress, key string) {
+	k.store(ctx).Set(createEncryptionKeyKey(addr), []byte(key))
+}
+
+// GetEncryptionKey returns the hex-encoded encryption key of the key holder, it's empty if the key isn't registered
+func (k *Keeper) GetEncryptionKey(ctx sdk.Context, addr sdk.AccAddress) string {
+	return string(k.store(ctx).Get(createEncryptionKeyKey(addr)))
+}
+
+// GetEncryptionKeys returns all registered encryption keys
+func (k *Keeper) GetEncryptionKeys(ctx sdk.Context) ([]types.EncryptionKey, sdk.Error) {
+	store := k.store(ctx)
+	iterator := sdk.KVStorePrefixIterator(store, []byte(keyEncryptionKeyPrefix))
+	defer iterator.Close()
+	keys := []types.EncryptionKey{}
+	for ; iterator.Valid(); iterator.Next() {
+		addr, err := sdk.AccAddressFromBech32(string(iterator.Key()[len(keyEncryptionKeyPrefix):]))
+		if err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't decode encryption key owner: %v", err))
+		}
+		keys = append(keys, types.EncryptionKey{Address: addr, Key: string(iterator.Value())})
+	}
+	return keys, nil
+}
+
+// refreshEncryptionKeys returns the encryption keys of the key holders in the verification keys order,
+// all key holders must have registered them
+func (k *Keeper) refreshEncryptionKeys(ctx sdk.Context, vkJSONList []types.VerificationKeyJSON) ([]string, sdk.Error) {
+	keys := make([]string, len(vkJSONList))
+	for i, vk := range vkJSONList {
+		if keys[i] = k.GetEncryptionKey(ctx, vk.Sender); keys[i] == "" {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("key holder %v hasn't registered the encryption key", vk.Sender))
+		}
+	}
+	return keys, nil
+}
+
+// SetRefreshDeal stores the key holder's sharing of zero
+func (k *Keeper) SetRefreshDeal(ctx sdk.Context, sender sdk.AccAddress, deal types.RefreshDeal) sdk.Error {
+	if phase := k.GetRefreshPhase(ctx); phase != types.RefreshPhaseDeals {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseDeals))
+	}
+	if _, err := k.refreshKeyHolderPos(ctx, sender); err != nil {
+		return err
+	}
+	store := k.store(ctx)
+	key := createRefreshDealKey(sender)
+	if store.Has(key) {
+		return sdk.ErrUnknownRequest("key holder has already sent refresh deal")
+	}
+	t, err := k.GetThresholdDecryption(ctx)
+	if err != nil {
+		return err
+	}
+	n, err := k.GetKeyHoldersNumber(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := deal.PubPoly(int(t), int(n)); err != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("invalid refresh deal: %v", err))
+	}
+	dealBytes, err2 := k.cdc.MarshalJSON(deal)
+	if err2 != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal refresh deal: %v", err2))
+	}
+	store.Set(key, dealBytes)
+	k.tryAdvanceRefreshPhase(ctx, createRefreshDealKey)
+	return nil
+}
+
+// GetRefreshDeals returns all refresh deals of the current refresh
+func (k *Keeper) GetRefreshDeals(ctx sdk.Context) ([]types.RefreshDealJSON, sdk.Error) {
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := k.store(ctx)
+	deals := make([]types.RefreshDealJSON, 0, len(vkJSONList))
+	for _, vk := range vkJSONList {
+		key := createRefreshDealKey(vk.Sender)
+		if !store.Has(key) {
+			continue
+		}
+		var deal types.RefreshDeal
+		if err := k.cdc.UnmarshalJSON(store.Get(key), &deal); err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal refresh deal: %v", err))
+		}
+		deals = append(deals, types.RefreshDealJSON{Dealer: vk.Sender, Deal: deal})
+	}
+	return deals, nil
+}
+
+// SetRefreshComplaint stores the dealers which sent incorrect shares to the key holder
+// Complained dealers must justify their deals at the next phase
+func (k *Keeper) SetRefreshComplaint(ctx sdk.Context, sender sdk.AccAddress, dealers []sdk.AccAddress) sdk.Error {
+	if phase := k.GetRefreshPhase(ctx); phase != types.RefreshPhaseComplaints {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseComplaints))
+	}
+	if _, err := k.refreshKeyHolderPos(ctx, sender); err != nil {
+		return err
+	}
+	store := k.store(ctx)
+	key := createRefreshComplaintKey(sender)
+	if store.Has(key) {
+		return sdk.ErrUnknownRequest("key holder has already sent refresh complaint")
+	}
+	for _, dealer := range dealers {
+		if !store.Has(createRefreshDealKey(dealer)) {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("there is no refresh deal from %v", dealer))
+		}
+	}
+	if dealers == nil {
+		dealers = []sdk.AccAddress{}
+	}
+	dealersBytes, err := k.cdc.MarshalJSON(dealers)
+	if err != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("can't marshal refresh complaint: %v", err))
+	}
+	store.Set(key, dealersBytes)
+	k.tryAdvanceRefreshPhase(ctx, createRefreshComplaintKey)
+	return nil
+}
+
+// GetRefreshComplaints returns all refresh complaints of the current refresh
+func (k *Keeper) GetRefreshComplaints(ctx sdk.Context) ([]types.RefreshComplaintJSON, sdk.Error) {
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := k.store(ctx)
+	complaints := make([]types.RefreshComplaintJSON, 0, len(vkJSONList))
+	for _, vk := range vkJSONList {
+		key := createRefreshComplaintKey(vk.Sender)
+		if !store.Has(key) {
+			continue
+		}
+		var dealers []sdk.AccAddress
+		if err := k.cdc.UnmarshalJSON(store.Get(key), &dealers); err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal refresh complaint: %v", err))
+		}
+		complaints = append(complaints, types.RefreshComplaintJSON{Sender: vk.Sender, Dealers: dealers})
+	}
+	return complaints, nil
+}
+
+// refreshComplainers returns the key holders who complained about the dealer
+func (k *Keeper) refreshComplainers(ctx sdk.Context, dealer sdk.AccAddress) ([]sdk.AccAddress, sdk.Error) {
+	complaints, err := k.GetRefreshComplaints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var complainers []sdk.AccAddress
+	for _, complaint := range complaints {
+		for _, d := range complaint.Dealers {
+			if d.Equals(dealer) {
+				complainers = append(complainers, complaint.Sender)
+				break
+			}
+		}
+	}
+	return complainers, nil
+}
+
+// SetRefreshJustification stores the dealer's shares revealed for the key holders who complained about them
+// Every complained share must be revealed and match the dealer's commitments
+func (k *Keeper) SetRefreshJustification(ctx sdk.Context, sender sdk.AccAddress, justifications []types.RefreshJustification) sdk.Error {
+	if phase := k.GetRefreshPhase(ctx); phase != types.RefreshPhaseJustifications {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseJustifications))
+	}
+	store := k.store(ctx)
+	key := createRefreshJustificationKey(sender)
+	if store.Has(key) {
+		return sdk.ErrUnknownRequest("dealer has already sent refresh justification")
+	}
+	dealBytes := store.Get(createRefreshDealKey(sender))
+	if dealBytes == nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("there is no refresh deal from %v", sender))
+	}
+	var deal types.RefreshDeal
+	if err := k.cdc.UnmarshalJSON(dealBytes, &deal); err != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal refresh deal: %v", err))
+	}
+	complainers, err := k.refreshComplainers(ctx, sender)
+	if err != nil {
+		return err
+	}
+	if len(justifications) != len(complainers) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("wrong number of justifications: %v, complaints: %v", len(justifications), len(complainers)))
+	}
+	t, err := k.GetThresholdDecryption(ctx)
+	if err != nil {
+		return err
+	}
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return err
+	}
+	justified := make(map[string]bool, len(justifications))
+	for _, j := range justifications {
+		id := -1
+		for _, vk := range vkJSONList {
+			if vk.Sender.Equals(j.KeyHolder) {
+				id = vk.KeyHolderID
+			}
+		}
+		if id < 0 || justified[j.KeyHolder.String()] {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("unexpected justification for %v", j.KeyHolder))
+		}
+		if _, err := deal.JustifiedShare(j, id, int(t), len(vkJSONList)); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("invalid justification for %v: %v", j.KeyHolder, err))
+		}
+		justified[j.KeyHolder.String()] = true
+	}
+	for _, complainer := range complainers {
+		if !justified[complainer.String()] {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("complaint of %v isn't justified", complainer))
+		}
+	}
+	store.Set(key, k.cdc.MustMarshalJSON(justifications))
+	k.tryFinishJustifications(ctx)
+	return nil
+}
+
+// GetRefreshJustifications returns all refresh justifications of the current refresh
+func (k *Keeper) GetRefreshJustifications(ctx sdk.Context) ([]types.RefreshJustificationsJSON, sdk.Error) {
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store := k.store(ctx)
+	justifications := make([]types.RefreshJustificationsJSON, 0)
+	for _, vk := range vkJSONList {
+		key := createRefreshJustificationKey(vk.Sender)
+		if !store.Has(key) {
+			continue
+		}
+		var list []types.RefreshJustification
+		if err := k.cdc.UnmarshalJSON(store.Get(key), &list); err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal refresh justification: %v", err))
+		}
+		justifications = append(justifications, types.RefreshJustificationsJSON{Dealer: vk.Sender, Justifications: list})
+	}
+	return justifications, nil
+}
+
+// complainedDealers returns the dealers who got complaints
+func (k *Keeper) complainedDealers(ctx sdk.Context) (map[string]bool, sdk.Error) {
+	complaints, err := k.GetRefreshComplaints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	complained := make(map[string]bool)
+	for _, complaint := range complaints {
+		for _, dealer := range complaint.Dealers {
+			complained[dealer.String()] = true
+		}
+	}
+	return complained, nil
+}
+
+// tryFinishJustifications finishes the refresh as soon as all complained dealers have justified their deals
+func (k *Keeper) tryFinishJustifications(ctx sdk.Context) {
+	complained, err := k.complainedDealers(ctx)
+	if err != nil {
+		return
+	}
+	store := k.store(ctx)
+	for dealer := range complained {
+		addr, err := sdk.AccAddressFromBech32(dealer)
+		if err != nil || !store.Has(createRefreshJustificationKey(addr)) {
+			return
+		}
+	}
+	k.advanceRefreshPhase(ctx)
+}
+
+// GetRefreshQUAL returns the dealers whose deals are applied by the current refresh
+func (k *Keeper) GetRefreshQUAL(ctx sdk.Context) []sdk.AccAddress {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyRefreshQUAL)) {
+		return []sdk.AccAddress{}
+	}
+	var qual []sdk.AccAddress
+	k.cdc.MustUnmarshalJSON(store.Get([]byte(keyRefreshQUAL)), &qual)
+	return qual
+}
+
+// GetPendingVerificationKeys returns refreshed verification keys which will be used since the next round
+func (k *Keeper) GetPendingVerificationKeys(ctx sdk.Context) []types.VerificationKeyJSON {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyPendingVerificationKeys)) {
+		return []types.VerificationKeyJSON{}
+	}
+	var vkList []types.VerificationKeyJSON
+	k.cdc.MustUnmarshalJSON(store.Get([]byte(keyPendingVerificationKeys)), &vkList)
+	return vkList
+}
+
+// tryAdvanceRefreshPhase moves the refresh to the next phase as soon as all key holders have sent their messages
+func (k *Keeper) tryAdvanceRefreshPhase(ctx sdk.Context, createKey func(sdk.AccAddress) []byte) {
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return
+	}
+	store := k.store(ctx)
+	for _, vk := range vkJSONList {
+		if !store.Has(createKey(vk.Sender)) {
+			return
+		}
+	}
+	k.advanceRefreshPhase(ctx)
+}
+
+// advanceRefreshPhase moves the refresh to the next phase, the justifications phase is skipped if there are no complaints.
+// New verification keys are computed after the last phase
+func (k *Keeper) advanceRefreshPhase(ctx sdk.Context) {
+	switch k.GetRefreshPhase(ctx) {
+	case types.RefreshPhaseDeals:
+		k.setRefreshPhase(ctx, types.RefreshPhaseComplaints)
+	case types.RefreshPhaseComplaints:
+		if complained, err := k.complainedDealers(ctx); err == nil && len(complained) > 0 {
+			k.setRefreshPhase(ctx, types.RefreshPhaseJustifications)
+			return
+		}
+		k.completeRefresh(ctx)
+	case types.RefreshPhaseJustifications:
+		k.completeRefresh(ctx)
+	}
+}
+
+// completeRefresh computes new verification keys, the refresh is dropped if it fails
+func (k *Keeper) completeRefresh(ctx sdk.Context) {
+	if err := k.finishRefresh(ctx); err != nil {
+		ctx.Logger().Error(fmt.Sprintf("herb key refresh failed: %v", err))
+		k.setRefreshPhase(ctx, types.RefreshPhaseNone)
+		return
+	}
+	k.setRefreshPhase(ctx, types.RefreshPhasePending)
+}
+
+// finishRefresh computes new verification keys from the deals which got no complaints or were justified
+func (k *Keeper) finishRefresh(ctx sdk.Context) error {
+	vkJSONList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return err
+	}
+	vkList, err := types.VerificationKeyArrayDeserialize(vkJSONList)
+	if err != nil {
+		return err
+	}
+	t, err := k.GetThresholdDecryption(ctx)
+	if err != nil {
+		return err
+	}
+	deals, err := k.GetRefreshDeals(ctx)
+	if err != nil {
+		return err
+	}
+
+	complained, err := k.complainedDealers(ctx)
+	if err != nil {
+		return err
+	}
+
+	store := k.store(ctx)
+	var pubPoly *share.PubPoly
+	qual := make([]sdk.AccAddress, 0, len(deals))
+	for _, deal := range deals {
+		// justifications are checked when they are stored
+		if complained[deal.Dealer.String()] && !store.Has(createRefreshJustificationKey(deal.Dealer)) {
+			continue
+		}
+		poly, err := deal.Deal.PubPoly(int(t), len(vkList))
+		if err != nil {
+			return err
+		}
+		qual = append(qual, deal.Dealer)
+		if pubPoly == nil {
+			pubPoly = poly
+			continue
+		}
+		if pubPoly, err = pubPoly.Add(poly); err != nil {
+			return err
+		}
+	}
+	if pubPoly == nil {
+		return fmt.Errorf("there are no correct refresh deals")
+	}
+
+	newVKList := make([]*types.VerificationKey, len(vkList))
+	for i, vk := range vkList {
+		newVKList[i] = &types.VerificationKey{
+			Key:         P256.Point().Add(vk.Key, pubPoly.Eval(vk.KeyHolderID).V),
+			KeyHolderID: vk.KeyHolderID,
+			Sender:      vk.Sender,
+		}
+	}
+	newVKJSONList, err := types.VerificationKeyArraySerialize(newVKList)
+	if err != nil {
+		return err
+	}
+	store.Set([]byte(keyPendingVerificationKeys), k.cdc.MustMarshalJSON(newVKJSONList))
+	store.Set([]byte(keyRefreshQUAL), k.cdc.MustMarshalJSON(qual))
+	return nil
+}
+
+// applyRefresh replaces verification keys with the pending ones and starts a new key epoch, it's called at the round boundary
+func (k *Keeper) applyRefresh(ctx sdk.Context, round uint64) {
+	store := k.store(ctx)
+	k.setVerificationKeysBytes(ctx, store.Get([]byte(keyPendingVerificationKeys)))
+	store.Delete([]byte(keyPendingVerificationKeys))
+	roundBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(roundBytes, round)
+	store.Set([]byte(keyVerificationKeysRound), roundBytes)
+	k.setRefreshPhase(ctx, types.RefreshPhaseNone)
+	k.setKeyEpoch(ctx, k.CurrentKeyEpoch(ctx)+1, round)
+}
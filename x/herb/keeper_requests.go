@@ -0,0 +1,142 @@
+package herb
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines the on-demand randomness requests. A request is bound to the round serving it: the round collecting
+//ciphertext shares or, if the current round doesn't collect them, the next one. The request fee goes to the rewards pool.
+//In the on-demand mode the next round is opened only if it's requested, requests of the failed round are moved
+//to the next one, so every request is served by a completed round eventually
+
+// RequestRandomness takes the request fee and binds the new request to the round serving it, the request ID is returned
+func (k *Keeper) RequestRandomness(ctx sdk.Context, requester sdk.AccAddress, fee sdk.Coins) (uint64, sdk.Error) {
+	if requester.Empty() {
+		return 0, sdk.ErrInvalidAddress("requester can't be empty")
+	}
+	if !k.keysDefined(ctx) {
+		return 0, sdk.ErrUnknownRequest("randomness can't be requested before the keys are defined")
+	}
+	if !fee.IsValid() {
+		return 0, sdk.ErrInvalidCoins(fmt.Sprintf("invalid request fee: %v", fee))
+	}
+	if !fee.Empty() {
+		if err := k.supplyKeeper.SendCoinsFromAccountToModule(ctx, requester, types.ModuleName, fee); err != nil {
+			return 0, err
+		}
+	}
+	round := k.CurrentRound(ctx)
+	switch k.GetStage(ctx, round) {
+	case stageCtCollecting:
+	case stageUnstarted:
+		if err := k.startFirstRound(ctx); err != nil {
+			return 0, err
+		}
+	default:
+		round++
+	}
+	id := k.nextRequestID(ctx)
+	request := types.RandomnessRequest{ID: id, Round: round, Requester: requester, Fee: fee}
+	k.setRequest(ctx, request)
+	k.setRoundRequests(ctx, round, append(k.roundRequests(ctx, round), id))
+	k.setNextRequestID(ctx, id+1)
+	k.emitRequest(ctx, request)
+	k.advanceRounds(ctx)
+	return id, nil
+}
+
+// GetRequest returns the randomness request
+func (k *Keeper) GetRequest(ctx sdk.Context, id uint64) (*types.RandomnessRequest, sdk.Error) {
+	store := k.store(ctx)
+	keyBytes := createRequestKey(id)
+	if !store.Has(keyBytes) {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("randomness request %v doesn't exist", id))
+	}
+	var request types.RandomnessRequest
+	if err := k.cdc.UnmarshalJSON(store.Get(keyBytes), &request); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal randomness request: %v", err))
+	}
+	return &request, nil
+}
+
+// GetAllRequests returns all randomness requests ordered by ID
+func (k *Keeper) GetAllRequests(ctx sdk.Context) ([]types.RandomnessRequest, sdk.Error) {
+	requests := []types.RandomnessRequest{}
+	for id := uint64(0); id < k.nextRequestID(ctx); id++ {
+		request, err := k.GetRequest(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	return requests, nil
+}
+
+// nextRoundRequested returns false in the on-demand mode if the round after the given one isn't requested
+func (k *Keeper) nextRoundRequested(ctx sdk.Context, round uint64) bool {
+	return !k.GetParams(ctx).OnDemandRounds || len(k.roundRequests(ctx, round+1)) > 0
+}
+
+// moveRequests binds requests of the failed round to the next round
+func (k *Keeper) moveRequests(ctx sdk.Context, round uint64) {
+	ids := k.roundRequests(ctx, round)
+	if len(ids) == 0 {
+		return
+	}
+	for _, id := range ids {
+		request, err := k.GetRequest(ctx, id)
+		if err != nil {
+			panic(err)
+		}
+		request.Round = round + 1
+		k.setRequest(ctx, *request)
+	}
+	k.setRoundRequests(ctx, round+1, append(k.roundRequests(ctx, round+1), ids...))
+	k.setRoundRequests(ctx, round, nil)
+}
+
+func (k *Keeper) setRequest(ctx sdk.Context, request types.RandomnessRequest) {
+	store := k.store(ctx)
+	store.Set(createRequestKey(request.ID), k.cdc.MustMarshalJSON(request))
+}
+
+// roundRequests returns IDs of the requests served by the round
+func (k *Keeper) roundRequests(ctx sdk.Context, round uint64) []uint64 {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyRoundRequests)
+	if !store.Has(keyBytes) {
+		return nil
+	}
+	var ids []uint64
+	k.cdc.MustUnmarshalJSON(store.Get(keyBytes), &ids)
+	return ids
+}
+
+func (k *Keeper) setRoundRequests(ctx sdk.Context, round uint64, ids []uint64) {
+	store := k.store(ctx)
+	keyBytes := createKeyBytesByRound(round, keyRoundRequests)
+	if len(ids) == 0 {
+		store.Delete(keyBytes)
+		return
+	}
+	store.Set(keyBytes, k.cdc.MustMarshalJSON(ids))
+}
+
+func (k *Keeper) nextRequestID(ctx sdk.Context) uint64 {
+	store := k.store(ctx)
+	if !store.Has([]byte(keyNextRequestID)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(store.Get([]byte(keyNextRequestID)))
+}
+
+func (k *Keeper) setNextRequestID(ctx sdk.Context, id uint64) {
+	store := k.store(ctx)
+	idBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(idBytes, id)
+	store.Set([]byte(keyNextRequestID), idBytes)
+}
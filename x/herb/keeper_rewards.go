@@ -0,0 +1,77 @@
+package herb
+
+import (
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+//this file defines participation rewards. The herb module account is funded by the share of the collected fees,
+//entropy providers and key holders of each completed round are paid from it
+
+// collectRewardFees moves the share of the block's collected fees to the rewards pool
+func (k *Keeper) collectRewardFees(ctx sdk.Context) {
+	feeShare := k.GetParams(ctx).RewardFeeShare
+	if !feeShare.IsPositive() {
+		return
+	}
+	fees := k.bankKeeper.GetCoins(ctx, k.supplyKeeper.GetModuleAddress(auth.FeeCollectorName))
+	amount, _ := sdk.NewDecCoins(fees).MulDecTruncate(feeShare).TruncateDecimal()
+	if amount.IsZero() {
+		return
+	}
+	if err := k.supplyKeeper.SendCoinsFromModuleToModule(ctx, auth.FeeCollectorName, types.ModuleName, amount); err != nil {
+		ctx.Logger().Error(fmt.Sprintf("herb can't collect fees to the rewards pool: %v", err))
+	}
+}
+
+// GetRewardPool returns balance of the herb module account
+func (k *Keeper) GetRewardPool(ctx sdk.Context) sdk.Coins {
+	return k.bankKeeper.GetCoins(ctx, k.supplyKeeper.GetModuleAddress(types.ModuleName))
+}
+
+// payRewards pays rewards to the entropy providers and key holders whose shares were counted for the completed round
+// Nobody is paid if the rewards pool can't cover all rewards of the round
+func (k *Keeper) payRewards(ctx sdk.Context, round uint64) {
+	params := k.GetParams(ctx)
+	if params.EntropyProviderReward.Empty() && params.KeyHolderReward.Empty() {
+		return
+	}
+	ctList, err := k.GetAllCiphertexts(ctx, round)
+	if err != nil {
+		return
+	}
+	dsList, err := k.GetAllDecryptionShares(ctx, round)
+	if err != nil {
+		return
+	}
+
+	var recipients []sdk.AccAddress
+	var rewards []sdk.Coins
+	total := sdk.NewCoins()
+	if !params.EntropyProviderReward.Empty() {
+		for _, ct := range ctList {
+			recipients = append(recipients, ct.EntropyProvider)
+			rewards = append(rewards, params.EntropyProviderReward)
+			total = total.Add(params.EntropyProviderReward)
+		}
+	}
+	if !params.KeyHolderReward.Empty() {
+		for _, ds := range dsList {
+			recipients = append(recipients, ds.KeyHolderAddr)
+			rewards = append(rewards, params.KeyHolderReward)
+			total = total.Add(params.KeyHolderReward)
+		}
+	}
+	if pool := k.GetRewardPool(ctx); !pool.IsAllGTE(total) {
+		ctx.Logger().Error(fmt.Sprintf("herb rewards pool %v can't cover round %v rewards %v", pool, round, total))
+		return
+	}
+	for i, recipient := range recipients {
+		if err := k.supplyKeeper.SendCoinsFromModuleToAccount(ctx, types.ModuleName, recipient, rewards[i]); err != nil {
+			ctx.Logger().Error(fmt.Sprintf("herb can't pay reward to %v: %v", recipient, err))
+		}
+	}
+}
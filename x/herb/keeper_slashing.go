@@ -0,0 +1,155 @@
+package herb
+
+import (
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/slashing"
+)
+
+//this file defines penalties for the key holders. Key holders are bonded as validators,
+//a key holder is slashed and jailed if it misses too many rounds in the window
+//or sends the decryption share with incorrect DLEQ proof. A round is missed only if it's aborted
+//while collecting decryption shares and the key holder hasn't sent its share
+
+// trackMissedShares updates miss counters of the key holders after the round is aborted at the decryption shares collecting
+func (k *Keeper) trackMissedShares(ctx sdk.Context, round uint64) {
+	params := k.GetParams(ctx)
+	if params.MissWindow == 0 {
+		return
+	}
+	vkList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return
+	}
+	dsList, err := k.GetAllDecryptionShares(ctx, round)
+	if err != nil {
+		return
+	}
+	sent := make(map[string]bool)
+	for _, ds := range dsList {
+		sent[ds.KeyHolderAddr.String()] = true
+	}
+	// key holder with several keys is punished once
+	punished := make(map[string]bool)
+	for _, vk := range vkList {
+		counter := k.getMissCounter(ctx, vk.Sender, round)
+		if round >= counter.WindowStart+uint64(params.MissWindow) {
+			counter.WindowStart = round
+			counter.MissedRounds = 0
+		}
+		if !sent[vk.Sender.String()] {
+			counter.MissedRounds++
+		}
+		if counter.MissedRounds > params.MaxMissedRounds && !punished[vk.Sender.String()] {
+			punished[vk.Sender.String()] = true
+			if k.punish(ctx, vk.Sender, params.SlashFractionMiss,
+				fmt.Sprintf("missed %v rounds since round %v", counter.MissedRounds, counter.WindowStart)) {
+				counter.Penalties++
+			}
+			counter.WindowStart = round + 1
+			counter.MissedRounds = 0
+		}
+		k.setMissCounter(ctx, counter)
+	}
+}
+
+// punishInvalidShare slashes and jails the key holder who sent decryption share with incorrect DLEQ proof
+// Further invalid shares of the same round aren't punished again
+func (k *Keeper) punishInvalidShare(ctx sdk.Context, round uint64, addr sdk.AccAddress) {
+	counter := k.getMissCounter(ctx, addr, k.CurrentRound(ctx))
+	repeated := counter.InvalidShares > 0 && counter.InvalidShareRound == round
+	counter.InvalidShares++
+	counter.InvalidShareRound = round
+	if !repeated && k.punish(ctx, addr, k.GetParams(ctx).SlashFractionInvalidShare, "invalid decryption share") {
+		counter.Penalties++
+	}
+	k.setMissCounter(ctx, counter)
+}
+
+// punish slashes and jails the validator whose operator is the key holder, returns false if the penalty isn't applied
+// Jailed and unbonded validators are skipped, the staking keeper can't slash them
+func (k *Keeper) punish(ctx sdk.Context, addr sdk.AccAddress, fraction sdk.Dec, reason string) bool {
+	validator := k.stakingKeeper.Validator(ctx, sdk.ValAddress(addr))
+	if validator == nil {
+		ctx.Logger().Error(fmt.Sprintf("herb key holder %v isn't a validator, penalty isn't applied: %v", addr, reason))
+		return false
+	}
+	if validator.IsJailed() || validator.IsUnbonded() {
+		ctx.Logger().Info(fmt.Sprintf("herb key holder %v is jailed or unbonded, penalty isn't applied: %v", addr, reason))
+		return false
+	}
+	consAddr := validator.GetConsAddr()
+	k.stakingKeeper.Slash(ctx, consAddr, ctx.BlockHeight(), validator.GetConsensusPower(), fraction)
+	k.stakingKeeper.Jail(ctx, consAddr)
+	k.setJailedUntil(ctx, consAddr)
+	ctx.Logger().Info(fmt.Sprintf("herb key holder %v is slashed and jailed: %v", addr, reason))
+	return true
+}
+
+// setJailedUntil keeps the jailed validator from unjailing until the jail duration expires
+func (k *Keeper) setJailedUntil(ctx sdk.Context, consAddr sdk.ConsAddress) {
+	var info slashing.ValidatorSigningInfo
+	found := false
+	k.slashingKeeper.IterateValidatorSigningInfos(ctx, func(address sdk.ConsAddress, i slashing.ValidatorSigningInfo) bool {
+		if address.Equals(consAddr) {
+			info, found = i, true
+		}
+		return found
+	})
+	if !found {
+		ctx.Logger().Error(fmt.Sprintf("herb: signing info of validator %v is missing, jail duration isn't set", consAddr))
+		return
+	}
+	jailedUntil := ctx.BlockHeader().Time.Add(k.GetParams(ctx).JailDuration)
+	if info.JailedUntil.Before(jailedUntil) {
+		info.JailedUntil = jailedUntil
+	}
+	k.slashingKeeper.SetValidatorSigningInfo(ctx, consAddr, info)
+}
+
+// getMissCounter returns the key holder's miss counter, new counter's window starts at the given round
+func (k *Keeper) getMissCounter(ctx sdk.Context, addr sdk.AccAddress, round uint64) types.MissCounter {
+	store := k.store(ctx)
+	keyBytes := createMissCounterKey(addr)
+	if !store.Has(keyBytes) {
+		return types.MissCounter{Address: addr, WindowStart: round}
+	}
+	var counter types.MissCounter
+	k.cdc.MustUnmarshalJSON(store.Get(keyBytes), &counter)
+	return counter
+}
+
+func (k *Keeper) setMissCounter(ctx sdk.Context, counter types.MissCounter) {
+	store := k.store(ctx)
+	store.Set(createMissCounterKey(counter.Address), k.cdc.MustMarshalJSON(counter))
+}
+
+// GetMissCounters returns miss counters of the current key holders
+func (k *Keeper) GetMissCounters(ctx sdk.Context) ([]types.MissCounter, sdk.Error) {
+	vkList, err := k.GetVerificationKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	round := k.CurrentRound(ctx)
+	counters := make([]types.MissCounter, len(vkList))
+	for i, vk := range vkList {
+		counters[i] = k.getMissCounter(ctx, vk.Sender, round)
+	}
+	return counters, nil
+}
+
+// getAllMissCounters returns miss counters of the current and former key holders
+func (k *Keeper) getAllMissCounters(ctx sdk.Context) []types.MissCounter {
+	store := k.store(ctx)
+	iterator := sdk.KVStorePrefixIterator(store, []byte(keyMissCounterPrefix))
+	defer iterator.Close()
+	counters := []types.MissCounter{}
+	for ; iterator.Valid(); iterator.Next() {
+		var counter types.MissCounter
+		k.cdc.MustUnmarshalJSON(iterator.Value(), &counter)
+		counters = append(counters, counter)
+	}
+	return counters
+}
@@ -2,25 +2,43 @@ package herb
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/store"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/params"
+	"github.com/cosmos/cosmos-sdk/x/slashing"
+	"github.com/cosmos/cosmos-sdk/x/staking"
+	stakingexported "github.com/cosmos/cosmos-sdk/x/staking/exported"
+	"github.com/cosmos/cosmos-sdk/x/supply"
+	supplyexported "github.com/cosmos/cosmos-sdk/x/supply/exported"
 
 	"github.com/corestario/HERB/dkg"
 	"github.com/corestario/HERB/x/herb/elgamal"
 	"github.com/corestario/HERB/x/herb/types"
 
 	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/libs/log"
 	tmtypes "github.com/tendermint/tendermint/types"
 	dbm "github.com/tendermint/tm-db"
 
 	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/encrypt/ecies"
 	"go.dedis.ch/kyber/v3/proof"
+	"go.dedis.ch/kyber/v3/proof/dleq"
 	"go.dedis.ch/kyber/v3/share"
+	rabin "go.dedis.ch/kyber/v3/share/dkg/rabin"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
 	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
 )
 
@@ -72,14 +90,15 @@ func TestHERB_Positive(t *testing.T) {
 		for i := 0; i < n; i++ {
 			y := keeper.group.Scalar().SetInt64(int64(r))
 			rr := keeper.group.Scalar().SetInt64(int64(r + i))
-			ct, CE, err := createCiphertext(P256, commonkey, y, rr)
+			context := keeper.proofContext(ctx, elgamal.ContextCE, keeper.CurrentRound(ctx), userAddrs[i])
+			ct, CE, err := createCiphertext(P256, context, commonkey, y, rr)
 			if err != nil {
 				t.Errorf("failed create proofs: %v", err)
 			}
-			ctShare := types.CiphertextShare{ct, CE, userAddrs[i]}
+			ctShare := types.CiphertextShare{Ciphertext: ct, CEproof: CE, EntropyProvider: userAddrs[i]}
 			ciphertexts = append(ciphertexts, ctShare.Ciphertext)
 			ciphertextShares = append(ciphertextShares, ctShare)
-			err1 := keeper.SetCiphertext(ctx, &ctShare)
+			err1 := keeper.SetCiphertext(ctx, keeper.CurrentRound(ctx), &ctShare)
 			if err1 != nil {
 				t.Errorf("failed set ciphertext: %v", err1)
 			}
@@ -119,14 +138,15 @@ func TestHERB_Positive(t *testing.T) {
 		}
 		keeper.forceRoundStage(ctx, uint64(round), stageDSCollecting)
 		for i := 0; i < trh; i++ {
-			ds, dleq, err := elgamal.CreateDecShare(P256, ACiphertext, partKeys[i])
+			context := keeper.proofContext(ctx, elgamal.ContextDLEQ, uint64(round), userAddrs[i])
+			ds, dleq, err := elgamal.CreateDecShare(P256, context, ACiphertext, partKeys[i])
 			if err != nil {
 				t.Errorf("failed creating decryption share: %v", err)
 			}
-			decShare := types.DecryptionShare{share.PubShare{I: Verkeys[i].KeyHolderID, V: ds}, dleq, userAddrs[i]}
+			decShare := types.DecryptionShare{DecShare: share.PubShare{I: Verkeys[i].KeyHolderID, V: ds}, DLEQproof: dleq, KeyHolderAddr: userAddrs[i]}
 			decryptionShares = append(decryptionShares, decShare)
 			dshares = append(dshares, &share.PubShare{I: Verkeys[i].KeyHolderID, V: ds})
-			err = keeper.SetDecryptionShare(ctx, &decShare)
+			err = keeper.SetDecryptionShare(ctx, uint64(round), &decShare)
 			if err != nil {
 				t.Errorf("Can't set decryption shares %v", err)
 			}
@@ -156,9 +176,12 @@ func TestHERB_Positive(t *testing.T) {
 				t.Errorf("dleq proofs don't equal")
 			}
 		}
-		resultPoint := elgamal.Decrypt(keeper.group, ACiphertext, dshares, n)
+		resultPoint, err2 := elgamal.Decrypt(keeper.group, ACiphertext, dshares, trh, n)
+		if err2 != nil {
+			t.Fatalf("can't decrypt: %v", err2)
+		}
 		hash := P256.Hash()
-		_, err2 := resultPoint.MarshalTo(hash)
+		_, err2 = resultPoint.MarshalTo(hash)
 		if err2 != nil {
 			t.Errorf("failed to marshal result point to hash: %v", err)
 		}
@@ -173,79 +196,2677 @@ func TestHERB_Positive(t *testing.T) {
 	}
 }
 
-func TestSetGetCiphertext_Positive(t *testing.T) {
-	round := 1
-	r := 2
+func TestSetGetCiphertext_Positive(t *testing.T) {
+	round := 1
+	r := 2
+	n := 3
+	trh := 3
+	ctx, keeper, _ := Initialize(uint64(trh), uint64(n), uint64(n))
+	var ciphertextShares []types.CiphertextShare
+	userAddrs := createTestAddrs(n)
+	keeper.forceRoundStage(ctx, uint64(round), stageCtCollecting)
+	keeper.forceCurrentRound(ctx, uint64(round))
+	_, err := setKeyHolders(ctx, &keeper, userAddrs, trh, n)
+	if err != nil {
+		t.Errorf("can't set public key %v", err)
+	}
+	store := ctx.KVStore(keeper.storeKey)
+	keyCommonBytes := []byte(keyCommonKey)
+	if !store.Has(keyCommonBytes) {
+		t.Errorf("Public key isn't exist")
+	}
+	commonkeyBytes := store.Get(keyCommonBytes)
+	commonkeyStr := string(commonkeyBytes)
+	commonkey, err := kyberenc.StringHexToPoint(keeper.group, commonkeyStr)
+	if err != nil {
+		t.Errorf("can't decode common key to point")
+	}
+	for i := 0; i < n; i++ {
+		y := keeper.group.Scalar().SetInt64(int64(r))
+		rr := keeper.group.Scalar().SetInt64(int64(r + i))
+		context := keeper.proofContext(ctx, elgamal.ContextCE, keeper.CurrentRound(ctx), userAddrs[i])
+		ct, CE, err := createCiphertext(P256, context, commonkey, y, rr)
+		if err != nil {
+			t.Errorf("failed create proofs: %v", err)
+		}
+		ctShare := types.CiphertextShare{Ciphertext: ct, CEproof: CE, EntropyProvider: userAddrs[i]}
+		ciphertextShares = append(ciphertextShares, ctShare)
+		err1 := keeper.SetCiphertext(ctx, keeper.CurrentRound(ctx), &ctShare)
+		if err1 != nil {
+			t.Errorf("failed set ciphertext: %v", err1)
+		}
+	}
+	newCiphertexts, err := keeper.GetAllCiphertexts(ctx, uint64(round))
+	if err != nil {
+		t.Errorf("failed get all ciphertexts: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if newCiphertexts[i].EntropyProvider.String() != ciphertextShares[i].EntropyProvider.String() {
+			t.Errorf("new map doesn't contains original entropy provider, round: %v, expected: %v", round, ciphertextShares[i].EntropyProvider.String())
+		}
+		if !newCiphertexts[i].Ciphertext.Equal(ciphertextShares[i].Ciphertext) {
+			t.Errorf("ciphertexts don't equal, round: %v", round)
+		}
+	}
+}
+
+func TestRoundDeadline_Abort(t *testing.T) {
+	n := 3
+	trh := 2
+	ctx, keeper, _ := Initialize(uint64(trh), uint64(n), uint64(n))
+	userAddrs := createTestAddrs(n)
+	if _, err := setKeyHolders(ctx, &keeper, userAddrs, trh, n); err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	params := keeper.GetParams(ctx)
+
+	ctx = ctx.WithBlockHeight(10)
+	keeper.setStage(ctx, 0, stageCtCollecting)
+
+	EndBlocker(ctx.WithBlockHeight(10+params.CiphertextDeadline-1), keeper)
+	if stage := keeper.GetStage(ctx, 0); stage != stageCtCollecting {
+		t.Errorf("round aborted before deadline, stage: %v", stage)
+	}
+
+	ctx = ctx.WithBlockHeight(10 + params.CiphertextDeadline)
+	EndBlocker(ctx, keeper)
+	if stage := keeper.GetStage(ctx, 0); stage != stageFailed {
+		t.Errorf("round isn't failed after deadline, stage: %v", stage)
+	}
+	if keeper.FailReason(ctx, 0) == "" {
+		t.Errorf("fail reason isn't recorded")
+	}
+	if round := keeper.CurrentRound(ctx); round != 1 {
+		t.Errorf("next round isn't opened, current round: %v", round)
+	}
+	if stage := keeper.GetStage(ctx, 1); stage != stageCtCollecting {
+		t.Errorf("wrong next round stage: %v", stage)
+	}
+
+	keeper.setStage(ctx, 1, stageDSCollecting)
+	ctx = ctx.WithBlockHeight(ctx.BlockHeight() + params.DecryptionDeadline)
+	EndBlocker(ctx, keeper)
+	if stage := keeper.GetStage(ctx, 1); stage != stageFailed {
+		t.Errorf("round isn't failed after decryption deadline, stage: %v", stage)
+	}
+	if round := keeper.CurrentRound(ctx); round != 2 {
+		t.Errorf("next round isn't opened, current round: %v", round)
+	}
+}
+
+func TestRoundDeadline_BrokenShares(t *testing.T) {
+	n := 3
+	trh := 2
+	ctx, keeper, _ := Initialize(uint64(trh), uint64(n), uint64(n))
+	userAddrs := createTestAddrs(n)
+	if _, err := setKeyHolders(ctx, &keeper, userAddrs, trh, n); err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	params := keeper.GetParams(ctx)
+
+	// the round with the unreadable share is aborted instead of halting the chain
+	ctx = ctx.WithBlockHeight(10)
+	keeper.setStage(ctx, 0, stageCtCollecting)
+	keeper.ctStore(ctx).Set(createKeyBytesByAddr(0, userAddrs[0]), []byte("broken"))
+	ctx = ctx.WithBlockHeight(10 + params.CiphertextDeadline)
+	EndBlocker(ctx, keeper)
+	if stage := keeper.GetStage(ctx, 0); stage != stageFailed {
+		t.Errorf("round with broken ciphertext share isn't failed, stage: %v", stage)
+	}
+	if reason := keeper.FailReason(ctx, 0); !strings.Contains(reason, "can't get ciphertext shares") {
+		t.Errorf("wrong fail reason: %v", reason)
+	}
+
+	keeper.setStage(ctx, 1, stageDSCollecting)
+	keeper.dsStore(ctx).Set(createKeyBytesByAddr(1, userAddrs[0]), []byte("broken"))
+	ctx = ctx.WithBlockHeight(ctx.BlockHeight() + params.DecryptionDeadline)
+	EndBlocker(ctx, keeper)
+	if stage := keeper.GetStage(ctx, 1); stage != stageFailed {
+		t.Errorf("round with broken decryption share isn't failed, stage: %v", stage)
+	}
+	if reason := keeper.FailReason(ctx, 1); !strings.Contains(reason, "can't get decryption shares") {
+		t.Errorf("wrong fail reason: %v", reason)
+	}
+	if round := keeper.CurrentRound(ctx); round != 2 {
+		t.Errorf("next round isn't opened, current round: %v", round)
+	}
+}
+
+func TestRoundDeadline_KeyHoldersChange(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n + 3)
+	if _, err := setKeyHolders(ctx, &keeper, userAddrs[:n], 2, n); err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err := keeper.GetCommonPublicKey(ctx)
+	if err != nil {
+		t.Fatalf("can't get common key: %v", err)
+	}
+	params := keeper.GetParams(ctx)
+	ctx = ctx.WithBlockHeight(10)
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+
+	participants := make([]types.DKGParticipantJSON, 3)
+	for i := range participants {
+		p, err := types.NewDKGParticipantJSON(&types.DKGParticipant{Address: userAddrs[n+i], PubKey: P256.Point().Pick(P256.RandomStream())})
+		if err != nil {
+			t.Fatalf("can't serialize participant: %v", err)
+		}
+		participants[i] = p
+	}
+	if err := keeper.ChangeKeyHolders(ctx, participants, 2, 2); err != nil {
+		t.Fatalf("can't change key holders: %v", err)
+	}
+
+	// the round stalled by a silent entropy provider is aborted while the DKG is running
+	ctx = ctx.WithBlockHeight(10 + params.CiphertextDeadline)
+	EndBlocker(ctx, keeper)
+	if stage := keeper.GetStage(ctx, 0); stage != stageFailed {
+		t.Fatalf("round isn't failed after the deadline during the DKG, stage: %v", stage)
+	}
+	if round := keeper.CurrentRound(ctx); round != 1 || keeper.GetStage(ctx, 1) != stageCtCollecting {
+		t.Fatalf("next round isn't opened, current round: %v", round)
+	}
+
+	// the round stalled by a silent key holder is aborted too
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	if stage := keeper.GetStage(ctx, 1); stage != stageDSCollecting {
+		t.Fatalf("round doesn't collect decryption shares: %v", stage)
+	}
+	ctx = ctx.WithBlockHeight(ctx.BlockHeight() + params.DecryptionDeadline)
+	EndBlocker(ctx, keeper)
+	if stage := keeper.GetStage(ctx, 1); stage != stageFailed {
+		t.Errorf("round isn't failed after the decryption deadline during the DKG, stage: %v", stage)
+	}
+	if phase := keeper.GetDKGPhase(ctx); phase == types.DKGPhaseDeals {
+		t.Errorf("DKG phase isn't advanced after its deadline: %v", phase)
+	}
+}
+
+func TestPipelinedRounds(t *testing.T) {
+	n := 3
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	params := keeper.GetParams(ctx)
+	params.PipelinedRounds = true
+	params.CiphertextDeadline = 10
+	params.DecryptionDeadline = 20
+	keeper.SetParams(ctx, params)
+	ctx = ctx.WithBlockHeight(1)
+
+	// round 1 is opened as soon as round 0 starts collecting decryption shares
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	if round, ok := keeper.DecryptingRound(ctx); !ok || round != 0 {
+		t.Fatalf("wrong decrypting round: %v, %v", round, ok)
+	}
+	if round, ok := keeper.CollectingRound(ctx); !ok || round != 1 {
+		t.Fatalf("wrong collecting round: %v, %v", round, ok)
+	}
+	context := keeper.proofContext(ctx, elgamal.ContextCE, 0, userAddrs[2])
+	ct, ceProof, err := createCiphertext(P256, context, commonKey, P256.Scalar().SetInt64(1), P256.Scalar().SetInt64(2))
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	if err := keeper.SetCiphertext(ctx, 0, &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[2]}); err == nil {
+		t.Errorf("ciphertext share for the decrypting round is accepted")
+	}
+
+	// round 1 keeps collecting ciphertext shares until round 0 is decrypted
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[2])
+	if stage := keeper.GetStage(ctx, 1); stage != stageCtCollecting {
+		t.Fatalf("round 1 is closed while round 0 is decrypted: %v", stage)
+	}
+	msg := newTestDecryptionShareMsg(t, ctx, &keeper, privKeys[0], userAddrs[0])
+	if res := NewHandler(keeper)(ctx, msg); res.IsOK() {
+		t.Errorf("decryption share for the collecting round is accepted")
+	}
+	EndBlocker(ctx.WithBlockHeight(1+params.CiphertextDeadline), keeper)
+	if stage := keeper.GetStage(ctx, 1); stage != stageCtCollecting {
+		t.Fatalf("round with enough ciphertext shares is aborted: %v", stage)
+	}
+	if res, broken := AllInvariants(keeper)(ctx); broken {
+		t.Fatalf("invariants are broken: %v", res)
+	}
+
+	exportedBytes := cdc.MustMarshalJSON(ExportGenesis(ctx, keeper))
+	var data GenesisState
+	cdc.MustUnmarshalJSON(exportedBytes, &data)
+	if err := ValidateGenesis(data); err != nil {
+		t.Fatalf("exported genesis isn't valid: %v", err)
+	}
+	newCtx, newKeeper, _ := Initialize(2, 2, uint64(n))
+	InitGenesis(newCtx, newKeeper, data)
+	if reexported := cdc.MustMarshalJSON(ExportGenesis(newCtx, newKeeper)); !bytes.Equal(reexported, exportedBytes) {
+		t.Errorf("pipelined rounds aren't kept by export and import:\n%s\n%s", exportedBytes, reexported)
+	}
+
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	if keeper.GetStage(ctx, 0) != stageCompleted || keeper.GetStage(ctx, 1) != stageDSCollecting || keeper.CurrentRound(ctx) != 2 {
+		t.Fatalf("rounds aren't advanced after decryption: %v, %v, %v", keeper.GetStage(ctx, 0), keeper.GetStage(ctx, 1), keeper.CurrentRound(ctx))
+	}
+
+	// new keys are applied only when no round is decrypted
+	keyHolders, err2 := keeper.GetVerificationKeys(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get verification keys: %v", err2)
+	}
+	tBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(tBytes, 2)
+	keeper.store(ctx).Set([]byte(keyPendingThresholdCiphertexts), tBytes)
+	if err := keeper.setPendingKeyEpoch(ctx, string(keeper.store(ctx).Get([]byte(keyCommonKey))), keyHolders, 2); err != nil {
+		t.Fatalf("can't set pending keys: %v", err)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[1], 1, userAddrs[1])
+	if keeper.GetStage(ctx, 2) != stageDSCollecting || keeper.CurrentRound(ctx) != 2 {
+		t.Fatalf("round is opened while keys are pending: %v, %v", keeper.GetStage(ctx, 2), keeper.CurrentRound(ctx))
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 2, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 2, privKeys[1], 1, userAddrs[1])
+	if keeper.CurrentRound(ctx) != 3 || keeper.CurrentKeyEpoch(ctx) != 1 {
+		t.Fatalf("pending keys aren't applied: %v, %v", keeper.CurrentRound(ctx), keeper.CurrentKeyEpoch(ctx))
+	}
+	if round, err := keeper.LatestCompletedRound(ctx); err != nil || round != 2 {
+		t.Errorf("wrong latest completed round: %v, %v", round, err)
+	}
+	if res, broken := AllInvariants(keeper)(ctx); broken {
+		t.Errorf("invariants are broken: %v", res)
+	}
+}
+
+func TestOnDemandRounds(t *testing.T) {
+	n := 3
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	params := keeper.GetParams(ctx)
+	params.OnDemandRounds = true
+	params.CiphertextDeadline = 10
+	keeper.SetParams(ctx, params)
+	ctx = ctx.WithBlockHeight(1)
+	bk := keeper.bankKeeper.(*mockBankKeeper)
+	bk.balances[userAddrs[2].String()] = sdk.NewCoins(sdk.NewInt64Coin("stake", 10))
+
+	// round 0 isn't started until it's requested
+	if _, ok := keeper.CollectingRound(ctx); ok {
+		t.Fatalf("unrequested round collects ciphertext shares")
+	}
+	context := keeper.proofContext(ctx, elgamal.ContextCE, 0, userAddrs[0])
+	ct, ceProof, err := createCiphertext(P256, context, commonKey, P256.Scalar().SetInt64(1), P256.Scalar().SetInt64(2))
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	if err := keeper.SetCiphertext(ctx, 0, &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[0]}); err == nil {
+		t.Fatalf("ciphertext share for the unrequested round is accepted")
+	}
+	fee := sdk.NewCoins(sdk.NewInt64Coin("stake", 5))
+	res := NewHandler(keeper)(ctx, types.NewMsgRequestRandomness(types.DefaultInstance, fee, userAddrs[2]))
+	if !res.IsOK() || string(res.Data) != "0" {
+		t.Fatalf("randomness request isn't accepted: %v", res.Log)
+	}
+	if pool := keeper.GetRewardPool(ctx); !pool.IsEqual(fee) {
+		t.Errorf("request fee isn't paid to the rewards pool: %v", pool)
+	}
+	if _, err := keeper.RequestRandomness(ctx, userAddrs[2], sdk.NewCoins(sdk.NewInt64Coin("stake", 6))); err == nil {
+		t.Errorf("request with the fee exceeding the balance is accepted")
+	}
+	if id, err := keeper.RequestRandomness(ctx, userAddrs[1], nil); err != nil || id != 1 {
+		t.Fatalf("can't request randomness: %v, %v", id, err)
+	}
+
+	// the request sent while round 0 is decrypted is served by round 1, which is opened after the decryption
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	if id, err := keeper.RequestRandomness(ctx, userAddrs[1], nil); err != nil || id != 2 {
+		t.Fatalf("can't request randomness: %v, %v", id, err)
+	}
+	if request, err := keeper.GetRequest(ctx, 2); err != nil || request.Round != 1 {
+		t.Fatalf("wrong round of the request: %v, %v", request, err)
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	if keeper.CurrentRound(ctx) != 1 || keeper.GetStage(ctx, 1) != stageCtCollecting {
+		t.Fatalf("requested round isn't opened: %v, %v", keeper.CurrentRound(ctx), keeper.GetStage(ctx, 1))
+	}
+	result0, err2 := keeper.RandomResult(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get result: %v", err2)
+	}
+	querier := NewQuerier(keeper)
+	resBytes, err2 := querier(ctx, []string{types.QueryRequest}, abci.RequestQuery{Data: cdc.MustMarshalJSON(types.QueryRequestParams{ID: 0})})
+	if err2 != nil {
+		t.Fatalf("can't query request: %v", err2)
+	}
+	var queryRes types.QueryRequestRes
+	cdc.MustUnmarshalJSON(resBytes, &queryRes)
+	if !bytes.Equal(queryRes.Result, result0) || !queryRes.Request.Requester.Equals(userAddrs[2]) {
+		t.Errorf("wrong request query result: %v", queryRes)
+	}
+
+	// requests of the failed round are moved to the next one
+	EndBlocker(ctx.WithBlockHeight(1+params.CiphertextDeadline), keeper)
+	if keeper.GetStage(ctx, 1) != stageFailed || keeper.CurrentRound(ctx) != 2 {
+		t.Fatalf("round isn't aborted: %v, %v", keeper.GetStage(ctx, 1), keeper.CurrentRound(ctx))
+	}
+	if request, err := keeper.GetRequest(ctx, 2); err != nil || request.Round != 2 {
+		t.Fatalf("request isn't moved to the next round: %v, %v", request, err)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 2, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 2, privKeys[1], 1, userAddrs[1])
+
+	// no round is opened until the next request
+	if keeper.CurrentRound(ctx) != 2 || keeper.GetStage(ctx, 2) != stageCompleted {
+		t.Fatalf("unrequested round is opened: %v, %v", keeper.CurrentRound(ctx), keeper.GetStage(ctx, 2))
+	}
+	if res, broken := AllInvariants(keeper)(ctx); broken {
+		t.Fatalf("invariants are broken: %v", res)
+	}
+	exportedBytes := cdc.MustMarshalJSON(ExportGenesis(ctx, keeper))
+	var data GenesisState
+	cdc.MustUnmarshalJSON(exportedBytes, &data)
+	if err := ValidateGenesis(data); err != nil {
+		t.Fatalf("exported genesis isn't valid: %v", err)
+	}
+	newCtx, newKeeper, _ := Initialize(2, 2, uint64(n))
+	InitGenesis(newCtx, newKeeper, data)
+	if reexported := cdc.MustMarshalJSON(ExportGenesis(newCtx, newKeeper)); !bytes.Equal(reexported, exportedBytes) {
+		t.Errorf("requests aren't kept by export and import:\n%s\n%s", exportedBytes, reexported)
+	}
+	if id, err := newKeeper.RequestRandomness(newCtx, userAddrs[1], nil); err != nil || id != 3 {
+		t.Fatalf("can't request randomness after import: %v, %v", id, err)
+	}
+	if newKeeper.CurrentRound(newCtx) != 3 || newKeeper.GetStage(newCtx, 3) != stageCtCollecting {
+		t.Fatalf("requested round isn't opened: %v, %v", newKeeper.CurrentRound(newCtx), newKeeper.GetStage(newCtx, 3))
+	}
+
+	// rounds are opened continuously again when the on-demand mode is switched off
+	params.OnDemandRounds = false
+	keeper.SetParams(ctx, params)
+	EndBlocker(ctx, keeper)
+	if keeper.CurrentRound(ctx) != 3 || keeper.GetStage(ctx, 3) != stageCtCollecting {
+		t.Fatalf("round isn't opened after the on-demand mode is off: %v, %v", keeper.CurrentRound(ctx), keeper.GetStage(ctx, 3))
+	}
+}
+
+func TestDKG_Positive(t *testing.T) {
+	n := 4
+	trh := 3
+	ctx, keeper, _ := Initialize(uint64(trh), uint64(n), uint64(n))
+	userAddrs := createTestAddrs(n)
+
+	longterms := make([]kyber.Scalar, n)
+	pubKeys := make([]kyber.Point, n)
+	participants := make([]types.DKGParticipantJSON, n)
+	for i := 0; i < n; i++ {
+		longterms[i] = P256.Scalar().Pick(P256.RandomStream())
+		pubKeys[i] = P256.Point().Mul(longterms[i], nil)
+		p, err := types.NewDKGParticipantJSON(&types.DKGParticipant{Address: userAddrs[i], PubKey: pubKeys[i]})
+		if err != nil {
+			t.Fatalf("can't serialize participant: %v", err)
+		}
+		participants[i] = p
+	}
+	if err := keeper.StartDKG(ctx, participants, uint64(trh)); err != nil {
+		t.Fatalf("can't start DKG: %v", err)
+	}
+	gens := make([]*rabin.DistKeyGenerator, n)
+	for i := 0; i < n; i++ {
+		gen, err := rabin.NewDistKeyGenerator(P256, longterms[i], pubKeys, trh)
+		if err != nil {
+			t.Fatalf("can't create key generator: %v", err)
+		}
+		gens[i] = gen
+	}
+
+	ct, _, err := elgamal.RandomCiphertext(P256, nil, P256.Point().Base())
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	if err := keeper.SetCiphertext(ctx, keeper.CurrentRound(ctx), &types.CiphertextShare{Ciphertext: ct, EntropyProvider: userAddrs[0]}); err == nil {
+		t.Errorf("ciphertext is accepted before the DKG is completed")
+	}
+
+	for i, gen := range gens {
+		deals, err := gen.Deals()
+		if err != nil {
+			t.Fatalf("can't create deals: %v", err)
+		}
+		var dkgDeals []types.DKGDeal
+		for recipient, deal := range deals {
+			dkgDeals = append(dkgDeals, types.DKGDeal{Recipient: uint32(recipient), Data: encodeDKGTest(t, deal)})
+		}
+		if err := keeper.SetDKGDeals(ctx, userAddrs[(i+1)%n], dkgDeals); err == nil {
+			t.Errorf("deals from the wrong sender are accepted")
+		}
+		if err := keeper.SetDKGDeals(ctx, userAddrs[i], dkgDeals); err != nil {
+			t.Fatalf("can't set deals: %v", err)
+		}
+	}
+	if phase := keeper.GetDKGPhase(ctx); phase != types.DKGPhaseResponses {
+		t.Fatalf("wrong DKG phase: %v, expected: %v", phase, types.DKGPhaseResponses)
+	}
+
+	for i, gen := range gens {
+		deals, err := keeper.GetDKGDeals(ctx, uint32(i))
+		if err != nil {
+			t.Fatalf("can't get deals: %v", err)
+		}
+		var responses [][]byte
+		for _, bz := range deals {
+			var deal rabin.Deal
+			if err := types.DecodeDKGMessage(bz, &deal); err != nil {
+				t.Fatalf("can't decode deal: %v", err)
+			}
+			resp, err := gen.ProcessDeal(&deal)
+			if err != nil {
+				t.Fatalf("can't process deal: %v", err)
+			}
+			responses = append(responses, encodeDKGTest(t, resp))
+		}
+		if err := keeper.SetDKGResponses(ctx, userAddrs[i], responses); err != nil {
+			t.Fatalf("can't set responses: %v", err)
+		}
+	}
+
+	responses, err2 := keeper.GetDKGMessages(ctx, types.DKGPhaseResponses)
+	if err2 != nil {
+		t.Fatalf("can't get responses: %v", err2)
+	}
+	for i, gen := range gens {
+		for _, msg := range responses {
+			if msg.Index == uint32(i) {
+				continue
+			}
+			for _, bz := range msg.Data {
+				var resp rabin.Response
+				if err := types.DecodeDKGMessage(bz, &resp); err != nil {
+					t.Fatalf("can't decode response: %v", err)
+				}
+				if j, err := gen.ProcessResponse(&resp); err != nil || j != nil {
+					t.Fatalf("unexpected response processing result: %v, %v", j, err)
+				}
+			}
+		}
+		if err := keeper.SetDKGJustifications(ctx, userAddrs[i], nil); err != nil {
+			t.Fatalf("can't set justifications: %v", err)
+		}
+	}
+
+	// the second dealer publishes secret commits which match the deals of the third and the fourth participants only:
+	// F'(x) = F(x) + R(x-3)(x-4), shares of the participant i are evaluated at x = i+1
+	for i, gen := range gens {
+		sc, err := gen.SecretCommits()
+		if err != nil {
+			t.Fatalf("can't create secret commits: %v", err)
+		}
+		if i == 1 {
+			r := P256.Point().Pick(P256.RandomStream())
+			forged := make([]kyber.Point, len(sc.Commitments))
+			for j, c := range []int64{12, -7, 1} {
+				forged[j] = P256.Point().Add(sc.Commitments[j], P256.Point().Mul(P256.Scalar().SetInt64(c), r))
+			}
+			sc.Commitments = forged
+			if sc.Signature, err = schnorr.Sign(P256, longterms[i], sc.Hash(P256)); err != nil {
+				t.Fatalf("can't sign secret commits: %v", err)
+			}
+		}
+		var qual []uint32
+		for _, idx := range gen.QUAL() {
+			qual = append(qual, uint32(idx))
+		}
+		if err := keeper.SetDKGSecretCommits(ctx, userAddrs[i], encodeDKGTest(t, sc), qual); err != nil {
+			t.Fatalf("can't set secret commits: %v", err)
+		}
+	}
+
+	commits, err2 := keeper.GetDKGMessages(ctx, types.DKGPhaseCommits)
+	if err2 != nil {
+		t.Fatalf("can't get secret commits: %v", err2)
+	}
+	for i, gen := range gens {
+		var complaints [][]byte
+		for _, msg := range commits {
+			if msg.Index == uint32(i) {
+				continue
+			}
+			var sc rabin.SecretCommits
+			if err := types.DecodeDKGMessage(msg.Data[0], &sc); err != nil {
+				t.Fatalf("can't decode secret commits: %v", err)
+			}
+			cc, err := gen.ProcessSecretCommits(&sc)
+			if err != nil || (cc == nil) != (sc.Index != 1 || i != 0) {
+				t.Fatalf("unexpected secret commits processing result: %v, %v", cc, err)
+			}
+			if cc != nil {
+				complaints = append(complaints, encodeDKGTest(t, cc))
+			}
+		}
+		if err := keeper.SetDKGComplaintCommits(ctx, userAddrs[i], complaints); err != nil {
+			t.Fatalf("can't set complaint commits: %v", err)
+		}
+	}
+
+	complaints, err2 := keeper.GetDKGMessages(ctx, types.DKGPhaseComplaints)
+	if err2 != nil {
+		t.Fatalf("can't get complaint commits: %v", err2)
+	}
+	if len(complaints[0].Data) != 1 {
+		t.Fatalf("wrong number of complaints: %v", len(complaints[0].Data))
+	}
+	var cc rabin.ComplaintCommits
+	if err := types.DecodeDKGMessage(complaints[0].Data[0], &cc); err != nil {
+		t.Fatalf("can't decode complaint commits: %v", err)
+	}
+	// the participants which accepted the secret commits reveal their shares,
+	// the complainer has no commitments and the dealer knows its commitments are right
+	revealed := make([]*rabin.ReconstructCommits, n)
+	for i, gen := range gens {
+		rc, err := gen.ProcessComplaintCommits(&cc)
+		if (err == nil) != (i > 1) {
+			t.Fatalf("unexpected complaint commits processing result, participant: %v, error: %v", i, err)
+		}
+		revealed[i] = rc
+	}
+	// the dealer also reveals its share to avoid being excluded
+	priPoly, err := share.RecoverPriPoly(P256, []*share.PriShare{cc.Deal.SecShare, revealed[2].Share, revealed[3].Share}, trh, n)
+	if err != nil {
+		t.Fatalf("can't recover dealer's polynomial: %v", err)
+	}
+	revealed[1] = &rabin.ReconstructCommits{SessionID: cc.Deal.SessionID, Index: 1, DealerIndex: 1, Share: priPoly.Eval(1)}
+	if revealed[1].Signature, err = schnorr.Sign(P256, longterms[1], revealed[1].Hash(P256)); err != nil {
+		t.Fatalf("can't sign reconstruct commits: %v", err)
+	}
+	if err := keeper.SetDKGReconstructCommits(ctx, userAddrs[0], [][]byte{encodeDKGTest(t, revealed[2])}); err == nil {
+		t.Errorf("reconstruct share of another participant is accepted")
+	}
+	for i, rc := range revealed {
+		var reconstructs [][]byte
+		if rc != nil {
+			reconstructs = [][]byte{encodeDKGTest(t, rc)}
+		}
+		if err := keeper.SetDKGReconstructCommits(ctx, userAddrs[i], reconstructs); err != nil {
+			t.Fatalf("can't set reconstruct commits: %v", err)
+		}
+	}
+
+	reconstructs, err2 := keeper.GetDKGMessages(ctx, types.DKGPhaseReconstructs)
+	if err2 != nil {
+		t.Fatalf("can't get reconstruct commits: %v", err2)
+	}
+	for i, gen := range gens {
+		for _, msg := range reconstructs {
+			if msg.Index == uint32(i) {
+				continue
+			}
+			for _, bz := range msg.Data {
+				var rc rabin.ReconstructCommits
+				if err := types.DecodeDKGMessage(bz, &rc); err != nil {
+					t.Fatalf("can't decode reconstruct commits: %v", err)
+				}
+				if err := gen.ProcessReconstructCommits(&rc); err != nil && i != 1 {
+					t.Fatalf("can't process reconstruct commits: %v", err)
+				}
+			}
+		}
+	}
+	if phase := keeper.GetDKGPhase(ctx); phase != types.DKGPhaseCompleted {
+		t.Fatalf("wrong DKG phase: %v, expected: %v", phase, types.DKGPhaseCompleted)
+	}
+
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	vkJSONList, err2 := keeper.GetVerificationKeys(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get verification keys: %v", err2)
+	}
+	vkList, err2 := types.VerificationKeyArrayDeserialize(vkJSONList)
+	if err2 != nil {
+		t.Fatalf("can't deserialize verification keys: %v", err2)
+	}
+	for i, gen := range gens {
+		distKey, err := gen.DistKeyShare()
+		if err != nil {
+			t.Fatalf("can't get distributed key share: %v", err)
+		}
+		if !distKey.Public().Equal(commonKey) {
+			t.Errorf("common keys don't equal, participant: %v", i)
+		}
+		vk := P256.Point().Mul(distKey.PriShare().V, nil)
+		if vkList[i].KeyHolderID != distKey.PriShare().I || !vkList[i].Key.Equal(vk) || !vkList[i].Sender.Equals(userAddrs[i]) {
+			t.Errorf("wrong verification key, participant: %v", i)
+		}
+	}
+	if tr, _ := keeper.GetThresholdDecryption(ctx); tr != uint64(trh) {
+		t.Errorf("wrong decryption threshold: %v", tr)
+	}
+}
+
+func TestDKG_PhaseDeadline(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, uint64(n), uint64(n))
+	userAddrs := createTestAddrs(n)
+	participants := make([]types.DKGParticipantJSON, n)
+	for i := 0; i < n; i++ {
+		p, err := types.NewDKGParticipantJSON(&types.DKGParticipant{Address: userAddrs[i], PubKey: P256.Point().Pick(P256.RandomStream())})
+		if err != nil {
+			t.Fatalf("can't serialize participant: %v", err)
+		}
+		participants[i] = p
+	}
+	ctx = ctx.WithBlockHeight(10)
+	if err := keeper.StartDKG(ctx, participants, 2); err != nil {
+		t.Fatalf("can't start DKG: %v", err)
+	}
+	params := keeper.GetParams(ctx)
+
+	EndBlocker(ctx.WithBlockHeight(10+params.DKGPhaseDeadline-1), keeper)
+	if phase := keeper.GetDKGPhase(ctx); phase != types.DKGPhaseDeals {
+		t.Errorf("DKG phase changed before deadline: %v", phase)
+	}
+	for i, phase := range types.DKGPhases[1:] {
+		ctx = ctx.WithBlockHeight(10 + int64(i+1)*params.DKGPhaseDeadline)
+		EndBlocker(ctx, keeper)
+		expected := phase
+		if phase == types.DKGPhaseCompleted {
+			// nobody sent the QUAL set
+			expected = types.DKGPhaseFailed
+		}
+		if current := keeper.GetDKGPhase(ctx); current != expected {
+			t.Fatalf("wrong DKG phase: %v, expected: %v", current, expected)
+		}
+	}
+}
+
+func TestRefresh_Positive(t *testing.T) {
+	n := 4
+	trh := 3
+	ctx, keeper, _ := Initialize(uint64(trh), uint64(n), uint64(n))
+	userAddrs := createTestAddrs(n + 1)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs[:n], trh, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	vkJSONList, err2 := keeper.GetVerificationKeys(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get verification keys: %v", err2)
+	}
+	vkList, err2 := types.VerificationKeyArrayDeserialize(vkJSONList)
+	if err2 != nil {
+		t.Fatalf("can't deserialize verification keys: %v", err2)
+	}
+
+	if err := keeper.StartRefresh(ctx, userAddrs[0]); err == nil {
+		t.Errorf("refresh is started without the encryption keys")
+	}
+	encPrivKeys := make([]kyber.Scalar, n)
+	encKeys := make([]kyber.Point, n)
+	for j := 0; j < n; j++ {
+		encPrivKeys[j] = P256.Scalar().Pick(P256.RandomStream())
+		encKeys[j] = P256.Point().Mul(encPrivKeys[j], nil)
+		encKey, _ := kyberenc.PointToStringHex(P256, encKeys[j])
+		if err := keeper.SetEncryptionKey(ctx, userAddrs[j], encKey); err != nil {
+			t.Fatalf("can't set encryption key: %v", err)
+		}
+	}
+	if err := keeper.SetEncryptionKey(ctx, userAddrs[n], vkJSONList[0].Key); err == nil {
+		t.Errorf("encryption key is set by non key holder")
+	}
+
+	if err := keeper.StartRefresh(ctx, userAddrs[n]); err == nil {
+		t.Errorf("refresh is started by non key holder")
+	}
+	if err := keeper.StartRefresh(ctx, userAddrs[0]); err != nil {
+		t.Fatalf("can't start refresh: %v", err)
+	}
+	if err := keeper.StartRefresh(ctx, userAddrs[1]); err == nil {
+		t.Errorf("refresh is started twice")
+	}
+
+	// the last dealer sends incorrect share to the second key holder
+	cheater := n - 1
+	polys := make([]*share.PriPoly, n)
+	for i := 0; i < n; i++ {
+		deal, poly, err := types.NewRefreshDeal(vkList, encKeys, trh)
+		if err != nil {
+			t.Fatalf("can't create refresh deal: %v", err)
+		}
+		polys[i] = poly
+		if i == cheater {
+			badShare, _ := P256.Scalar().Pick(P256.RandomStream()).MarshalBinary()
+			if deal.Shares[1], err = ecies.Encrypt(P256, encKeys[1], badShare, nil); err != nil {
+				t.Fatalf("can't encrypt share: %v", err)
+			}
+		}
+		if err := keeper.SetRefreshDeal(ctx, userAddrs[i], *deal); err != nil {
+			t.Fatalf("can't set refresh deal: %v", err)
+		}
+	}
+	if phase := keeper.GetRefreshPhase(ctx); phase != types.RefreshPhaseComplaints {
+		t.Fatalf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseComplaints)
+	}
+
+	deals, err2 := keeper.GetRefreshDeals(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get refresh deals: %v", err2)
+	}
+	for _, deal := range deals {
+		if _, err := deal.Deal.DecryptShare(privKeys[0], 0, vkList[0].KeyHolderID, trh, n); err == nil {
+			t.Errorf("share is decrypted with the key share")
+		}
+	}
+	// the third key holder complains about the honest first dealer
+	for j := 0; j < n; j++ {
+		var dealers []sdk.AccAddress
+		for _, deal := range deals {
+			if _, err := deal.Deal.DecryptShare(encPrivKeys[j], j, vkList[j].KeyHolderID, trh, n); err != nil {
+				dealers = append(dealers, deal.Dealer)
+			}
+		}
+		if (j == 1) != (len(dealers) == 1) {
+			t.Errorf("wrong complaints of key holder %v: %v", j, dealers)
+		}
+		if j == 2 {
+			dealers = append(dealers, userAddrs[0])
+		}
+		if err := keeper.SetRefreshComplaint(ctx, userAddrs[j], dealers); err != nil {
+			t.Fatalf("can't set refresh complaint: %v", err)
+		}
+	}
+	if phase := keeper.GetRefreshPhase(ctx); phase != types.RefreshPhaseJustifications {
+		t.Fatalf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseJustifications)
+	}
+
+	badShare, _ := kyberenc.ScalarToStringHex(P256, P256.Scalar().Pick(P256.RandomStream()))
+	badJustification := types.RefreshJustification{KeyHolder: userAddrs[1], Share: badShare}
+	if err := keeper.SetRefreshJustification(ctx, userAddrs[cheater], []types.RefreshJustification{badJustification}); err == nil {
+		t.Errorf("incorrect share is accepted as justification")
+	}
+	justification, err := types.NewRefreshJustification(polys[0], vkList[1])
+	if err != nil {
+		t.Fatalf("can't create justification: %v", err)
+	}
+	if err := keeper.SetRefreshJustification(ctx, userAddrs[0], []types.RefreshJustification{justification}); err == nil {
+		t.Errorf("justification for the key holder who didn't complain is accepted")
+	}
+	if justification, err = types.NewRefreshJustification(polys[0], vkList[2]); err != nil {
+		t.Fatalf("can't create justification: %v", err)
+	}
+	if err := keeper.SetRefreshJustification(ctx, userAddrs[0], []types.RefreshJustification{justification}); err != nil {
+		t.Fatalf("can't set refresh justification: %v", err)
+	}
+	// the cheater doesn't justify its deal until the phase deadline
+	if phase := keeper.GetRefreshPhase(ctx); phase != types.RefreshPhaseJustifications {
+		t.Fatalf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseJustifications)
+	}
+	keeper.advanceRefreshPhase(ctx)
+	if phase := keeper.GetRefreshPhase(ctx); phase != types.RefreshPhasePending {
+		t.Fatalf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhasePending)
+	}
+	qual := keeper.GetRefreshQUAL(ctx)
+	if len(qual) != n-1 || !qual[0].Equals(userAddrs[0]) {
+		t.Fatalf("wrong QUAL: %v", qual)
+	}
+
+	newPrivKeys := make([]kyber.Scalar, n)
+	for j := 0; j < n; j++ {
+		newPrivKeys[j] = P256.Scalar().Set(privKeys[j])
+		for i, deal := range deals {
+			if i == cheater {
+				continue
+			}
+			s, err := deal.Deal.DecryptShare(encPrivKeys[j], j, vkList[j].KeyHolderID, trh, n)
+			if i == 0 && j == 2 {
+				s, err = deal.Deal.JustifiedShare(justification, vkList[j].KeyHolderID, trh, n)
+			}
+			if err != nil {
+				t.Fatalf("can't get share: %v", err)
+			}
+			newPrivKeys[j].Add(newPrivKeys[j], s)
+		}
+	}
+
+	if vks, _ := keeper.GetVerificationKeys(ctx); vks[0].Key != vkJSONList[0].Key {
+		t.Errorf("verification keys are changed before the round boundary")
+	}
+	keeper.startNextRound(ctx)
+	if phase := keeper.GetRefreshPhase(ctx); phase != types.RefreshPhaseNone {
+		t.Errorf("wrong refresh phase: %v, expected: %v", phase, types.RefreshPhaseNone)
+	}
+	if round := keeper.GetVerificationKeysRound(ctx); round != 1 {
+		t.Errorf("wrong verification keys round: %v", round)
+	}
+	newVKJSONList, err2 := keeper.GetVerificationKeys(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get verification keys: %v", err2)
+	}
+	newVKList, err2 := types.VerificationKeyArrayDeserialize(newVKJSONList)
+	if err2 != nil {
+		t.Fatalf("can't deserialize verification keys: %v", err2)
+	}
+	pubShares := make([]*share.PubShare, n)
+	for j, vk := range newVKList {
+		if vk.Key.Equal(vkList[j].Key) {
+			t.Errorf("verification key %v isn't changed", j)
+		}
+		if !vk.Key.Equal(P256.Point().Mul(newPrivKeys[j], nil)) {
+			t.Errorf("verification key %v doesn't match the refreshed private key", j)
+		}
+		pubShares[j] = &share.PubShare{I: vk.KeyHolderID, V: vk.Key}
+	}
+	recovered, err := share.RecoverCommit(P256, pubShares[1:], trh, n)
+	if err != nil {
+		t.Fatalf("can't recover common key: %v", err)
+	}
+	if !recovered.Equal(commonKey) {
+		t.Errorf("common key is changed by refresh")
+	}
+}
+
+func TestKeyHoldersChange_Epoch(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n + 4)
+	if _, err := setKeyHolders(ctx, &keeper, userAddrs[:n], 2, n); err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	oldCommonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, oldCommonKey, userAddrs[0])
+	if epoch, err := keeper.GetRoundEpoch(ctx, 0); err != nil || epoch.Number != 0 {
+		t.Fatalf("wrong epoch of round 0: %v, %v", epoch, err)
+	}
+
+	newN := 4
+	newTrh := 3
+	newAddrs := userAddrs[n:]
+	longterms := make([]kyber.Scalar, newN)
+	participants := make([]types.DKGParticipantJSON, newN)
+	for i := 0; i < newN; i++ {
+		longterms[i] = P256.Scalar().Pick(P256.RandomStream())
+		p, err := types.NewDKGParticipantJSON(&types.DKGParticipant{Address: newAddrs[i], PubKey: P256.Point().Mul(longterms[i], nil)})
+		if err != nil {
+			t.Fatalf("can't serialize participant: %v", err)
+		}
+		participants[i] = p
+	}
+	if err := keeper.ChangeKeyHolders(ctx, participants, uint64(newTrh), uint64(newTrh)); err != nil {
+		t.Fatalf("can't change key holders: %v", err)
+	}
+	if err := keeper.ChangeKeyHolders(ctx, participants, uint64(newTrh), uint64(newTrh)); err == nil {
+		t.Errorf("key holders change is started twice")
+	}
+	// rounds continue with the old keys while the DKG is running
+	setTestCiphertext(t, ctx, &keeper, oldCommonKey, userAddrs[1])
+
+	gens := runDKGTest(t, ctx, &keeper, newAddrs, longterms, newTrh)
+	if phase := keeper.GetDKGPhase(ctx); phase != types.DKGPhaseCompleted {
+		t.Fatalf("wrong DKG phase: %v, expected: %v", phase, types.DKGPhaseCompleted)
+	}
+	if commonKey, _ := keeper.GetCommonPublicKey(ctx); !commonKey.Equal(oldCommonKey) {
+		t.Errorf("common key is changed before the round boundary")
+	}
+	if keeper.GetPendingKeyEpoch(ctx) == nil {
+		t.Fatalf("new keys aren't pending")
+	}
+	if encKey := keeper.GetEncryptionKey(ctx, newAddrs[0]); encKey != participants[0].PubKey {
+		t.Errorf("participant's long-term key isn't registered as the encryption key: %v", encKey)
+	}
+	for i := 0; i < n; i++ {
+		if err := keeper.SetEncryptionKey(ctx, userAddrs[i], participants[i].PubKey); err != nil {
+			t.Fatalf("can't set encryption key: %v", err)
+		}
+	}
+	if err := keeper.StartRefresh(ctx, userAddrs[0]); err == nil {
+		t.Errorf("refresh is started while new keys are pending")
+	}
+
+	keeper.AbortRound(ctx, keeper.CurrentRound(ctx), "test")
+	if epoch := keeper.CurrentKeyEpoch(ctx); epoch != 1 {
+		t.Fatalf("wrong current epoch: %v", epoch)
+	}
+	distKey, err := gens[0].DistKeyShare()
+	if err != nil {
+		t.Fatalf("can't get distributed key share: %v", err)
+	}
+	newCommonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	if !newCommonKey.Equal(distKey.Public()) {
+		t.Errorf("common key isn't replaced")
+	}
+	if kh, _ := keeper.GetKeyHoldersNumber(ctx); kh != uint64(newN) {
+		t.Errorf("wrong key holders number: %v", kh)
+	}
+	if tr, _ := keeper.GetThresholdDecryption(ctx); tr != uint64(newTrh) {
+		t.Errorf("wrong decryption threshold: %v", tr)
+	}
+
+	oldEpoch, err2 := keeper.GetRoundEpoch(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get epoch of round 0: %v", err2)
+	}
+	newEpoch, err2 := keeper.GetRoundEpoch(ctx, 1)
+	if err2 != nil {
+		t.Fatalf("can't get epoch of round 1: %v", err2)
+	}
+	oldKeyHex, _ := kyberenc.PointToStringHex(P256, oldCommonKey)
+	newKeyHex, _ := kyberenc.PointToStringHex(P256, newCommonKey)
+	if oldEpoch.Number != 0 || oldEpoch.CommonPublicKey != oldKeyHex || len(oldEpoch.KeyHolders) != n || oldEpoch.ThresholdDecryption != 2 {
+		t.Errorf("wrong epoch of round 0: %v", oldEpoch)
+	}
+	if newEpoch.Number != 1 || newEpoch.StartRound != 1 || newEpoch.CommonPublicKey != newKeyHex || len(newEpoch.KeyHolders) != newN ||
+		newEpoch.ThresholdCiphertexts != uint64(newTrh) || newEpoch.ThresholdDecryption != uint64(newTrh) {
+		t.Errorf("wrong epoch of round 1: %v", newEpoch)
+	}
+	setTestCiphertext(t, ctx, &keeper, newCommonKey, userAddrs[0])
+}
+
+func TestSlashing_MissedRounds(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	params := keeper.GetParams(ctx)
+	params.MissWindow = 4
+	params.MaxMissedRounds = 1
+	keeper.SetParams(ctx, params)
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	sk := keeper.stakingKeeper.(*mockStakingKeeper)
+	for _, addr := range userAddrs {
+		sk.addValidator(addr)
+	}
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+
+	// the last key holder is slower than the others, it isn't blamed while the rounds are completed
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	for i := 0; i < 2; i++ {
+		setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[i], i, userAddrs[i])
+	}
+	if current := keeper.CurrentRound(ctx); current != 1 {
+		t.Fatalf("round 0 isn't completed")
+	}
+	// only the first key holder sends decryption shares, the rounds are aborted at the decryption deadline
+	for round := uint64(1); round < 3; round++ {
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+		setTestDecryptionShare(t, ctx, &keeper, round, privKeys[0], 0, userAddrs[0])
+		keeper.AbortRound(ctx, round, "decryption deadline")
+		if current := keeper.CurrentRound(ctx); current != round+1 {
+			t.Fatalf("round %v isn't aborted", round)
+		}
+	}
+
+	if len(sk.slashed) != 2 || !sk.slashed[0].Equals(sk.consAddr(userAddrs[1])) || !sk.slashed[1].Equals(sk.consAddr(userAddrs[2])) ||
+		!sk.fractions[0].Equal(params.SlashFractionMiss) {
+		t.Fatalf("wrong slashed validators: %v", sk.slashed)
+	}
+	if len(sk.jailed) != 2 || !sk.jailed[0].Equals(sk.consAddr(userAddrs[1])) || !sk.jailed[1].Equals(sk.consAddr(userAddrs[2])) {
+		t.Fatalf("wrong jailed validators: %v", sk.jailed)
+	}
+	counters, err2 := keeper.GetMissCounters(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get miss counters: %v", err2)
+	}
+	for i, counter := range counters {
+		if !counter.Address.Equals(userAddrs[i]) {
+			t.Fatalf("wrong miss counter address: %v", counter.Address)
+		}
+		if i == 0 && (counter.MissedRounds != 0 || counter.Penalties != 0) {
+			t.Errorf("wrong miss counter of key holder %v: %v", i, counter)
+		}
+		if i > 0 && (counter.MissedRounds != 0 || counter.Penalties != 1 || counter.WindowStart != 3) {
+			t.Errorf("wrong miss counter of key holder %v: %v", i, counter)
+		}
+	}
+
+	// jailed key holders keep missing rounds, they aren't slashed again
+	for round := uint64(3); round < 5; round++ {
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+		setTestDecryptionShare(t, ctx, &keeper, round, privKeys[0], 0, userAddrs[0])
+		keeper.AbortRound(ctx, round, "decryption deadline")
+	}
+	if len(sk.slashed) != 2 || len(sk.jailed) != 2 {
+		t.Errorf("jailed validators are punished again: %v, %v", sk.slashed, sk.jailed)
+	}
+	counters, _ = keeper.GetMissCounters(ctx)
+	if counters[1].Penalties != 1 || counters[2].Penalties != 1 {
+		t.Errorf("wrong miss counters: %v", counters)
+	}
+}
+
+func TestDecryptionShare_KeyHolderIndex(t *testing.T) {
+	n := 3
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	aggCt, err2 := keeper.GetAggregatedCiphertext(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get aggregated ciphertext: %v", err2)
+	}
+
+	// the correct share under another holder's index is stored with the sender's own index
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 1, userAddrs[0])
+	shares, err2 := keeper.GetAllDecryptionShares(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get decryption shares: %v", err2)
+	}
+	if len(shares) != 1 || shares[0].DecShare.I != 0 {
+		t.Fatalf("share is stored under the wrong index: %v", shares)
+	}
+
+	// the genesis share must carry the key holder ID too
+	var data GenesisState
+	cdc.MustUnmarshalJSON(cdc.MustMarshalJSON(ExportGenesis(ctx, keeper)), &data)
+	if err := ValidateGenesis(data); err != nil {
+		t.Fatalf("exported genesis isn't valid: %v", err)
+	}
+	wrongIndex := *shares[0]
+	wrongIndex.DecShare.I = 1
+	dsJSON, err2 := types.NewDecryptionShareJSON(&wrongIndex)
+	if err2 != nil {
+		t.Fatalf("can't encode decryption share: %v", err2)
+	}
+	data.RoundData[0].DecryptionShares[0] = &dsJSON
+	if err := ValidateGenesis(data); err == nil {
+		t.Errorf("genesis share with another holder's index is accepted")
+	}
+
+	// the message carries no index, the result matches the decryption with the key holder IDs
+	if res := NewHandler(keeper)(ctx, newTestDecryptionShareMsg(t, ctx, &keeper, privKeys[1], userAddrs[1])); !res.IsOK() {
+		t.Fatalf("decryption share isn't accepted: %v", res.Log)
+	}
+	if stage := keeper.GetStage(ctx, 0); stage != stageCompleted {
+		t.Fatalf("round isn't completed: %v", stage)
+	}
+	dsList := make([]*share.PubShare, 2)
+	for i := range dsList {
+		v, _, err := elgamal.CreateDecShare(P256, nil, *aggCt, privKeys[i])
+		if err != nil {
+			t.Fatalf("can't create decryption share: %v", err)
+		}
+		dsList[i] = &share.PubShare{I: i, V: v}
+	}
+	expected, err2 := decryptResult(*aggCt, dsList, 2, n)
+	if err2 != nil {
+		t.Fatalf("can't decrypt: %v", err2)
+	}
+	if result, err := keeper.RandomResult(ctx, 0); err != nil || !bytes.Equal(result, expected) {
+		t.Errorf("wrong result: %X, expected %X, %v", result, expected, err)
+	}
+}
+
+func TestProofContext(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	y := P256.Scalar().Pick(P256.RandomStream())
+	r := P256.Scalar().Pick(P256.RandomStream())
+	ct, ceProof, err := createCiphertext(P256, keeper.proofContext(ctx, elgamal.ContextCE, 0, userAddrs[0]), commonKey, y, r)
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	if err := keeper.SetCiphertext(ctx, 0, &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[0]}); err != nil {
+		t.Fatalf("ciphertext share isn't accepted: %v", err)
+	}
+
+	// the share copied by another provider doesn't pass the proof
+	if err := keeper.SetCiphertext(ctx, 0, &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[1]}); err == nil {
+		t.Errorf("copied ciphertext share is accepted")
+	}
+	// the same ciphertext is rejected even with the correct proof
+	_, ceProof, err = createCiphertext(P256, keeper.proofContext(ctx, elgamal.ContextCE, 0, userAddrs[1]), commonKey, y, r)
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	if err := keeper.SetCiphertext(ctx, 0, &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[1]}); err == nil {
+		t.Errorf("duplicate ciphertext is accepted")
+	}
+	for _, context := range [][]byte{
+		keeper.proofContext(ctx, elgamal.ContextCE, 1, userAddrs[1]),
+		elgamal.ProofContext(elgamal.ContextCE, "other-chain", types.DefaultInstance, 0, userAddrs[1].String()),
+		elgamal.ProofContext(elgamal.ContextCE, ctx.ChainID(), "other", 0, userAddrs[1].String()),
+	} {
+		ct, ceProof, err := createCiphertext(P256, context, commonKey, P256.Scalar().Pick(P256.RandomStream()), r)
+		if err != nil {
+			t.Fatalf("can't create ciphertext: %v", err)
+		}
+		if err := keeper.SetCiphertext(ctx, 0, &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[1]}); err == nil {
+			t.Errorf("ciphertext share with the proof of another context is accepted")
+		}
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	if stage := keeper.GetStage(ctx, 0); stage != stageDSCollecting {
+		t.Fatalf("round doesn't collect decryption shares: %v", stage)
+	}
+
+	aggCt, err2 := keeper.GetAggregatedCiphertext(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get aggregated ciphertext: %v", err2)
+	}
+	for _, context := range [][]byte{
+		keeper.proofContext(ctx, elgamal.ContextDLEQ, 1, userAddrs[0]),
+		keeper.proofContext(ctx, elgamal.ContextCE, 0, userAddrs[0]),
+		elgamal.ProofContext(elgamal.ContextDLEQ, "other-chain", types.DefaultInstance, 0, userAddrs[0].String()),
+	} {
+		ds, dleq, err := elgamal.CreateDecShare(P256, context, *aggCt, privKeys[0])
+		if err != nil {
+			t.Fatalf("can't create decryption share: %v", err)
+		}
+		decShare := types.DecryptionShare{DecShare: share.PubShare{I: 0, V: ds}, DLEQproof: dleq, KeyHolderAddr: userAddrs[0]}
+		if err := keeper.SetDecryptionShare(ctx, 0, &decShare); err == nil {
+			t.Errorf("decryption share with the proof of another context is accepted")
+		}
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	if stage := keeper.GetStage(ctx, 0); stage != stageCompleted {
+		t.Errorf("round isn't completed: %v", stage)
+	}
+	transcript, err2 := keeper.GetRoundTranscript(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get transcript: %v", err2)
+	}
+	if err := transcript.Verify(); err != nil {
+		t.Errorf("transcript isn't verified: %v", err)
+	}
+}
+
+func TestAnteHandler_WrongRound(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	passed := false
+	anteHandler := NewAnteHandler(keeper, func(ctx sdk.Context, tx sdk.Tx, simulate bool) (sdk.Context, sdk.Result, bool) {
+		passed = true
+		return ctx, sdk.Result{}, false
+	})
+	checkRound := func(ctx sdk.Context, msg sdk.Msg) sdk.CodeType {
+		passed = false
+		_, res, abort := anteHandler(ctx, auth.StdTx{Msgs: []sdk.Msg{msg}}, false)
+		if abort == passed {
+			t.Fatalf("ante handler both aborted and passed the tx: %v", res.Log)
+		}
+		return res.Code
+	}
+	ctMsg := func(round uint64, sender sdk.AccAddress) types.MsgSetCiphertextShare {
+		context := keeper.proofContext(ctx, elgamal.ContextCE, round, sender)
+		ct, ceProof, err := createCiphertext(P256, context, commonKey, P256.Scalar().Pick(P256.RandomStream()), P256.Scalar().Pick(P256.RandomStream()))
+		if err != nil {
+			t.Fatalf("can't create ciphertext: %v", err)
+		}
+		ctBytes, err := types.EncodeCiphertext(&ct)
+		if err != nil {
+			t.Fatalf("can't encode ciphertext: %v", err)
+		}
+		return types.NewMsgSetCiphertextShare(types.DefaultInstance, round, ctBytes, ceProof, sender)
+	}
+
+	checkCtx := ctx.WithIsCheckTx(true)
+	deliverCtx := ctx.WithIsCheckTx(false)
+	if code := checkRound(checkCtx, ctMsg(1, userAddrs[0])); code != types.CodeWrongRound {
+		t.Errorf("share for the unstarted round isn't rejected in CheckTx: %v", code)
+	}
+	if code := checkRound(checkCtx, ctMsg(0, userAddrs[0])); code != sdk.CodeOK || !passed {
+		t.Errorf("share for the collecting round is rejected: %v", code)
+	}
+	msg := ctMsg(0, userAddrs[0])
+	msg.Instance = "unknown"
+	if code := checkRound(checkCtx, msg); code != types.CodeUnknownInstance {
+		t.Errorf("share for the unknown instance isn't rejected: %v", code)
+	}
+	// the handler reports the wrong round with the same code
+	if code := checkRound(deliverCtx, ctMsg(1, userAddrs[0])); code != sdk.CodeOK || !passed {
+		t.Errorf("round is checked by the ante handler in DeliverTx: %v", code)
+	}
+	if res := NewHandler(keeper)(deliverCtx, ctMsg(1, userAddrs[0])); res.Code != types.CodeWrongRound {
+		t.Errorf("share for the wrong round isn't rejected by the handler: %v", res.Log)
+	}
+
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	if code := checkRound(checkCtx, newTestDecryptionShareMsg(t, ctx, &keeper, privKeys[0], userAddrs[0])); code != types.CodeWrongRound {
+		t.Errorf("decryption share for the round collecting ciphertexts isn't rejected: %v", code)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	if code := checkRound(checkCtx, ctMsg(0, userAddrs[2])); code != types.CodeWrongRound {
+		t.Errorf("ciphertext share for the decrypting round isn't rejected: %v", code)
+	}
+	dsMsg := newTestDecryptionShareMsg(t, ctx, &keeper, privKeys[0], userAddrs[0])
+	if code := checkRound(checkCtx, dsMsg); code != sdk.CodeOK || !passed {
+		t.Errorf("decryption share for the decrypting round is rejected: %v", code)
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	// the share left in the mempool is dropped on the recheck after the round is completed
+	if code := checkRound(checkCtx, dsMsg); code != types.CodeWrongRound {
+		t.Errorf("decryption share for the completed round isn't rejected: %v", code)
+	}
+}
+
+func TestSlashing_InvalidShare(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 1, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	sk := keeper.stakingKeeper.(*mockStakingKeeper)
+	sk.addValidator(userAddrs[0])
+	sk.addSigningInfo(ctx, &keeper, userAddrs[0])
+	ctx = ctx.WithBlockTime(time.Unix(1000, 0))
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+
+	aggCt, err2 := keeper.GetAggregatedCiphertext(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get aggregated ciphertext: %v", err2)
+	}
+	// share is computed with the key of another key holder
+	ds, dleq, err := elgamal.CreateDecShare(P256, keeper.proofContext(ctx, elgamal.ContextDLEQ, 0, userAddrs[0]), *aggCt, privKeys[1])
+	if err != nil {
+		t.Fatalf("can't create decryption share: %v", err)
+	}
+	decShare := types.DecryptionShare{DecShare: share.PubShare{I: 0, V: ds}, DLEQproof: dleq, KeyHolderAddr: userAddrs[0]}
+	dsBytes, err2 := types.EncodeDecSharePoint(ds)
+	if err2 != nil {
+		t.Fatalf("can't serialize decryption share: %v", err2)
+	}
+	proofBytes, err2 := types.EncodeDLEQProof(dleq)
+	if err2 != nil {
+		t.Fatalf("can't serialize DLEQ proof: %v", err2)
+	}
+	res := NewHandler(keeper)(ctx, types.NewMsgSetDecryptionShare(types.DefaultInstance, keeper.CurrentRound(ctx), dsBytes, proofBytes, userAddrs[0]))
+	if !res.IsOK() {
+		t.Fatalf("transaction with invalid share failed, penalty is discarded: %v", res.Log)
+	}
+	if err := keeper.SetDecryptionShare(ctx, keeper.CurrentRound(ctx), &decShare); err == nil || err.Code() != types.CodeInvalidDecryptionShare {
+		t.Errorf("wrong error for invalid share: %v", err)
+	}
+	if len(sk.slashed) != 1 || !sk.fractions[0].Equal(keeper.GetParams(ctx).SlashFractionInvalidShare) {
+		t.Errorf("wrong slashed validators: %v", sk.slashed)
+	}
+	if len(sk.jailed) != 1 {
+		t.Errorf("jailed validator is jailed again: %v", sk.jailed)
+	}
+	if shares, _ := keeper.GetAllDecryptionShares(ctx, 0); len(shares) != 0 {
+		t.Errorf("invalid share is stored")
+	}
+	counters, _ := keeper.GetMissCounters(ctx)
+	if counters[0].InvalidShares != 2 || counters[0].Penalties != 1 || counters[0].InvalidShareRound != 0 {
+		t.Errorf("wrong miss counter: %v", counters[0])
+	}
+
+	// the validator can't unjail itself until the jail duration expires
+	unjail := slashing.NewHandler(keeper.slashingKeeper.(slashing.Keeper))
+	if res := unjail(ctx, slashing.NewMsgUnjail(sdk.ValAddress(userAddrs[0]))); res.IsOK() {
+		t.Errorf("validator is unjailed immediately")
+	}
+	ctx = ctx.WithBlockTime(ctx.BlockHeader().Time.Add(keeper.GetParams(ctx).JailDuration))
+	if res := unjail(ctx, slashing.NewMsgUnjail(sdk.ValAddress(userAddrs[0]))); !res.IsOK() {
+		t.Errorf("validator isn't unjailed after the jail duration: %v", res.Log)
+	}
+}
+
+func TestRewards_CompletedRound(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	params := keeper.GetParams(ctx)
+	params.EntropyProviderReward = sdk.NewCoins(sdk.NewInt64Coin("stake", 5))
+	params.KeyHolderReward = sdk.NewCoins(sdk.NewInt64Coin("stake", 10))
+	params.RewardFeeShare = sdk.NewDecWithPrec(5, 1)
+	keeper.SetParams(ctx, params)
+	bk := keeper.bankKeeper.(*mockBankKeeper)
+	feeCollector := supply.NewModuleAddress(auth.FeeCollectorName)
+	bk.balances[feeCollector.String()] = sdk.NewCoins(sdk.NewInt64Coin("stake", 101))
+
+	EndBlocker(ctx, keeper)
+	if pool := keeper.GetRewardPool(ctx); !pool.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", 50))) {
+		t.Fatalf("wrong rewards pool: %v", pool)
+	}
+	if fees := bk.GetCoins(ctx, feeCollector); !fees.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", 51))) {
+		t.Fatalf("wrong fee collector balance: %v", fees)
+	}
+
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	// entropy providers 0 and 2, key holders 0 and 1
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[2])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	if !bk.GetCoins(ctx, userAddrs[0]).Empty() {
+		t.Errorf("reward is paid before the round is completed")
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	for i, expected := range []int64{15, 10, 5} {
+		if coins := bk.GetCoins(ctx, userAddrs[i]); !coins.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", expected))) {
+			t.Errorf("wrong reward of participant %v: %v", i, coins)
+		}
+	}
+	if pool := keeper.GetRewardPool(ctx); !pool.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", 20))) {
+		t.Fatalf("wrong rewards pool: %v", pool)
+	}
+
+	// the pool can't cover the next round rewards
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[2])
+	setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[1], 1, userAddrs[1])
+	if keeper.GetStage(ctx, 1) != stageCompleted {
+		t.Fatalf("round isn't completed")
+	}
+	if pool := keeper.GetRewardPool(ctx); !pool.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", 20))) {
+		t.Errorf("rewards are paid partially: %v", pool)
+	}
+}
+
+func TestEntropyProviders_Registry(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n + 1)
+	if _, err := setKeyHolders(ctx, &keeper, userAddrs[:n], 2, n); err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	bond := sdk.NewCoins(sdk.NewInt64Coin("stake", 10))
+	params := keeper.GetParams(ctx)
+	params.RestrictedProviders = true
+	params.MaxEntropyProviders = 2
+	params.EntropyProviderBond = bond
+	keeper.SetParams(ctx, params)
+	bk := keeper.bankKeeper.(*mockBankKeeper)
+	for _, addr := range userAddrs {
+		bk.balances[addr.String()] = sdk.NewCoins(sdk.NewInt64Coin("stake", 15))
+	}
+
+	commonKey, err := keeper.GetCommonPublicKey(ctx)
+	if err != nil {
+		t.Fatalf("can't get common key: %v", err)
+	}
+	context := keeper.proofContext(ctx, elgamal.ContextCE, keeper.CurrentRound(ctx), userAddrs[0])
+	ct, ceProof, err2 := createCiphertext(P256, context, commonKey, P256.Scalar().Pick(P256.RandomStream()), P256.Scalar().Pick(P256.RandomStream()))
+	if err2 != nil {
+		t.Fatalf("can't create ciphertext: %v", err2)
+	}
+	if err := keeper.SetCiphertext(ctx, keeper.CurrentRound(ctx), &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[0]}); err == nil {
+		t.Fatalf("unregistered entropy provider's ciphertext is accepted")
+	}
+
+	for _, addr := range userAddrs[:2] {
+		if err := keeper.RegisterEntropyProvider(ctx, addr); err != nil {
+			t.Fatalf("can't register entropy provider: %v", err)
+		}
+	}
+	if err := keeper.RegisterEntropyProvider(ctx, userAddrs[0]); err == nil {
+		t.Errorf("entropy provider is registered twice")
+	}
+	if err := keeper.RegisterEntropyProvider(ctx, userAddrs[2]); err == nil {
+		t.Errorf("entropy provider is registered over the max size")
+	}
+	if coins := bk.GetCoins(ctx, userAddrs[0]); !coins.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", 5))) {
+		t.Errorf("bond isn't taken: %v", coins)
+	}
+	if pool := bk.GetCoins(ctx, supply.NewModuleAddress(types.ProviderBondPoolName)); !pool.IsEqual(bond.Add(bond)) {
+		t.Errorf("wrong bonds pool: %v", pool)
+	}
+	providers, err := keeper.GetEntropyProviders(ctx)
+	if err != nil {
+		t.Fatalf("can't get entropy providers: %v", err)
+	}
+	if len(providers) != 2 {
+		t.Errorf("wrong number of entropy providers: %v", len(providers))
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+
+	if err := keeper.DeregisterEntropyProvider(ctx, userAddrs[0]); err != nil {
+		t.Fatalf("can't deregister entropy provider: %v", err)
+	}
+	if coins := bk.GetCoins(ctx, userAddrs[0]); !coins.IsEqual(sdk.NewCoins(sdk.NewInt64Coin("stake", 15))) {
+		t.Errorf("bond isn't returned: %v", coins)
+	}
+	if err := keeper.DeregisterEntropyProvider(ctx, userAddrs[0]); err == nil {
+		t.Errorf("unregistered entropy provider is deregistered")
+	}
+	if err := keeper.SetCiphertext(ctx, keeper.CurrentRound(ctx), &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: userAddrs[0]}); err == nil {
+		t.Errorf("deregistered entropy provider's ciphertext is accepted")
+	}
+	if err := keeper.RegisterEntropyProvider(ctx, userAddrs[3]); err != nil {
+		t.Errorf("can't register entropy provider after deregistration: %v", err)
+	}
+}
+
+func TestHooks_RoundStages(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	hooks1, hooks2 := &mockHerbHooks{}, &mockHerbHooks{}
+	keeper.SetHooks(hooks1, hooks2)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	result, err2 := keeper.RandomResult(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get random result: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	keeper.AbortRound(ctx, keeper.CurrentRound(ctx), "test")
+
+	for _, hooks := range []*mockHerbHooks{hooks1, hooks2} {
+		if len(hooks.ctClosed) != 2 || hooks.ctClosed[0] != 0 || hooks.ctClosed[1] != 1 {
+			t.Errorf("wrong ciphertext stage closed calls: %v", hooks.ctClosed)
+		}
+		if len(hooks.completed) != 1 || hooks.completed[0] != 0 || !bytes.Equal(hooks.results[0], result) {
+			t.Errorf("wrong round completed calls: %v", hooks.completed)
+		}
+		if len(hooks.failed) != 1 || hooks.failed[0] != 1 {
+			t.Errorf("wrong round failed calls: %v", hooks.failed)
+		}
+		for _, instance := range hooks.instances {
+			if instance != types.DefaultInstance {
+				t.Errorf("wrong instance passed to the hooks: %q", instance)
+			}
+		}
+	}
+}
+
+func TestHooks_PanicFailsMessage(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.SetHooks(&mockHerbHooks{panicOnCompleted: true})
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+
+	// the message state is dropped on error as the cache context of the transaction
+	cacheCtx, _ := ctx.CacheContext()
+	msg := newTestDecryptionShareMsg(t, cacheCtx, &keeper, privKeys[1], userAddrs[1])
+	if res := NewHandler(keeper)(cacheCtx, msg); res.IsOK() {
+		t.Fatalf("message is accepted though the hook panicked")
+	}
+	if stage := keeper.GetStage(ctx, 0); stage != stageDSCollecting {
+		t.Errorf("round is completed though the hook panicked: %v", stage)
+	}
+	if res := NewHandler(keeper)(ctx, msg); res.IsOK() {
+		t.Errorf("message is accepted though the hook panicked")
+	}
+}
+
+func TestQuerier_Result(t *testing.T) {
+	n := 3
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	queryResult := func(round int64) ([]byte, sdk.Error) {
+		resBytes, err := NewQuerier(keeper)(ctx, []string{types.QueryResult}, abci.RequestQuery{Data: cdc.MustMarshalJSON(types.NewQueryByRound(round))})
+		if err != nil {
+			return nil, err
+		}
+		var res types.QueryResultRes
+		cdc.MustUnmarshalJSON(resBytes, &res)
+		return res.Random, nil
+	}
+	if _, err := queryResult(-1); err == nil || err.Code() != types.CodeRoundNotCompleted {
+		t.Errorf("latest result is returned before the first round is completed: %v", err)
+	}
+
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	result0, err2 := keeper.RandomResult(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get result: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+
+	// the explicit round isn't replaced by the earlier one while it's decrypted
+	if _, err := queryResult(1); err == nil || err.Code() != types.CodeRoundNotCompleted {
+		t.Errorf("result of the round in progress is returned: %v", err)
+	}
+	for _, round := range []int64{-1, 0} {
+		if result, err := queryResult(round); err != nil || !bytes.Equal(result, result0) {
+			t.Errorf("wrong result of round %v: %x, %v", round, result, err)
+		}
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[1], 1, userAddrs[1])
+	result1, err2 := keeper.RandomResult(ctx, 1)
+	if err2 != nil {
+		t.Fatalf("can't get result: %v", err2)
+	}
+	for _, round := range []int64{-1, 1} {
+		if result, err := queryResult(round); err != nil || !bytes.Equal(result, result1) {
+			t.Errorf("wrong result of round %v: %x, %v", round, result, err)
+		}
+	}
+	if _, err := queryResult(int64(keeper.CurrentRound(ctx))); err == nil || err.Code() != types.CodeRoundNotCompleted {
+		t.Errorf("result of the current round is returned: %v", err)
+	}
+}
+
+func TestEvents_Round(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	result, err2 := keeper.RandomResult(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get random result: %v", err2)
+	}
+
+	var accepted, stages, results []sdk.Event
+	for _, event := range ctx.EventManager().Events() {
+		switch event.Type {
+		case types.EventTypeShareAccepted:
+			accepted = append(accepted, event)
+		case types.EventTypeStageChanged:
+			stages = append(stages, event)
+		case types.EventTypeRandomResult:
+			results = append(results, event)
+		}
+	}
+	if len(accepted) != 4 {
+		t.Fatalf("wrong number of share accepted events: %v", len(accepted))
+	}
+	if attr := eventAttribute(accepted[1], types.AttributeKeyShareCount); attr != "2" {
+		t.Errorf("wrong share count: %v", attr)
+	}
+	if attr := eventAttribute(accepted[2], types.AttributeKeySender); attr != userAddrs[0].String() {
+		t.Errorf("wrong share sender: %v", attr)
+	}
+	expectedStages := [][2]string{
+		{stageUnstarted, stageCtCollecting},
+		{stageCtCollecting, stageDSCollecting},
+		{stageDSCollecting, stageCompleted},
+		{stageUnstarted, stageCtCollecting},
+	}
+	if len(stages) != len(expectedStages) {
+		t.Fatalf("wrong number of stage changed events: %v", len(stages))
+	}
+	for i, expected := range expectedStages {
+		if eventAttribute(stages[i], types.AttributeKeyOldStage) != expected[0] || eventAttribute(stages[i], types.AttributeKeyNewStage) != expected[1] {
+			t.Errorf("wrong stage changed event %v: %v", i, stages[i])
+		}
+	}
+	if len(results) != 1 || eventAttribute(results[0], types.AttributeKeyResult) != fmt.Sprintf("%x", result) {
+		t.Errorf("wrong random result events: %v", results)
+	}
+}
+
+func TestEvents_KeysAndProviders(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	if _, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n); err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	// every transaction has its own event manager
+	handler := func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		return NewHandler(keeper)(ctx.WithEventManager(sdk.NewEventManager()), msg)
+	}
+	eventsOf := func(res sdk.Result, eventType string) []sdk.Event {
+		var events []sdk.Event
+		for _, event := range res.Events {
+			if event.Type == eventType {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	res := handler(ctx, types.NewMsgRegisterEntropyProvider(types.DefaultInstance, userAddrs[0]))
+	if !res.IsOK() {
+		t.Fatalf("can't register entropy provider: %v", res.Log)
+	}
+	if events := eventsOf(res, types.EventTypeProviderRegistered); len(events) != 1 || eventAttribute(events[0], types.AttributeKeySender) != userAddrs[0].String() {
+		t.Errorf("wrong provider registered events: %v", events)
+	}
+	if events := eventsOf(res, sdk.EventTypeMessage); len(events) != 1 {
+		t.Errorf("wrong message events: %v", events)
+	}
+
+	for i := range userAddrs {
+		encKey, _ := kyberenc.PointToStringHex(P256, P256.Point().Pick(P256.RandomStream()))
+		res = handler(ctx, types.NewMsgSetEncryptionKey(types.DefaultInstance, encKey, userAddrs[i]))
+		if !res.IsOK() {
+			t.Fatalf("can't set encryption key: %v", res.Log)
+		}
+	}
+	events := eventsOf(res, types.EventTypeRefreshMessage)
+	if len(events) != 1 || eventAttribute(events[0], types.AttributeKeyMessageType) != "setEncryptionKey" || eventAttribute(events[0], types.AttributeKeySender) != userAddrs[n-1].String() {
+		t.Errorf("wrong refresh message events: %v", events)
+	}
+	res = handler(ctx, types.NewMsgStartRefresh(types.DefaultInstance, userAddrs[0]))
+	if !res.IsOK() {
+		t.Fatalf("can't start refresh: %v", res.Log)
+	}
+	events = eventsOf(res, types.EventTypeRefreshPhaseChanged)
+	if len(events) != 1 || eventAttribute(events[0], types.AttributeKeyNewPhase) != types.RefreshPhaseDeals || eventAttribute(events[0], types.AttributeKeyRefreshNumber) != "1" {
+		t.Errorf("wrong refresh phase changed events: %v", events)
+	}
+	if events := eventsOf(res, types.EventTypeRefreshMessage); len(events) != 1 || eventAttribute(events[0], types.AttributeKeyPhase) != types.RefreshPhaseDeals {
+		t.Errorf("wrong refresh message events: %v", events)
+	}
+	if res := handler(ctx, types.NewMsgStartRefresh(types.DefaultInstance, userAddrs[0])); res.IsOK() || len(res.Events) > 0 {
+		t.Errorf("rejected message has events: %v", res.Events)
+	}
+
+	ctx, keeper, _ = Initialize(2, 2, uint64(n))
+	participants := make([]types.DKGParticipantJSON, n)
+	for i := range participants {
+		p, err := types.NewDKGParticipantJSON(&types.DKGParticipant{Address: userAddrs[i], PubKey: P256.Point().Pick(P256.RandomStream())})
+		if err != nil {
+			t.Fatalf("can't serialize participant: %v", err)
+		}
+		participants[i] = p
+	}
+	if err := keeper.StartDKG(ctx, participants, 2); err != nil {
+		t.Fatalf("can't start DKG: %v", err)
+	}
+	for _, event := range ctx.EventManager().Events() {
+		if event.Type == types.EventTypeDKGPhaseChanged && eventAttribute(event, types.AttributeKeyOldPhase) == types.DKGPhaseNone &&
+			eventAttribute(event, types.AttributeKeyNewPhase) == types.DKGPhaseDeals {
+			return
+		}
+	}
+	t.Errorf("DKG phase changed event isn't emitted")
+}
+
+func eventAttribute(event sdk.Event, key string) string {
+	for _, attr := range event.Attributes {
+		if string(attr.Key) == key {
+			return string(attr.Value)
+		}
+	}
+	return ""
+}
+
+func TestRandomness_CompletedRoundsOnly(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	var randomnessKeeper types.RandomnessKeeper = &keeper
+	if _, err := randomnessKeeper.LatestCompletedRound(ctx); err == nil || err.Code() != types.CodeRoundNotCompleted {
+		t.Errorf("latest completed round before the first result: %v", err)
+	}
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	if _, err := randomnessKeeper.GetRandomness(ctx, "test", "draw", 0); err == nil || err.Code() != types.CodeRoundNotCompleted {
+		t.Errorf("randomness of the incomplete round: %v", err)
+	}
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+
+	round, err2 := randomnessKeeper.LatestCompletedRound(ctx)
+	if err2 != nil || round != 0 {
+		t.Fatalf("wrong latest completed round %v: %v", round, err2)
+	}
+	draw, err2 := randomnessKeeper.GetRandomness(ctx, "test", "draw", 0)
+	if err2 != nil {
+		t.Fatalf("can't get randomness: %v", err2)
+	}
+	shuffle, err2 := randomnessKeeper.GetRandomness(ctx, "test", "shuffle", 0)
+	if err2 != nil {
+		t.Fatalf("can't get randomness: %v", err2)
+	}
+	result, _ := keeper.RandomResult(ctx, 0)
+	if bytes.Equal(draw, shuffle) || bytes.Equal(draw, result) {
+		t.Errorf("randomness isn't separated by purpose")
+	}
+	if _, err := randomnessKeeper.GetRandomness(ctx, "test", "draw", 1); err == nil || err.Code() != types.CodeRoundNotCompleted {
+		t.Errorf("randomness of the started round: %v", err)
+	}
+}
+
+func TestRoundTranscript_Verify(t *testing.T) {
+	n := 3
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[2], 2, userAddrs[2])
+
+	transcript, err2 := keeper.GetRoundTranscript(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get round transcript: %v", err2)
+	}
+	// transcript is verified after the JSON round trip, as the client gets it
+	var decoded types.RoundTranscript
+	cdc.MustUnmarshalJSON(cdc.MustMarshalJSON(transcript), &decoded)
+	if err := decoded.Verify(); err != nil {
+		t.Fatalf("correct round isn't verified: %v", err)
+	}
+
+	tampered := decoded
+	tampered.Result = append([]byte{}, decoded.Result...)
+	tampered.Result[0] ^= 1
+	if err := tampered.Verify(); err == nil {
+		t.Errorf("wrong result is verified")
+	}
+	tampered = decoded
+	tampered.Ciphertexts = decoded.Ciphertexts[:1]
+	if err := tampered.Verify(); err == nil {
+		t.Errorf("transcript without ciphertext share is verified")
+	}
+	tampered = decoded
+	tampered.DecryptionShares = []types.DecryptionShareJSON{decoded.DecryptionShares[1], decoded.DecryptionShares[0]}
+	tampered.DecryptionShares[0].KeyHolderAddr = decoded.DecryptionShares[0].KeyHolderAddr
+	if err := tampered.Verify(); err == nil {
+		t.Errorf("decryption share of the other key holder is verified")
+	}
+
+	transcript, err2 = keeper.GetRoundTranscript(ctx, 1)
+	if err2 != nil {
+		t.Fatalf("can't get round transcript: %v", err2)
+	}
+	if err := transcript.Verify(); err == nil {
+		t.Errorf("round without result is verified")
+	}
+}
+
+func TestPruning_OldRounds(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	params := keeper.GetParams(ctx)
+	params.RoundsRetention = 1
+	keeper.SetParams(ctx, params)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	for round := uint64(0); round < 2; round++ {
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+		setTestDecryptionShare(t, ctx, &keeper, round, privKeys[0], 0, userAddrs[0])
+		setTestDecryptionShare(t, ctx, &keeper, round, privKeys[1], 1, userAddrs[1])
+	}
+	expectedHash, err3 := keeper.transcriptHash(ctx, 0)
+	if err3 != nil {
+		t.Fatalf("can't hash transcript: %v", err3)
+	}
+	result, err2 := keeper.RandomResult(ctx, 0)
+	if err2 != nil {
+		t.Fatalf("can't get random result: %v", err2)
+	}
+
+	EndBlocker(ctx, keeper)
+	if _, err := keeper.GetAllCiphertexts(ctx, 0); err == nil || err.Code() != types.CodeRoundPruned {
+		t.Errorf("ciphertexts of the pruned round: %v", err)
+	}
+	if _, err := keeper.GetAllDecryptionShares(ctx, 0); err == nil || err.Code() != types.CodeRoundPruned {
+		t.Errorf("decryption shares of the pruned round: %v", err)
+	}
+	if _, err := keeper.GetRoundTranscript(ctx, 0); err == nil || err.Code() != types.CodeRoundPruned {
+		t.Errorf("transcript of the pruned round: %v", err)
+	}
+	if ctStore := ctx.KVStore(keeper.storeCiphertextSharesKey); ctStore.Has(createKeyBytesByAddr(0, userAddrs[0])) || ctStore.Has(createSharesCountKey(0)) {
+		t.Errorf("ciphertext shares aren't deleted")
+	}
+	if prunedResult, err := keeper.RandomResult(ctx, 0); err != nil || !bytes.Equal(prunedResult, result) {
+		t.Errorf("result of the pruned round isn't kept: %v", err)
+	}
+	if hash, err := keeper.GetTranscriptHash(ctx, 0); err != nil || !bytes.Equal(hash, expectedHash) {
+		t.Errorf("wrong transcript hash of the pruned round: %v", err)
+	}
+	if transcript, err := keeper.GetRoundTranscript(ctx, 1); err != nil || transcript.Verify() != nil {
+		t.Errorf("round in the retention window is pruned: %v", err)
+	}
+}
+
+// writeBaselineStore writes the store as the version 0 keeper did: completed round 0 and round 1 collecting ciphertext shares,
+// keys and values are built by the version 0 encoding, proofs aren't bound to the proof context.
+// Params, key epochs and round chain IDs didn't exist in the version 0 store
+func writeBaselineStore(t *testing.T, ctx sdk.Context, k *Keeper, addrs []sdk.AccAddress, trh int) (commonKey kyber.Point, privKeys []kyber.Scalar, result []byte) {
+	n := len(addrs)
+	store := ctx.KVStore(k.storeKey)
+	ctStore := ctx.KVStore(k.storeCiphertextSharesKey)
+	dsStore := ctx.KVStore(k.storeDecryptionSharesKey)
+	decShares, verKeys, err := dkg.RabinDKGSimulator(P256.String(), n, trh)
+	if err != nil {
+		t.Fatalf("can't generate keys: %v", err)
+	}
+	commonKey = decShares[0].Public()
+	for _, decShare := range decShares {
+		privKeys = append(privKeys, decShare.PriShare().V)
+	}
+	commonKeyStr, err := kyberenc.PointToStringHex(P256, commonKey)
+	if err != nil {
+		t.Fatalf("can't serialize common key: %v", err)
+	}
+	store.Set([]byte(keyCommonKey), []byte(commonKeyStr))
+	vkList := make([]*types.VerificationKey, n)
+	for i := range addrs {
+		vkList[i] = &types.VerificationKey{Key: *verKeys[i], KeyHolderID: i, Sender: addrs[i]}
+	}
+	vkJSONList, err2 := types.VerificationKeyArraySerialize(vkList)
+	if err2 != nil {
+		t.Fatalf("can't serialize verification keys: %v", err2)
+	}
+	store.Set([]byte(keyVerificationKeys), k.cdc.MustMarshalJSON(vkJSONList))
+	uint64Bytes := func(v uint64) []byte {
+		bz := make([]byte, 8)
+		binary.LittleEndian.PutUint64(bz, v)
+		return bz
+	}
+	store.Set([]byte(keyKeyHoldersNumber), uint64Bytes(uint64(n)))
+	store.Set([]byte(keyThresholdCiphertexts), uint64Bytes(2))
+	store.Set([]byte(keyThresholdDecrypt), uint64Bytes(uint64(trh)))
+	store.Set([]byte(keyCurrentRound), uint64Bytes(1))
+
+	setCiphertexts := func(round uint64, senders []sdk.AccAddress) elgamal.Ciphertext {
+		var cts []elgamal.Ciphertext
+		var addrList []string
+		for _, sender := range senders {
+			ct, ceProof, err := createCiphertext(P256, []byte("CE"), commonKey, P256.Scalar().Pick(P256.RandomStream()), P256.Scalar().Pick(P256.RandomStream()))
+			if err != nil {
+				t.Fatalf("can't create ciphertext: %v", err)
+			}
+			ctJSON, err2 := types.NewCiphertextShareJSON(&types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: sender})
+			if err2 != nil {
+				t.Fatalf("can't serialize ciphertext share: %v", err2)
+			}
+			ctStore.Set(legacyKeyBytesByAddr(round, sender), k.cdc.MustMarshalJSON(ctJSON))
+			cts = append(cts, ct)
+			addrList = append(addrList, sender.String())
+		}
+		ctStore.Set(legacySharesListKey(round), k.cdc.MustMarshalJSON(addrList))
+		aggCt := elgamal.AggregateCiphertext(P256, cts)
+		aggJSON, err := elgamal.NewCiphertextJSON(&aggCt, P256)
+		if err != nil {
+			t.Fatalf("can't serialize aggregated ciphertext: %v", err)
+		}
+		store.Set(legacyKeyBytesByRound(round, keyAggregatedCiphertext), k.cdc.MustMarshalJSON(aggJSON))
+		return aggCt
+	}
+
+	aggCt := setCiphertexts(0, addrs[:2])
+	var dsList []*share.PubShare
+	var addrList []string
+	for i := 0; i < trh; i++ {
+		proof, _, ds, err := dleq.NewDLEQProof(P256, P256.Point().Base(), aggCt.PointA, decShares[i].PriShare().V)
+		if err != nil {
+			t.Fatalf("can't create DLEQ proof: %v", err)
+		}
+		decShare := share.PubShare{I: i, V: ds}
+		dsBuf, dleqBuf := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+		if err := gob.NewEncoder(dsBuf).Encode(decShare); err != nil {
+			t.Fatalf("can't encode decryption share: %v", err)
+		}
+		if err := gob.NewEncoder(dleqBuf).Encode(proof); err != nil {
+			t.Fatalf("can't encode DLEQ proof: %v", err)
+		}
+		dsJSON := legacyDecryptionShareJSON{
+			DecShare:      base64.StdEncoding.EncodeToString(dsBuf.Bytes()),
+			DLEQproof:     base64.StdEncoding.EncodeToString(dleqBuf.Bytes()),
+			KeyHolderAddr: addrs[i],
+		}
+		dsStore.Set(legacyKeyBytesByAddr(0, addrs[i]), k.cdc.MustMarshalJSON(dsJSON))
+		dsList = append(dsList, &decShare)
+		addrList = append(addrList, addrs[i].String())
+	}
+	dsStore.Set(legacySharesListKey(0), k.cdc.MustMarshalJSON(addrList))
+	resultPoint, err := elgamal.Decrypt(P256, aggCt, dsList, trh, n)
+	if err != nil {
+		t.Fatalf("can't decrypt result: %v", err)
+	}
+	hash := P256.Hash()
+	if _, err := resultPoint.MarshalTo(hash); err != nil {
+		t.Fatalf("can't hash result: %v", err)
+	}
+	result = hash.Sum(nil)
+	store.Set(legacyKeyBytesByRound(0, keyRandomResult), result)
+	store.Set(legacyKeyBytesByRound(0, keyStage), []byte(stageCompleted))
+
+	setCiphertexts(1, addrs[2:])
+	store.Set(legacyKeyBytesByRound(1, keyStage), []byte(stageCtCollecting))
+	return commonKey, privKeys, result
+}
+
+func TestMigration_BinaryKeys(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := newTestKeeper()
+	userAddrs := createTestAddrs(n)
+	commonKey, privKeys, result := writeBaselineStore(t, ctx, &keeper, userAddrs, 2)
+	if keeper.GetStage(ctx, 0) != stageUnstarted {
+		t.Fatalf("store isn't written in the legacy layout")
+	}
+
+	BeginBlocker(ctx, keeper)
+	if keeper.getStoreVersion(ctx) != storeVersion {
+		t.Errorf("store version isn't updated")
+	}
+	if params := keeper.GetParams(ctx); params.String() != types.DefaultParams().String() {
+		t.Errorf("params aren't set to the defaults: %v", params)
+	}
+	epoch, err := keeper.GetKeyEpoch(ctx, 0)
+	if err != nil {
+		t.Fatalf("key epoch 0 isn't created: %v", err)
+	}
+	if epochKey, _ := kyberenc.StringHexToPoint(P256, epoch.CommonPublicKey); epochKey == nil || !epochKey.Equal(commonKey) ||
+		len(epoch.KeyHolders) != n || epoch.ThresholdCiphertexts != 2 || epoch.ThresholdDecryption != 2 {
+		t.Errorf("wrong key epoch 0: %v", epoch)
+	}
+	for round := uint64(0); round <= 1; round++ {
+		if roundEpoch, err := keeper.GetRoundEpoch(ctx, round); err != nil || roundEpoch.Number != 0 {
+			t.Errorf("wrong key epoch of round %v: %v, %v", round, roundEpoch, err)
+		}
+		if chainID := keeper.store(ctx).Get(createKeyBytesByRound(round, keyRoundChainID)); string(chainID) != ctx.ChainID() {
+			t.Errorf("wrong chain ID of round %v: %s", round, chainID)
+		}
+	}
+	transcript, err := keeper.GetRoundTranscript(ctx, 0)
+	if err != nil {
+		t.Fatalf("can't get transcript of the migrated round: %v", err)
+	}
+	if len(transcript.Ciphertexts) != 2 || len(transcript.DecryptionShares) != 2 {
+		t.Errorf("wrong number of the migrated shares: %v, %v", len(transcript.Ciphertexts), len(transcript.DecryptionShares))
+	}
+	if migrated, _ := keeper.RandomResult(ctx, 0); !bytes.Equal(migrated, result) {
+		t.Errorf("wrong result of the migrated round")
+	}
+	if ctStore := ctx.KVStore(keeper.storeCiphertextSharesKey); ctStore.Has(legacySharesListKey(0)) || ctStore.Has(legacyKeyBytesByAddr(0, userAddrs[0])) {
+		t.Errorf("legacy keys aren't deleted")
+	}
+
+	// the in-progress round continues with the migrated shares number
+	if keeper.GetStage(ctx, 1) != stageCtCollecting {
+		t.Fatalf("wrong stage of the migrated round: %v", keeper.GetStage(ctx, 1))
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	if keeper.GetStage(ctx, 1) != stageDSCollecting {
+		t.Errorf("migrated shares aren't counted")
+	}
+
+	// the migrated rounds are pruned and exported
+	for i := 0; i < 2; i++ {
+		setTestDecryptionShare(t, ctx, &keeper, 1, privKeys[i], i, userAddrs[i])
+	}
+	if keeper.GetStage(ctx, 1) != stageCompleted {
+		t.Fatalf("migrated round isn't completed")
+	}
+	params := keeper.GetParams(ctx)
+	params.RoundsRetention = 1
+	keeper.SetParams(ctx, params)
+	EndBlocker(ctx, keeper)
+	if !keeper.isRoundPruned(ctx, 0) {
+		t.Errorf("migrated round isn't pruned")
+	}
+	if rounds := ExportGenesis(ctx, keeper).RoundData; len(rounds) != 3 || rounds[0].TranscriptHash == nil || rounds[1].ChainID != ctx.ChainID() {
+		t.Errorf("wrong exported rounds: %v", rounds)
+	}
+}
+
+func TestVerificationKeys_Restart(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(3, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 3, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	for round := uint64(0); round < 2; round++ {
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+		if round == 0 {
+			for i := 0; i < n; i++ {
+				setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[i], i, userAddrs[i])
+			}
+		}
+	}
+	if keeper.CurrentRound(ctx) != 1 || keeper.GetStage(ctx, 1) != stageDSCollecting {
+		t.Fatalf("wrong round: %v, %v", keeper.CurrentRound(ctx), keeper.GetStage(ctx, 1))
+	}
+
+	// node restarted at round 1 has the empty cache, gas is the same as with the filled one
+	restarted := keeper
+	restarted.vkCache = newVKCache()
+	coldCtx, _ := ctx.CacheContext()
+	coldCtx = coldCtx.WithGasMeter(sdk.NewGasMeter(1000000))
+	setTestDecryptionShare(t, coldCtx, &restarted, 1, privKeys[0], 0, userAddrs[0])
+	warmCtx, _ := ctx.CacheContext()
+	warmCtx = warmCtx.WithGasMeter(sdk.NewGasMeter(1000000))
+	setTestDecryptionShare(t, warmCtx, &keeper, 1, privKeys[0], 0, userAddrs[0])
+	if coldCtx.GasMeter().GasConsumed() != warmCtx.GasMeter().GasConsumed() {
+		t.Errorf("gas depends on the cache: %v, %v", coldCtx.GasMeter().GasConsumed(), warmCtx.GasMeter().GasConsumed())
+	}
+
+	// keys changed in a branch of the state, the cache is shared by the keeper copies
+	vk, err2 := keeper.GetVerificationKey(ctx, userAddrs[1])
+	if err2 != nil {
+		t.Fatalf("can't get verification key: %v", err2)
+	}
+	branchCtx, _ := ctx.CacheContext()
+	newKey := &types.VerificationKey{Key: P256.Point().Pick(P256.RandomStream()), KeyHolderID: 1, Sender: userAddrs[1]}
+	vkJSONList, err2 := types.VerificationKeyArraySerialize([]*types.VerificationKey{newKey})
+	if err2 != nil {
+		t.Fatalf("can't serialize verification keys: %v", err2)
+	}
+	keeper.setVerificationKeysBytes(branchCtx, keeper.cdc.MustMarshalJSON(vkJSONList))
+	if branchVK, _ := restarted.GetVerificationKey(branchCtx, userAddrs[1]); branchVK == nil || !branchVK.Key.Equal(newKey.Key) {
+		t.Errorf("new verification key isn't found")
+	}
+	if _, err := keeper.GetVerificationKey(branchCtx, userAddrs[0]); err == nil {
+		t.Errorf("removed verification key is found")
+	}
+
+	// the original state is used again as after LoadHeight
+	if oldVK, _ := keeper.GetVerificationKey(ctx, userAddrs[1]); oldVK == nil || !oldVK.Key.Equal(vk.Key) {
+		t.Errorf("stale verification key is used")
+	}
+	res := NewHandler(restarted)(ctx, newTestDecryptionShareMsg(t, ctx, &keeper, privKeys[1], userAddrs[1]))
+	if !res.IsOK() || res.Log != "" {
+		t.Errorf("decryption share is rejected after restart: %v", res.Log)
+	}
+	if shares, _ := keeper.GetAllDecryptionShares(ctx, 1); len(shares) != 1 {
+		t.Errorf("wrong number of decryption shares: %v", len(shares))
+	}
+}
+
+// newTestDecryptionShareMsg creates the decryption share message for the current round
+func newTestDecryptionShareMsg(t *testing.T, ctx sdk.Context, k *Keeper, privKey kyber.Scalar, sender sdk.AccAddress) types.MsgSetDecryptionShare {
+	aggCt, err := k.GetAggregatedCiphertext(ctx, k.CurrentRound(ctx))
+	if err != nil {
+		t.Fatalf("can't get aggregated ciphertext: %v", err)
+	}
+	context := k.proofContext(ctx, elgamal.ContextDLEQ, k.CurrentRound(ctx), sender)
+	ds, dleq, err2 := elgamal.CreateDecShare(P256, context, *aggCt, privKey)
+	if err2 != nil {
+		t.Fatalf("can't create decryption share: %v", err2)
+	}
+	dsBytes, err := types.EncodeDecSharePoint(ds)
+	if err != nil {
+		t.Fatalf("can't encode decryption share: %v", err)
+	}
+	proofBytes, err := types.EncodeDLEQProof(dleq)
+	if err != nil {
+		t.Fatalf("can't encode DLEQ proof: %v", err)
+	}
+	return types.NewMsgSetDecryptionShare(types.DefaultInstance, k.CurrentRound(ctx), dsBytes, proofBytes, sender)
+}
+
+func TestInvariants(t *testing.T) {
+	n := 3
+	ctx, keeper, _ := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	if res, broken := AllInvariants(keeper)(ctx); broken {
+		t.Errorf("invariants are broken before round 0: %v", res)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[2])
+	keeper.AbortRound(ctx, keeper.CurrentRound(ctx), "test")
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	if res, broken := AllInvariants(keeper)(ctx); broken {
+		t.Fatalf("invariants are broken: %v", res)
+	}
+
+	// aggregated ciphertext of the open round
+	brokenCtx, _ := ctx.CacheContext()
+	ct, _, err := createCiphertext(P256, nil, commonKey, P256.Scalar().SetInt64(1), P256.Scalar().SetInt64(2))
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	keeper.SetAggregatedCiphertext(brokenCtx, 2, &ct)
+	if _, broken := AggregatedCiphertextInvariant(keeper)(brokenCtx); !broken {
+		t.Errorf("wrong aggregated ciphertext isn't detected")
+	}
+
+	// stages
+	brokenCtx, _ = ctx.CacheContext()
+	// the previous round may collect decryption shares with pipelined rounds, the earlier ones can't
+	keeper.forceRoundStage(brokenCtx, 0, stageDSCollecting)
+	if _, broken := RoundStagesInvariant(keeper)(brokenCtx); !broken {
+		t.Errorf("collecting stage of the old round isn't detected")
+	}
+	brokenCtx, _ = ctx.CacheContext()
+	keeper.forceRoundStage(brokenCtx, 2, stageCompleted)
+	if _, broken := RoundStagesInvariant(keeper)(brokenCtx); !broken {
+		t.Errorf("finished current round isn't detected")
+	}
+
+	// results
+	brokenCtx, _ = ctx.CacheContext()
+	brokenCtx.KVStore(keeper.storeKey).Set(createKeyBytesByRound(0, keyRandomResult), []byte("fake"))
+	if _, broken := RandomResultsInvariant(keeper)(brokenCtx); !broken {
+		t.Errorf("wrong result isn't detected")
+	}
+}
+
+func TestGenesis_ExportImport(t *testing.T) {
 	n := 3
-	trh := 3
-	ctx, keeper, _ := Initialize(uint64(trh), uint64(n), uint64(n))
-	var ciphertextShares []types.CiphertextShare
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
 	userAddrs := createTestAddrs(n)
-	keeper.forceRoundStage(ctx, uint64(round), stageCtCollecting)
-	keeper.forceCurrentRound(ctx, uint64(round))
-	_, err := setKeyHolders(ctx, &keeper, userAddrs, trh, n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
 	if err != nil {
-		t.Errorf("can't set public key %v", err)
+		t.Fatalf("can't set key holders: %v", err)
 	}
-	store := ctx.KVStore(keeper.storeKey)
-	keyCommonBytes := []byte(keyCommonKey)
-	if !store.Has(keyCommonBytes) {
-		t.Errorf("Public key isn't exist")
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
 	}
-	commonkeyBytes := store.Get(keyCommonBytes)
-	commonkeyStr := string(commonkeyBytes)
-	commonkey, err := kyberenc.StringHexToPoint(keeper.group, commonkeyStr)
-	if err != nil {
-		t.Errorf("can't decode common key to point")
+	// rounds 0 and 1 are completed, round 2 is failed, round 3 is collecting decryption shares
+	for round := uint64(0); round < 2; round++ {
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+		setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+		setTestDecryptionShare(t, ctx, &keeper, round, privKeys[0], 0, userAddrs[0])
+		setTestDecryptionShare(t, ctx, &keeper, round, privKeys[1], 1, userAddrs[1])
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[2])
+	keeper.AbortRound(ctx, keeper.CurrentRound(ctx), "test")
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[2])
+	setTestDecryptionShare(t, ctx, &keeper, 3, privKeys[2], 2, userAddrs[2])
+	params := keeper.GetParams(ctx)
+	params.RoundsRetention = 2
+	keeper.SetParams(ctx, params)
+	keeper.pruneRounds(ctx)
+	keeper.setMissCounter(ctx, types.MissCounter{Address: userAddrs[1], WindowStart: 1, MissedRounds: 1, Penalties: 2})
+	if err := keeper.RegisterEntropyProvider(ctx, userAddrs[2]); err != nil {
+		t.Fatalf("can't register entropy provider: %v", err)
+	}
+
+	exported := ExportGenesis(ctx, keeper)
+	exportedBytes := cdc.MustMarshalJSON(exported)
+	var data GenesisState
+	cdc.MustUnmarshalJSON(exportedBytes, &data)
+	if err := ValidateGenesis(data); err != nil {
+		t.Fatalf("exported genesis isn't valid: %v", err)
 	}
+	if data.CurrentRound != 3 || data.PrunedRounds != 1 || len(data.RoundData) != 4 {
+		t.Fatalf("wrong rounds are exported: %v, %v, %v", data.CurrentRound, data.PrunedRounds, len(data.RoundData))
+	}
+	if len(data.MissCounters) != 1 || data.MissCounters[0].Penalties != 2 {
+		t.Fatalf("wrong miss counters are exported: %v", data.MissCounters)
+	}
+	if len(data.EntropyProviders) != 1 || !data.EntropyProviders[0].Address.Equals(userAddrs[2]) {
+		t.Fatalf("wrong entropy providers are exported: %v", data.EntropyProviders)
+	}
+
+	newCtx, newKeeper, _ := Initialize(2, 2, uint64(n))
+	InitGenesis(newCtx, newKeeper, data)
+	if reexported := cdc.MustMarshalJSON(ExportGenesis(newCtx, newKeeper)); !bytes.Equal(reexported, exportedBytes) {
+		t.Errorf("state isn't kept by export and import:\n%s\n%s", exportedBytes, reexported)
+	}
+	if res, broken := AllInvariants(newKeeper)(newCtx); broken {
+		t.Errorf("invariants are broken after import: %v", res)
+	}
+	if !newKeeper.IsEntropyProvider(newCtx, userAddrs[2]) || newKeeper.getProvidersCount(newCtx) != 1 {
+		t.Errorf("entropy providers registry isn't imported")
+	}
+	if hash, err := newKeeper.GetTranscriptHash(newCtx, 0); err != nil || len(hash) == 0 {
+		t.Errorf("transcript hash of the pruned round isn't imported: %v", err)
+	}
+	if round, err := newKeeper.LatestCompletedRound(newCtx); err != nil || round != 1 {
+		t.Errorf("wrong latest completed round: %v, %v", round, err)
+	}
+
+	// the round in progress is finished on the imported state
+	setTestDecryptionShare(t, newCtx, &newKeeper, 3, privKeys[0], 0, userAddrs[0])
+	if newKeeper.GetStage(newCtx, 3) != stageCompleted || newKeeper.CurrentRound(newCtx) != 4 {
+		t.Fatalf("round isn't completed after import: %v", newKeeper.GetStage(newCtx, 3))
+	}
+	if transcript, err := newKeeper.GetRoundTranscript(newCtx, 3); err != nil || transcript.Verify() != nil {
+		t.Errorf("round completed after import isn't verified: %v", err)
+	}
+
+	tampered := func(modify func(data *GenesisState)) GenesisState {
+		var tamperedData GenesisState
+		cdc.MustUnmarshalJSON(exportedBytes, &tamperedData)
+		modify(&tamperedData)
+		return tamperedData
+	}
+	invalid := map[string]GenesisState{
+		"wrong result": tampered(func(data *GenesisState) { data.RoundData[1].Result[0] ^= 1 }),
+		"missing ciphertext share": tampered(func(data *GenesisState) {
+			data.RoundData[3].CiphertextShares = data.RoundData[3].CiphertextShares[:1]
+		}),
+		"decryption share of the other key holder": tampered(func(data *GenesisState) {
+			data.RoundData[3].DecryptionShares[0].KeyHolderAddr = userAddrs[0]
+		}),
+		"unfinished old round": tampered(func(data *GenesisState) { data.RoundData[2].Stage = stageDSCollecting }),
+		"missing round":        tampered(func(data *GenesisState) { data.RoundData = data.RoundData[1:] }),
+		"shares of pruned round": tampered(func(data *GenesisState) {
+			data.RoundData[0].CiphertextShares = data.RoundData[1].CiphertextShares
+		}),
+		"unknown key epoch": tampered(func(data *GenesisState) { data.RoundData[3].Epoch = 1 }),
+		"duplicate miss counter": tampered(func(data *GenesisState) {
+			data.MissCounters = append(data.MissCounters, data.MissCounters[0])
+		}),
+		"duplicate entropy provider": tampered(func(data *GenesisState) {
+			data.EntropyProviders = append(data.EntropyProviders, data.EntropyProviders[0])
+		}),
+	}
+	for name, data := range invalid {
+		if err := ValidateGenesis(data); err == nil {
+			t.Errorf("%v: invalid genesis is accepted", name)
+		}
+	}
+
+	reimport := func(ctx sdk.Context, k Keeper) (sdk.Context, Keeper, GenesisState) {
+		exportedBytes := cdc.MustMarshalJSON(ExportGenesis(ctx, k))
+		var data GenesisState
+		cdc.MustUnmarshalJSON(exportedBytes, &data)
+		if err := ValidateGenesis(data); err != nil {
+			t.Fatalf("exported genesis isn't valid: %v", err)
+		}
+		newCtx, newKeeper, _ := Initialize(2, 2, uint64(n))
+		InitGenesis(newCtx, newKeeper, data)
+		if reexported := cdc.MustMarshalJSON(ExportGenesis(newCtx, newKeeper)); !bytes.Equal(reexported, exportedBytes) {
+			t.Errorf("state isn't kept by export and import:\n%s\n%s", exportedBytes, reexported)
+		}
+		return newCtx, newKeeper, data
+	}
+
+	// the key holders change DKG is started again after the import, its keys are pending after the DKG
+	newAddrs := createTestAddrs(2 * n)[n:]
+	longterms := make([]kyber.Scalar, n)
+	participants := make([]types.DKGParticipantJSON, n)
 	for i := 0; i < n; i++ {
-		y := keeper.group.Scalar().SetInt64(int64(r))
-		rr := keeper.group.Scalar().SetInt64(int64(r + i))
-		ct, CE, err := createCiphertext(P256, commonkey, y, rr)
+		longterms[i] = P256.Scalar().Pick(P256.RandomStream())
+		p, err := types.NewDKGParticipantJSON(&types.DKGParticipant{Address: newAddrs[i], PubKey: P256.Point().Mul(longterms[i], nil)})
 		if err != nil {
-			t.Errorf("failed create proofs: %v", err)
-		}
-		ctShare := types.CiphertextShare{Ciphertext: ct, CEproof: CE, EntropyProvider: userAddrs[i]}
-		ciphertextShares = append(ciphertextShares, ctShare)
-		err1 := keeper.SetCiphertext(ctx, &ctShare)
-		if err1 != nil {
-			t.Errorf("failed set ciphertext: %v", err1)
+			t.Fatalf("can't serialize participant: %v", err)
 		}
+		participants[i] = p
 	}
-	newCiphertexts, err := keeper.GetAllCiphertexts(ctx, uint64(round))
-	if err != nil {
-		t.Errorf("failed get all ciphertexts: %v", err)
+	if err := newKeeper.ChangeKeyHolders(newCtx, participants, 2, 2); err != nil {
+		t.Fatalf("can't change key holders: %v", err)
 	}
-	for i := 0; i < n; i++ {
-		if newCiphertexts[i].EntropyProvider.String() != ciphertextShares[i].EntropyProvider.String() {
-			t.Errorf("new map doesn't contains original entropy provider, round: %v, expected: %v", round, ciphertextShares[i].EntropyProvider.String())
+	dkgCtx, dkgKeeper, dkgData := reimport(newCtx, newKeeper)
+	if dkgData.KeyHoldersChange == nil || len(dkgData.KeyHoldersChange.Participants) != n {
+		t.Fatalf("key holders change isn't exported: %v", dkgData.KeyHoldersChange)
+	}
+	if phase := dkgKeeper.GetDKGPhase(dkgCtx); phase != types.DKGPhaseDeals {
+		t.Fatalf("DKG isn't started after import: %v", phase)
+	}
+	runDKGTest(t, dkgCtx, &dkgKeeper, newAddrs, longterms, 2)
+	epochCtx, epochKeeper, epochData := reimport(dkgCtx, dkgKeeper)
+	if epochData.PendingKeyEpoch == nil || epochData.KeyHoldersChange != nil {
+		t.Fatalf("pending keys aren't exported: %v, %v", epochData.PendingKeyEpoch, epochData.KeyHoldersChange)
+	}
+	epochKeeper.AbortRound(epochCtx, epochKeeper.CurrentRound(epochCtx), "test")
+	if epoch := epochKeeper.CurrentKeyEpoch(epochCtx); epoch != 1 {
+		t.Fatalf("pending keys aren't applied after import, epoch: %v", epoch)
+	}
+
+	// the running refresh is exported with its messages and goes on after the import
+	if err := epochKeeper.StartRefresh(epochCtx, newAddrs[0]); err != nil {
+		t.Fatalf("can't start refresh: %v", err)
+	}
+	vkJSONList, err2 := epochKeeper.GetVerificationKeys(epochCtx)
+	if err2 != nil {
+		t.Fatalf("can't get verification keys: %v", err2)
+	}
+	vkList, err2 := types.VerificationKeyArrayDeserialize(vkJSONList)
+	if err2 != nil {
+		t.Fatalf("can't deserialize verification keys: %v", err2)
+	}
+	encKeys := make([]kyber.Point, len(vkList))
+	for i, vk := range vkList {
+		if encKeys[i], err = kyberenc.StringHexToPoint(P256, epochKeeper.GetEncryptionKey(epochCtx, vk.Sender)); err != nil {
+			t.Fatalf("can't decode encryption key: %v", err)
 		}
-		if !newCiphertexts[i].Ciphertext.Equal(ciphertextShares[i].Ciphertext) {
-			t.Errorf("ciphertexts don't equal, round: %v", round)
+	}
+	deals := make([]*types.RefreshDeal, len(vkList))
+	for i := range vkList {
+		if deals[i], _, err = types.NewRefreshDeal(vkList, encKeys, 2); err != nil {
+			t.Fatalf("can't create refresh deal: %v", err)
+		}
+	}
+	if err := epochKeeper.SetRefreshDeal(epochCtx, vkList[0].Sender, *deals[0]); err != nil {
+		t.Fatalf("can't set refresh deal: %v", err)
+	}
+	refreshCtx, refreshKeeper, refreshData := reimport(epochCtx, epochKeeper)
+	if refreshData.Refresh == nil || refreshData.Refresh.Phase != types.RefreshPhaseDeals || len(refreshData.Refresh.Deals) != 1 {
+		t.Fatalf("running refresh isn't exported: %v", refreshData.Refresh)
+	}
+	if err := refreshKeeper.SetRefreshDeal(refreshCtx, vkList[0].Sender, *deals[0]); err == nil {
+		t.Errorf("refresh deal is accepted twice after import")
+	}
+	for i := 1; i < len(vkList); i++ {
+		if err := refreshKeeper.SetRefreshDeal(refreshCtx, vkList[i].Sender, *deals[i]); err != nil {
+			t.Fatalf("can't set refresh deal after import: %v", err)
 		}
 	}
+	if phase := refreshKeeper.GetRefreshPhase(refreshCtx); phase != types.RefreshPhaseComplaints {
+		t.Errorf("wrong refresh phase after import: %v", phase)
+	}
+
+	refreshData.PendingKeyEpoch = epochData.PendingKeyEpoch
+	if err := ValidateGenesis(refreshData); err == nil {
+		t.Errorf("refresh running with pending keys is accepted")
+	}
+	refreshData.PendingKeyEpoch = nil
+	refreshData.Refresh.Deals = append(refreshData.Refresh.Deals, refreshData.Refresh.Deals[0])
+	if err := ValidateGenesis(refreshData); err == nil {
+		t.Errorf("duplicate refresh deal is accepted")
+	}
+}
+
+func TestInstances_Separate(t *testing.T) {
+	n := 3
+	ctx, keeper, cdc := Initialize(2, 2, uint64(n))
+	userAddrs := createTestAddrs(n)
+	privKeys, err := setKeyHolders(ctx, &keeper, userAddrs, 2, n)
+	if err != nil {
+		t.Fatalf("can't set key holders: %v", err)
+	}
+	keeper.setKeyEpoch(ctx, 0, 0)
+	commonKey, err2 := keeper.GetCommonPublicKey(ctx)
+	if err2 != nil {
+		t.Fatalf("can't get common key: %v", err2)
+	}
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[0])
+	setTestCiphertext(t, ctx, &keeper, commonKey, userAddrs[1])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[0], 0, userAddrs[0])
+	setTestDecryptionShare(t, ctx, &keeper, 0, privKeys[1], 1, userAddrs[1])
+
+	// the instance is defined in the genesis with the same key holders and its own params
+	instanceState := ExportGenesis(ctx, keeper)
+	instanceState.CurrentRound, instanceState.PrunedRounds = 0, 0
+	instanceState.KeyEpochs, instanceState.RoundData = nil, []types.RoundData{}
+	instanceState.Params.CiphertextDeadline = 7
+	genesis := ExportGenesis(ctx, keeper)
+	genesis.Instances = []types.InstanceGenesisState{{ID: "games", State: instanceState}}
+	if err := ValidateGenesis(genesis); err != nil {
+		t.Fatalf("genesis with instance isn't valid: %v", err)
+	}
+	ctx, keeper, _ = Initialize(2, 2, uint64(n))
+	InitGenesis(ctx, keeper, genesis)
+	instance := keeper.Instance("games")
+
+	if keeper.CurrentRound(ctx) != 1 || instance.CurrentRound(ctx) != 0 {
+		t.Fatalf("rounds aren't separate: %v, %v", keeper.CurrentRound(ctx), instance.CurrentRound(ctx))
+	}
+	if keeper.GetParams(ctx).CiphertextDeadline == 7 || instance.GetParams(ctx).CiphertextDeadline != 7 {
+		t.Errorf("params aren't separate: %v, %v", keeper.GetParams(ctx), instance.GetParams(ctx))
+	}
+	setTestCiphertext(t, ctx, &instance, commonKey, userAddrs[2])
+	if cts, err := keeper.GetAllCiphertexts(ctx, 0); err != nil || len(cts) != 2 {
+		t.Errorf("instance share is stored in the default instance: %v, %v", len(cts), err)
+	}
+	if cts, err := instance.GetAllCiphertexts(ctx, 0); err != nil || len(cts) != 1 {
+		t.Errorf("wrong instance shares: %v, %v", len(cts), err)
+	}
+	if instance.GetStage(ctx, 0) != stageCtCollecting {
+		t.Errorf("wrong instance stage: %v", instance.GetStage(ctx, 0))
+	}
+
+	res, err3 := NewQuerier(keeper)(ctx, []string{types.QueryCurrentRound, "games"}, abci.RequestQuery{})
+	if err3 != nil {
+		t.Fatalf("can't query instance round: %v", err3)
+	}
+	var roundRes types.QueryCurrentRoundRes
+	cdc.MustUnmarshalJSON(res, &roundRes)
+	if roundRes.Round != 0 {
+		t.Errorf("wrong instance round is queried: %v", roundRes.Round)
+	}
+	if _, err := NewQuerier(keeper)(ctx, []string{types.QueryCurrentRound, "unknown"}, abci.RequestQuery{}); err == nil || err.Code() != types.CodeUnknownInstance {
+		t.Errorf("query to unknown instance isn't rejected: %v", err)
+	}
+	msg := newTestDecryptionShareMsg(t, ctx, &instance, privKeys[0], userAddrs[0])
+	msg.Instance = "unknown"
+	if res := NewHandler(keeper)(ctx, msg); res.Code != types.CodeUnknownInstance {
+		t.Errorf("message to unknown instance isn't rejected: %v", res.Log)
+	}
+	if res, broken := AllInvariants(keeper)(ctx); broken {
+		t.Errorf("invariants are broken: %v", res)
+	}
+
+	exported := ExportGenesis(ctx, keeper)
+	if len(exported.Instances) != 1 || exported.Instances[0].ID != "games" || len(exported.Instances[0].State.RoundData) != 1 {
+		t.Fatalf("instance isn't exported: %v", exported.Instances)
+	}
+	if err := ValidateGenesis(exported); err != nil {
+		t.Fatalf("exported genesis isn't valid: %v", err)
+	}
+	exportedBytes := cdc.MustMarshalJSON(exported)
+	newCtx, newKeeper, _ := Initialize(2, 2, uint64(n))
+	InitGenesis(newCtx, newKeeper, exported)
+	if reexported := cdc.MustMarshalJSON(ExportGenesis(newCtx, newKeeper)); !bytes.Equal(reexported, exportedBytes) {
+		t.Errorf("instances aren't kept by export and import:\n%s\n%s", exportedBytes, reexported)
+	}
+
+	// hooks of the keeper are called for the instance rounds with the instance ID
+	hooks := &mockHerbHooks{}
+	newKeeper.SetHooks(hooks)
+	newInstance := newKeeper.Instance("games")
+	newInstance.AbortRound(newCtx, 0, "test")
+	if len(hooks.failed) != 1 || hooks.failed[0] != 0 || len(hooks.instances) != 1 || hooks.instances[0] != "games" {
+		t.Errorf("hooks aren't called for the instance: %v, %v", hooks.failed, hooks.instances)
+	}
+
+	// instance params are changed by the instance params change proposal
+	params := newInstance.GetParams(newCtx)
+	params.CiphertextDeadline = 9
+	proposal := types.NewInstanceParamsChangeProposal("games", "title", "description", params)
+	if err := proposal.ValidateBasic(); err != nil {
+		t.Fatalf("instance params change proposal isn't valid: %v", err)
+	}
+	if err := NewKeyHoldersChangeProposalHandler(newKeeper)(newCtx, proposal); err != nil {
+		t.Fatalf("can't change instance params: %v", err)
+	}
+	if newInstance.GetParams(newCtx).CiphertextDeadline != 9 || newKeeper.GetParams(newCtx).CiphertextDeadline == 9 {
+		t.Errorf("wrong params after the proposal: %v, %v", newInstance.GetParams(newCtx), newKeeper.GetParams(newCtx))
+	}
+	proposal.Instance = "unknown"
+	if err := NewKeyHoldersChangeProposalHandler(newKeeper)(newCtx, proposal); err == nil || err.Code() != types.CodeUnknownInstance {
+		t.Errorf("params change of unknown instance isn't rejected: %v", err)
+	}
+	proposal.Instance = types.DefaultInstance
+	if err := proposal.ValidateBasic(); err == nil {
+		t.Errorf("params change of the default instance is accepted")
+	}
+	proposal.Instance, proposal.Params.CiphertextDeadline = "games", 0
+	if err := proposal.ValidateBasic(); err == nil {
+		t.Errorf("invalid instance params are accepted")
+	}
+
+	genesis.Instances = append(genesis.Instances, genesis.Instances[0])
+	if err := ValidateGenesis(genesis); err == nil {
+		t.Errorf("duplicate instance is accepted")
+	}
 }
 
 func Initialize(thresholdDecryption uint64, thresholdCiphertexts uint64, n uint64) (ctx sdk.Context, keeperInstance Keeper, cdc *codec.Codec) {
+	ctx, keeperInstance, cdc = newTestKeeper()
+	keeperInstance.SetParams(ctx, types.DefaultParams())
+	keeperInstance.SetKeyHoldersNumber(ctx, n)
+	keeperInstance.SetThreshold(ctx, thresholdCiphertexts, thresholdDecryption)
+	return
+}
+
+// newTestKeeper creates the keeper with the empty stores
+func newTestKeeper() (ctx sdk.Context, keeperInstance Keeper, cdc *codec.Codec) {
 	cdc = codec.New()
 	types.RegisterCodec(cdc)
 	codec.RegisterCrypto(cdc)
 	keyHERB := sdk.NewKVStoreKey(types.StoreKey)
 	keyCt := sdk.NewKVStoreKey(types.CtStoreKey)
 	keyDs := sdk.NewKVStoreKey(types.DsStoreKey)
-	keeperInstance = NewKeeper(keyHERB, keyCt, keyDs, cdc)
+	keySlashing := sdk.NewKVStoreKey(slashing.StoreKey)
+	keyParams := sdk.NewKVStoreKey(params.StoreKey)
+	tkeyParams := sdk.NewTransientStoreKey(params.TStoreKey)
+	paramsKeeper := params.NewKeeper(cdc, keyParams, tkeyParams, params.DefaultCodespace)
+	bankKeeper := newMockBankKeeper()
+	stakingKeeper := newMockStakingKeeper()
+	slashingKeeper := slashing.NewKeeper(cdc, keySlashing, stakingKeeper, paramsKeeper.Subspace(slashing.DefaultParamspace), slashing.DefaultCodespace)
+	keeperInstance = NewKeeper(keyHERB, keyCt, keyDs, stakingKeeper, slashingKeeper, bankKeeper, bankKeeper, paramsKeeper.Subspace(types.DefaultParamspace), cdc)
 	db := dbm.NewMemDB()
 	ms := store.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(keySlashing, sdk.StoreTypeIAVL, db)
 	ms.MountStoreWithDB(keyHERB, sdk.StoreTypeIAVL, db)
 	ms.MountStoreWithDB(keyCt, sdk.StoreTypeIAVL, db)
 	ms.MountStoreWithDB(keyDs, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(keyParams, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(tkeyParams, sdk.StoreTypeTransient, db)
 	err := ms.LoadLatestVersion()
 	if err != nil {
 		panic(err)
 	}
 	ctx = sdk.NewContext(ms, abci.Header{ChainID: "test-chain"}, true, log.NewNopLogger())
-	keeperInstance.SetKeyHoldersNumber(ctx, n)
-	keeperInstance.SetThreshold(ctx, thresholdCiphertexts, thresholdDecryption)
 	ctx = ctx.WithConsensusParams(
 		&abci.ConsensusParams{
 			Validator: &abci.ValidatorParams{
@@ -256,13 +2877,13 @@ func Initialize(thresholdDecryption uint64, thresholdCiphertexts uint64, n uint6
 	return
 }
 
-func createCiphertext(group proof.Suite, commonKey kyber.Point, y kyber.Scalar, r kyber.Scalar) (ct elgamal.Ciphertext, ceProof []byte, err error) {
+func createCiphertext(group proof.Suite, context []byte, commonKey kyber.Point, y kyber.Scalar, r kyber.Scalar) (ct elgamal.Ciphertext, ceProof []byte, err error) {
 	m := group.Point().Mul(y, nil)
 	s := group.Point().Mul(r, commonKey)
 	a := group.Point().Mul(r, nil)
 	b := s.Add(group.Point().Mul(r, commonKey), m)
 	ct = elgamal.Ciphertext{PointA: a, PointB: b}
-	ceProof, err = elgamal.CE(group, group.Point().Base(), commonKey, ct.PointA, ct.PointB, r, y)
+	ceProof, err = elgamal.CE(group, context, group.Point().Base(), commonKey, ct.PointA, ct.PointB, r, y)
 	if err != nil {
 		return
 	}
@@ -351,3 +2972,325 @@ func setKeyHolders(ctx sdk.Context, k *Keeper, adds []sdk.AccAddress, t, n int)
 
 	return partialKeys, nil
 }
+
+func setTestCiphertext(t *testing.T, ctx sdk.Context, k *Keeper, commonKey kyber.Point, sender sdk.AccAddress) {
+	y := P256.Scalar().Pick(P256.RandomStream())
+	r := P256.Scalar().Pick(P256.RandomStream())
+	context := k.proofContext(ctx, elgamal.ContextCE, k.CurrentRound(ctx), sender)
+	ct, ceProof, err := createCiphertext(P256, context, commonKey, y, r)
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	if err := k.SetCiphertext(ctx, k.CurrentRound(ctx), &types.CiphertextShare{Ciphertext: ct, CEproof: ceProof, EntropyProvider: sender}); err != nil {
+		t.Fatalf("can't set ciphertext: %v", err)
+	}
+}
+
+// runDKGTest runs the DKG without complaints for the started participants
+func runDKGTest(t *testing.T, ctx sdk.Context, k *Keeper, addrs []sdk.AccAddress, longterms []kyber.Scalar, trh int) []*rabin.DistKeyGenerator {
+	n := len(longterms)
+	pubKeys := make([]kyber.Point, n)
+	for i := range longterms {
+		pubKeys[i] = P256.Point().Mul(longterms[i], nil)
+	}
+	gens := make([]*rabin.DistKeyGenerator, n)
+	for i := range gens {
+		gen, err := rabin.NewDistKeyGenerator(P256, longterms[i], pubKeys, trh)
+		if err != nil {
+			t.Fatalf("can't create key generator: %v", err)
+		}
+		gens[i] = gen
+	}
+	for i, gen := range gens {
+		deals, err := gen.Deals()
+		if err != nil {
+			t.Fatalf("can't create deals: %v", err)
+		}
+		var dkgDeals []types.DKGDeal
+		for recipient, deal := range deals {
+			dkgDeals = append(dkgDeals, types.DKGDeal{Recipient: uint32(recipient), Data: encodeDKGTest(t, deal)})
+		}
+		if err := k.SetDKGDeals(ctx, addrs[i], dkgDeals); err != nil {
+			t.Fatalf("can't set deals: %v", err)
+		}
+	}
+	for i, gen := range gens {
+		deals, err := k.GetDKGDeals(ctx, uint32(i))
+		if err != nil {
+			t.Fatalf("can't get deals: %v", err)
+		}
+		var responses [][]byte
+		for _, bz := range deals {
+			var deal rabin.Deal
+			if err := types.DecodeDKGMessage(bz, &deal); err != nil {
+				t.Fatalf("can't decode deal: %v", err)
+			}
+			resp, err := gen.ProcessDeal(&deal)
+			if err != nil {
+				t.Fatalf("can't process deal: %v", err)
+			}
+			responses = append(responses, encodeDKGTest(t, resp))
+		}
+		if err := k.SetDKGResponses(ctx, addrs[i], responses); err != nil {
+			t.Fatalf("can't set responses: %v", err)
+		}
+	}
+	responses, err := k.GetDKGMessages(ctx, types.DKGPhaseResponses)
+	if err != nil {
+		t.Fatalf("can't get responses: %v", err)
+	}
+	for i, gen := range gens {
+		for _, msg := range responses {
+			if msg.Index == uint32(i) {
+				continue
+			}
+			for _, bz := range msg.Data {
+				var resp rabin.Response
+				if err := types.DecodeDKGMessage(bz, &resp); err != nil {
+					t.Fatalf("can't decode response: %v", err)
+				}
+				if _, err := gen.ProcessResponse(&resp); err != nil {
+					t.Fatalf("can't process response: %v", err)
+				}
+			}
+		}
+		if err := k.SetDKGJustifications(ctx, addrs[i], nil); err != nil {
+			t.Fatalf("can't set justifications: %v", err)
+		}
+	}
+	for i, gen := range gens {
+		sc, err := gen.SecretCommits()
+		if err != nil {
+			t.Fatalf("can't create secret commits: %v", err)
+		}
+		var qual []uint32
+		for _, idx := range gen.QUAL() {
+			qual = append(qual, uint32(idx))
+		}
+		if err := k.SetDKGSecretCommits(ctx, addrs[i], encodeDKGTest(t, sc), qual); err != nil {
+			t.Fatalf("can't set secret commits: %v", err)
+		}
+	}
+	commits, err := k.GetDKGMessages(ctx, types.DKGPhaseCommits)
+	if err != nil {
+		t.Fatalf("can't get secret commits: %v", err)
+	}
+	for i, gen := range gens {
+		for _, msg := range commits {
+			if msg.Index == uint32(i) {
+				continue
+			}
+			var sc rabin.SecretCommits
+			if err := types.DecodeDKGMessage(msg.Data[0], &sc); err != nil {
+				t.Fatalf("can't decode secret commits: %v", err)
+			}
+			if _, err := gen.ProcessSecretCommits(&sc); err != nil {
+				t.Fatalf("can't process secret commits: %v", err)
+			}
+		}
+		if err := k.SetDKGComplaintCommits(ctx, addrs[i], nil); err != nil {
+			t.Fatalf("can't set complaint commits: %v", err)
+		}
+	}
+	for i := range gens {
+		if err := k.SetDKGReconstructCommits(ctx, addrs[i], nil); err != nil {
+			t.Fatalf("can't set reconstruct commits: %v", err)
+		}
+	}
+	return gens
+}
+
+func setTestDecryptionShare(t *testing.T, ctx sdk.Context, k *Keeper, round uint64, privKey kyber.Scalar, id int, sender sdk.AccAddress) {
+	aggCt, err := k.GetAggregatedCiphertext(ctx, round)
+	if err != nil {
+		t.Fatalf("can't get aggregated ciphertext: %v", err)
+	}
+	ds, dleq, err2 := elgamal.CreateDecShare(P256, k.proofContext(ctx, elgamal.ContextDLEQ, round, sender), *aggCt, privKey)
+	if err2 != nil {
+		t.Fatalf("can't create decryption share: %v", err2)
+	}
+	decShare := types.DecryptionShare{DecShare: share.PubShare{I: id, V: ds}, DLEQproof: dleq, KeyHolderAddr: sender}
+	if err := k.SetDecryptionShare(ctx, round, &decShare); err != nil {
+		t.Fatalf("can't set decryption share: %v", err)
+	}
+}
+
+// mockHerbHooks records rounds and instances passed to the hooks
+type mockHerbHooks struct {
+	ctClosed  []uint64
+	completed []uint64
+	results   [][]byte
+	failed    []uint64
+	instances []string
+
+	panicOnCompleted bool
+}
+
+func (h *mockHerbHooks) AfterCiphertextStageClosed(ctx sdk.Context, instance string, round uint64) {
+	h.ctClosed = append(h.ctClosed, round)
+	h.instances = append(h.instances, instance)
+}
+
+func (h *mockHerbHooks) AfterRoundCompleted(ctx sdk.Context, instance string, round uint64, result []byte) {
+	if h.panicOnCompleted {
+		panic("round completed")
+	}
+	h.completed = append(h.completed, round)
+	h.results = append(h.results, result)
+	h.instances = append(h.instances, instance)
+}
+
+func (h *mockHerbHooks) AfterRoundFailed(ctx sdk.Context, instance string, round uint64) {
+	h.failed = append(h.failed, round)
+	h.instances = append(h.instances, instance)
+}
+
+// mockStakingKeeper records slashed and jailed validators, it panics on the penalties the staking keeper doesn't allow
+type mockStakingKeeper struct {
+	validators map[string]staking.Validator
+	slashed    []sdk.ConsAddress
+	fractions  []sdk.Dec
+	jailed     []sdk.ConsAddress
+}
+
+func newMockStakingKeeper() *mockStakingKeeper {
+	return &mockStakingKeeper{validators: make(map[string]staking.Validator)}
+}
+
+func (sk *mockStakingKeeper) addValidator(addr sdk.AccAddress) {
+	validator := staking.NewValidator(sdk.ValAddress(addr), ed25519.GenPrivKey().PubKey(), staking.Description{})
+	validator.Status = sdk.Bonded
+	validator.Tokens = sdk.TokensFromConsensusPower(10)
+	validator.DelegatorShares = validator.Tokens.ToDec()
+	sk.validators[sdk.ValAddress(addr).String()] = validator
+}
+
+// addSigningInfo creates the validator's signing info as the slashing hooks do at bonding
+func (sk *mockStakingKeeper) addSigningInfo(ctx sdk.Context, k *Keeper, addr sdk.AccAddress) {
+	consAddr := sk.consAddr(addr)
+	k.slashingKeeper.SetValidatorSigningInfo(ctx, consAddr, slashing.NewValidatorSigningInfo(consAddr, ctx.BlockHeight(), 0, time.Unix(0, 0), false, 0))
+}
+
+func (sk *mockStakingKeeper) IterateValidators(ctx sdk.Context, fn func(index int64, validator stakingexported.ValidatorI) (stop bool)) {
+	var i int64
+	for _, validator := range sk.validators {
+		if fn(i, validator) {
+			return
+		}
+		i++
+	}
+}
+
+func (sk *mockStakingKeeper) ValidatorByConsAddr(ctx sdk.Context, consAddr sdk.ConsAddress) stakingexported.ValidatorI {
+	for _, validator := range sk.validators {
+		if validator.GetConsAddr().Equals(consAddr) {
+			return validator
+		}
+	}
+	return nil
+}
+
+func (sk *mockStakingKeeper) Delegation(ctx sdk.Context, delAddr sdk.AccAddress, valAddr sdk.ValAddress) stakingexported.DelegationI {
+	validator, ok := sk.validators[valAddr.String()]
+	if !ok || !sdk.ValAddress(delAddr).Equals(valAddr) {
+		return nil
+	}
+	return staking.NewDelegation(delAddr, valAddr, validator.DelegatorShares)
+}
+
+func (sk *mockStakingKeeper) MaxValidators(ctx sdk.Context) uint16 {
+	return 100
+}
+
+func (sk *mockStakingKeeper) consAddr(addr sdk.AccAddress) sdk.ConsAddress {
+	return sk.validators[sdk.ValAddress(addr).String()].GetConsAddr()
+}
+
+func (sk *mockStakingKeeper) Validator(ctx sdk.Context, address sdk.ValAddress) stakingexported.ValidatorI {
+	validator, ok := sk.validators[address.String()]
+	if !ok {
+		return nil
+	}
+	return validator
+}
+
+func (sk *mockStakingKeeper) Slash(ctx sdk.Context, consAddr sdk.ConsAddress, infractionHeight int64, power int64, slashFactor sdk.Dec) {
+	validator := sk.ValidatorByConsAddr(ctx, consAddr)
+	if validator == nil || validator.IsJailed() || validator.IsUnbonded() {
+		panic(fmt.Sprintf("can't slash validator %v", consAddr))
+	}
+	sk.slashed = append(sk.slashed, consAddr)
+	sk.fractions = append(sk.fractions, slashFactor)
+}
+
+func (sk *mockStakingKeeper) Jail(ctx sdk.Context, consAddr sdk.ConsAddress) {
+	sk.setJailed(consAddr, true)
+	sk.jailed = append(sk.jailed, consAddr)
+}
+
+func (sk *mockStakingKeeper) Unjail(ctx sdk.Context, consAddr sdk.ConsAddress) {
+	sk.setJailed(consAddr, false)
+}
+
+func (sk *mockStakingKeeper) setJailed(consAddr sdk.ConsAddress, jailed bool) {
+	for key, validator := range sk.validators {
+		if validator.GetConsAddr().Equals(consAddr) {
+			if validator.Jailed == jailed {
+				panic(fmt.Sprintf("validator %v jailed status is already %v", consAddr, jailed))
+			}
+			validator.Jailed = jailed
+			sk.validators[key] = validator
+		}
+	}
+}
+
+// mockBankKeeper keeps balances of accounts and module accounts
+type mockBankKeeper struct {
+	balances map[string]sdk.Coins
+}
+
+func newMockBankKeeper() *mockBankKeeper {
+	return &mockBankKeeper{balances: make(map[string]sdk.Coins)}
+}
+
+func (bk *mockBankKeeper) GetCoins(ctx sdk.Context, addr sdk.AccAddress) sdk.Coins {
+	return bk.balances[addr.String()]
+}
+
+func (bk *mockBankKeeper) GetModuleAddress(moduleName string) sdk.AccAddress {
+	return supply.NewModuleAddress(moduleName)
+}
+
+func (bk *mockBankKeeper) GetModuleAccount(ctx sdk.Context, moduleName string) supplyexported.ModuleAccountI {
+	return supply.NewEmptyModuleAccount(moduleName)
+}
+
+func (bk *mockBankKeeper) send(from, to sdk.AccAddress, amt sdk.Coins) sdk.Error {
+	balance, hasNeg := bk.balances[from.String()].SafeSub(amt)
+	if hasNeg {
+		return sdk.ErrInsufficientCoins(fmt.Sprintf("%v < %v", bk.balances[from.String()], amt))
+	}
+	bk.balances[from.String()] = balance
+	bk.balances[to.String()] = bk.balances[to.String()].Add(amt)
+	return nil
+}
+
+func (bk *mockBankKeeper) SendCoinsFromModuleToAccount(ctx sdk.Context, senderModule string, recipientAddr sdk.AccAddress, amt sdk.Coins) sdk.Error {
+	return bk.send(supply.NewModuleAddress(senderModule), recipientAddr, amt)
+}
+
+func (bk *mockBankKeeper) SendCoinsFromModuleToModule(ctx sdk.Context, senderModule, recipientModule string, amt sdk.Coins) sdk.Error {
+	return bk.send(supply.NewModuleAddress(senderModule), supply.NewModuleAddress(recipientModule), amt)
+}
+
+func (bk *mockBankKeeper) SendCoinsFromAccountToModule(ctx sdk.Context, senderAddr sdk.AccAddress, recipientModule string, amt sdk.Coins) sdk.Error {
+	return bk.send(senderAddr, supply.NewModuleAddress(recipientModule), amt)
+}
+
+func encodeDKGTest(t *testing.T, msg interface{}) []byte {
+	bz, err := types.EncodeDKGMessage(msg)
+	if err != nil {
+		t.Fatalf("can't encode DKG message: %v", err)
+	}
+	return bz
+}
@@ -0,0 +1,74 @@
+package herb
+
+import (
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// GetRoundTranscript returns all data needed to check the round offline
+func (k *Keeper) GetRoundTranscript(ctx sdk.Context, round uint64) (*types.RoundTranscript, sdk.Error) {
+	if err := k.checkRoundPruned(ctx, round); err != nil {
+		return nil, err
+	}
+	stage := k.GetStage(ctx, round)
+	if stage == stageUnstarted {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("round %v hasn't started yet", round))
+	}
+	epoch, err := k.GetRoundEpoch(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	ctList, err := k.GetAllCiphertexts(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	ctJSONList, err := types.CiphertextArraySerialize(ctList)
+	if err != nil {
+		return nil, err
+	}
+	dsJSONList := []types.DecryptionShareJSON{}
+	if stage != stageCtCollecting {
+		dsList, err := k.GetAllDecryptionShares(ctx, round)
+		if err != nil {
+			return nil, err
+		}
+		dsJSONList, err = types.DecryptionSharesArraySerialize(dsList)
+		if err != nil {
+			return nil, err
+		}
+	}
+	transcript := &types.RoundTranscript{
+		ChainID:              k.roundChainID(ctx, round),
+		Instance:             k.instance,
+		Round:                round,
+		Stage:                stage,
+		Epoch:                epoch.Number,
+		CommonPublicKey:      epoch.CommonPublicKey,
+		KeyHolders:           epoch.KeyHolders,
+		ThresholdCiphertexts: epoch.ThresholdCiphertexts,
+		ThresholdDecryption:  epoch.ThresholdDecryption,
+		Ciphertexts:          ctJSONList,
+		DecryptionShares:     dsJSONList,
+	}
+	aggCt, err := k.GetAggregatedCiphertext(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	if aggCt != nil {
+		aggCtJSON, err := elgamal.NewCiphertextJSON(aggCt, P256)
+		if err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't serialize aggregated ciphertext: %v", err))
+		}
+		transcript.AggregatedCiphertext = aggCtJSON
+	}
+	if stage == stageCompleted {
+		transcript.Result, err = k.RandomResult(ctx, round)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transcript, nil
+}
@@ -0,0 +1,111 @@
+package herb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+
+	"github.com/corestario/HERB/x/herb/types"
+)
+
+//this file defines the verification keys lookup. Keys are always looked up through the store: the hash of the stored
+//keys list is read on each lookup and parsed keys are cached in memory by this hash. The cache can't be stale
+//after a restart or LoadHeight, and the gas used doesn't depend on whether the cache is filled on this node.
+
+// vkCache holds parsed verification keys of each beacon instance, it's shared by the keeper copies
+type vkCache struct {
+	mtx     sync.RWMutex
+	entries map[string]vkCacheEntry
+}
+
+type vkCacheEntry struct {
+	hash []byte
+	keys map[string]*types.VerificationKey
+}
+
+func newVKCache() *vkCache {
+	return &vkCache{entries: make(map[string]vkCacheEntry)}
+}
+
+func (c *vkCache) get(instance string, hash []byte, addr sdk.AccAddress) (*types.VerificationKey, bool, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	entry, ok := c.entries[instance]
+	if !ok || !bytes.Equal(entry.hash, hash) {
+		return nil, false, false
+	}
+	vk, ok := entry.keys[addr.String()]
+	return vk, ok, true
+}
+
+func (c *vkCache) set(instance string, hash []byte, keys map[string]*types.VerificationKey) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.entries[instance] = vkCacheEntry{hash: hash, keys: keys}
+}
+
+// setVerificationKeysBytes stores the verification keys list with its hash, all changes of the keys go through it
+func (k *Keeper) setVerificationKeysBytes(ctx sdk.Context, vkBytes []byte) {
+	store := k.store(ctx)
+	hash := sha256.Sum256(vkBytes)
+	store.Set([]byte(keyVerificationKeys), vkBytes)
+	store.Set([]byte(keyVerificationKeysHash), hash[:])
+}
+
+// GetVerificationKey returns the key holder's verification key
+func (k *Keeper) GetVerificationKey(ctx sdk.Context, keyHolder sdk.AccAddress) (*types.VerificationKey, sdk.Error) {
+	store := k.store(ctx)
+	hash := store.Get([]byte(keyVerificationKeysHash))
+	if hash == nil {
+		return nil, sdk.ErrUnknownRequest("Verification keys are not defined")
+	}
+	vk, ok, cached := k.vkCache.get(k.instance, hash, keyHolder)
+	if !cached {
+		keys, err := k.loadVerificationKeys(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		vk, ok = keys[keyHolder.String()]
+	}
+	if !ok {
+		return nil, sdk.ErrUnknownRequest("verification key isn't exist")
+	}
+	return vk, nil
+}
+
+// InitializeVerificationKeys checks that verification keys are defined and fills the cache
+func (k *Keeper) InitializeVerificationKeys(ctx sdk.Context) sdk.Error {
+	hash := k.store(ctx).Get([]byte(keyVerificationKeysHash))
+	if hash == nil {
+		return sdk.ErrUnknownRequest("Verification keys are not defined")
+	}
+	_, err := k.loadVerificationKeys(ctx, hash)
+	return err
+}
+
+// loadVerificationKeys parses the stored keys into the cache, reads aren't charged
+// so the gas doesn't differ between nodes with filled and empty caches
+func (k *Keeper) loadVerificationKeys(ctx sdk.Context, hash []byte) (map[string]*types.VerificationKey, sdk.Error) {
+	store := k.store(ctx.WithGasMeter(sdk.NewInfiniteGasMeter()))
+	vkBytes := store.Get([]byte(keyVerificationKeys))
+	if actual := sha256.Sum256(vkBytes); !bytes.Equal(actual[:], hash) {
+		return nil, sdk.ErrInternal("verification keys don't match their hash")
+	}
+	var vkJSONList []types.VerificationKeyJSON
+	if err := k.cdc.UnmarshalJSON(vkBytes, &vkJSONList); err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't unmarshal verification keys: %v", err))
+	}
+	vkList, err := types.VerificationKeyArrayDeserialize(vkJSONList)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*types.VerificationKey, len(vkList))
+	for _, vk := range vkList {
+		keys[vk.Sender.String()] = vk
+	}
+	k.vkCache.set(k.instance, hash, keys)
+	return keys, nil
+}
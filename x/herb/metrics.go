@@ -1,28 +1,75 @@
 package herb
 
 import (
+	"flag"
+	"log"
+	"net/http"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+var metricsServerOnce sync.Once
+
 type Metrics struct {
 	Random      prometheus.Gauge
 	CountRandom prometheus.Counter
+	CountFailed prometheus.Counter
 }
 
 func PrometheusMetrics() *Metrics {
-	random := prometheus.NewGauge(prometheus.GaugeOpts{
+	random := registerCollector(prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: "HERB",
 		Subsystem: "MetricsSubsystem",
 		Name:      "random",
 		Help:      "output of HERB",
-	})
-	prometheus.MustRegister(random)
-	countRandom := prometheus.NewCounter(prometheus.CounterOpts{
+	})).(prometheus.Gauge)
+	countRandom := registerCollector(prometheus.NewCounter(prometheus.CounterOpts{
 		Namespace: "HERB",
 		Subsystem: "MetricsSubsystem",
 		Name:      "countRandom",
 		Help:      "output of HERB",
+	})).(prometheus.Counter)
+	countFailed := registerCollector(prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "HERB",
+		Subsystem: "MetricsSubsystem",
+		Name:      "countFailed",
+		Help:      "number of aborted HERB rounds",
+	})).(prometheus.Counter)
+	return &Metrics{Random: random, CountRandom: countRandom, CountFailed: countFailed}
+}
+
+// registerCollector registers the collector or returns the already registered one,
+// so several keepers (e.g. in tests) can share the same metrics
+func registerCollector(c prometheus.Collector) prometheus.Collector {
+	if err := prometheus.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
+
+// startMetricsServer starts the prometheus HTTP server, it's started once per process
+// as the genesis can be imported several times (e.g. in tests)
+func startMetricsServer() {
+	metricsServerOnce.Do(func() {
+		var addr = flag.String("listen-address", ":8080", "The address to listen on for HTTP requests.")
+		srv := &http.Server{
+			Addr: *addr,
+			Handler: promhttp.InstrumentMetricHandler(
+				prometheus.DefaultRegisterer, promhttp.HandlerFor(
+					prometheus.DefaultGatherer,
+					promhttp.HandlerOpts{MaxRequestsInFlight: 3},
+				),
+			),
+		}
+		go func() {
+			if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+				log.Fatal(http.ListenAndServe(*addr, nil))
+			}
+		}()
 	})
-	prometheus.MustRegister(countRandom)
-	return &Metrics{Random: random, CountRandom: countRandom}
 }
@@ -14,7 +14,7 @@ import (
 
 // SetKeyHoldersNumber set the number of key holders (n for (t, n)-threshold cryptosystem)
 func (k *Keeper) SetKeyHoldersNumber(ctx sdk.Context, n uint64) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	nBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(nBytes, n)
 	store.Set([]byte(keyKeyHoldersNumber), nBytes)
@@ -22,7 +22,7 @@ func (k *Keeper) SetKeyHoldersNumber(ctx sdk.Context, n uint64) {
 
 // GetKeyHoldersNumber returns size of the current key holders group
 func (k *Keeper) GetKeyHoldersNumber(ctx sdk.Context) (uint64, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	if !store.Has([]byte(keyKeyHoldersNumber)) {
 		return 0, sdk.ErrUnknownRequest("Store doesn't contain number of key holders")
 	}
@@ -33,7 +33,7 @@ func (k *Keeper) GetKeyHoldersNumber(ctx sdk.Context) (uint64, sdk.Error) {
 
 // SetVerificationKeys set verification keys corresponding to each address
 func (k *Keeper) SetVerificationKeys(ctx sdk.Context, verificationKeys []types.VerificationKeyJSON) sdk.Error {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	if store.Has([]byte(keyVerificationKeys)) {
 		return sdk.ErrUnknownRequest("verification keys already exist")
 	}
@@ -43,34 +43,14 @@ func (k *Keeper) SetVerificationKeys(ctx sdk.Context, verificationKeys []types.V
 		return sdk.ErrUnknownRequest("can't marshal list")
 	}
 
-	store.Set([]byte(keyVerificationKeys), verificationKeysBytes)
+	k.setVerificationKeysBytes(ctx, verificationKeysBytes)
 	return nil
 
 }
 
-// InitializeVerificationKeys runs at the start of the HERB protocol
-// It's purpose is get verification keys from the KVStore and save them as a keeper field
-// By default we can get gas limit problem with storing verification keys only in KVStore
-func (k *Keeper) InitializeVerificationKeys(ctx sdk.Context) sdk.Error {
-	verificationKeysJSON, err := k.GetVerificationKeys(ctx)
-	if err != nil {
-		return err
-	}
-	verificationKeys, err := types.VerificationKeyArrayDeserialize(verificationKeysJSON)
-	if err != nil {
-		return err
-	}
-	vk := make(map[string]types.VerificationKey)
-	for _, key := range verificationKeys {
-		vk[key.Sender.String()] = *key
-	}
-	k.verificationKeys = vk
-	return nil
-}
-
 // GetVerificationKeys returns verification keys corresponding to each address
 func (k *Keeper) GetVerificationKeys(ctx sdk.Context) ([]types.VerificationKeyJSON, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	if !store.Has([]byte(keyVerificationKeys)) {
 		return nil, sdk.ErrUnknownRequest("Verification keys are not defined")
 	}
@@ -82,7 +62,7 @@ func (k *Keeper) GetVerificationKeys(ctx sdk.Context) ([]types.VerificationKeyJS
 
 // SetThreshold set threshold for decryption and ciphertext shares
 func (k *Keeper) SetThreshold(ctx sdk.Context, thresholdCiphertexts uint64, thresholdDecrypt uint64) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	thresholdCiphertextsBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(thresholdCiphertextsBytes, thresholdCiphertexts)
 	store.Set([]byte(keyThresholdCiphertexts), thresholdCiphertextsBytes)
@@ -93,7 +73,7 @@ func (k *Keeper) SetThreshold(ctx sdk.Context, thresholdCiphertexts uint64, thre
 
 // GetThresholdCiphertexts returns the total number of ciphertexts which required by HERB settings
 func (k *Keeper) GetThresholdCiphertexts(ctx sdk.Context) (uint64, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	if !store.Has([]byte(keyThresholdCiphertexts)) {
 		return 0, sdk.ErrUnknownRequest("threshold for ciphertext shares is not defined")
 	}
@@ -105,7 +85,7 @@ func (k *Keeper) GetThresholdCiphertexts(ctx sdk.Context) (uint64, sdk.Error) {
 
 // GetThresholdDecryption returns threshold value for ElGamal cryptosystem
 func (k *Keeper) GetThresholdDecryption(ctx sdk.Context) (uint64, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	if !store.Has([]byte(keyThresholdDecrypt)) {
 		return 0, sdk.ErrUnknownRequest("decryption threshold is not defined")
 	}
@@ -116,13 +96,13 @@ func (k *Keeper) GetThresholdDecryption(ctx sdk.Context) (uint64, sdk.Error) {
 }
 
 func (k *Keeper) SetCommonPublicKey(ctx sdk.Context, pubKeyHex string) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	keyBytes := []byte(pubKeyHex)
 	store.Set([]byte(keyCommonKey), keyBytes)
 }
 
 func (k *Keeper) GetCommonPublicKey(ctx sdk.Context) (kyber.Point, sdk.Error) {
-	store := ctx.KVStore(k.storeKey)
+	store := k.store(ctx)
 	keyBytes := store.Get([]byte(keyCommonKey))
 	key, err := kyberenc.StringHexToPoint(P256, string(keyBytes))
 	if err != nil {
@@ -130,3 +110,23 @@ func (k *Keeper) GetCommonPublicKey(ctx sdk.Context) (kyber.Point, sdk.Error) {
 	}
 	return key, nil
 }
+
+// GetParams returns the round parameters from the params subspace
+// Params of the other beacon instances are kept in the instance store
+func (k *Keeper) GetParams(ctx sdk.Context) (params types.Params) {
+	if k.instance != types.DefaultInstance {
+		k.cdc.MustUnmarshalJSON(k.store(ctx).Get([]byte(keyInstanceParams)), &params)
+		return params
+	}
+	k.paramSpace.GetParamSet(ctx, &params)
+	return params
+}
+
+// SetParams sets the round parameters to the params subspace
+func (k *Keeper) SetParams(ctx sdk.Context, params types.Params) {
+	if k.instance != types.DefaultInstance {
+		k.store(ctx).Set([]byte(keyInstanceParams), k.cdc.MustMarshalJSON(params))
+		return
+	}
+	k.paramSpace.SetParamSet(ctx, &params)
+}
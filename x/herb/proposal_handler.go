@@ -0,0 +1,33 @@
+package herb
+
+import (
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/types"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+)
+
+// NewKeyHoldersChangeProposalHandler returns a handler for the herb governance proposals
+func NewKeyHoldersChangeProposalHandler(k Keeper) govtypes.Handler {
+	return func(ctx sdk.Context, content govtypes.Content) sdk.Error {
+		switch c := content.(type) {
+		case types.KeyHoldersChangeProposal:
+			keeper, err := k.instanceKeeper(ctx, c.Instance)
+			if err != nil {
+				return err
+			}
+			return keeper.ChangeKeyHolders(ctx, c.Participants, c.ThresholdCiphertexts, c.ThresholdDecryption)
+		case types.InstanceParamsChangeProposal:
+			keeper, err := k.instanceKeeper(ctx, c.Instance)
+			if err != nil {
+				return err
+			}
+			keeper.SetParams(ctx, c.Params)
+			return nil
+		default:
+			errMsg := fmt.Sprintf("unrecognized herb proposal content type: %T", c)
+			return sdk.ErrUnknownRequest(errMsg)
+		}
+	}
+}
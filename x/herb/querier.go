@@ -1,17 +1,26 @@
 package herb
 
 import (
-	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/corestario/HERB/x/herb/elgamal"
 	"github.com/corestario/HERB/x/herb/types"
 
+	"github.com/cosmos/cosmos-sdk/codec"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	abci "github.com/tendermint/tendermint/abci/types"
 )
 
 // NewQuerier is the module level router for state queries
-func NewQuerier(keeper Keeper) sdk.Querier {
+// The optional second path element is the beacon instance ID, queries without it go to the default instance
+func NewQuerier(k Keeper) sdk.Querier {
 	return func(ctx sdk.Context, path []string, req abci.RequestQuery) (res []byte, err sdk.Error) {
+		instance := types.DefaultInstance
+		if len(path) > 1 {
+			instance = path[1]
+		}
+		keeper, err := k.instanceKeeper(ctx, instance)
+		if err != nil {
+			return nil, err
+		}
 		switch path[0] {
 		case types.QueryAggregatedCt:
 			return queryAggregatedCt(ctx, req, keeper)
@@ -25,6 +34,30 @@ func NewQuerier(keeper Keeper) sdk.Querier {
 			return queryCurrentRound(ctx, keeper)
 		case types.QueryResult:
 			return queryResult(ctx, req, keeper)
+		case types.QueryDKGPhase:
+			return queryDKGPhase(ctx, keeper)
+		case types.QueryDKGParticipants:
+			return queryDKGParticipants(ctx, keeper)
+		case types.QueryDKGDeals:
+			return queryDKGDeals(ctx, req, keeper)
+		case types.QueryDKGMessages:
+			return queryDKGMessages(ctx, req, keeper)
+		case types.QueryRefresh:
+			return queryRefresh(ctx, keeper)
+		case types.QueryEpoch:
+			return queryEpoch(ctx, req, keeper)
+		case types.QueryRoundEpoch:
+			return queryRoundEpoch(ctx, req, keeper)
+		case types.QueryMissCounters:
+			return queryMissCounters(ctx, keeper)
+		case types.QueryRewardPool:
+			return queryRewardPool(ctx, keeper)
+		case types.QueryEntropyProviders:
+			return queryEntropyProviders(ctx, keeper)
+		case types.QueryRoundTranscript:
+			return queryRoundTranscript(ctx, req, keeper)
+		case types.QueryRequest:
+			return queryRequest(ctx, req, keeper)
 		default:
 			return nil, sdk.ErrUnknownRequest("unknown herb query endpoint")
 		}
@@ -47,7 +80,7 @@ func queryAggregatedCt(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([
 		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("coudn't get JSON ciphertext", err2.Error()))
 	}
 
-	resBytes, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryAggregatedCtRes{*ctJSON})
+	resBytes, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryAggregatedCtRes{CiphertextJSON: *ctJSON})
 	if err2 != nil {
 		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("could not marshal result to JSON", err2.Error()))
 	}
@@ -70,7 +103,7 @@ func queryGetAllCt(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byt
 		return nil, err
 	}
 
-	bz, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryAllCtRes{allCtJSON})
+	bz, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryAllCtRes{CiphertextShares: allCtJSON})
 	if err2 != nil {
 		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("could not marshal result to JSON", err2.Error()))
 	}
@@ -94,7 +127,7 @@ func queryAllDescryptionShares(ctx sdk.Context, req abci.RequestQuery, keeper Ke
 		return nil, err
 	}
 
-	resBytes, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryAllDescryptionSharesRes{allSharesJSON})
+	resBytes, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryAllDescryptionSharesRes{DecryptionShares: allSharesJSON})
 	if err2 != nil {
 		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("could not marshal result to JSON", err2.Error()))
 	}
@@ -109,8 +142,9 @@ func queryStage(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte,
 	}
 
 	stage := keeper.GetStage(ctx, round)
+	reason := keeper.FailReason(ctx, round)
 
-	res, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryStageRes{stage})
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryStageRes{Stage: stage, Reason: reason})
 	if err2 != nil {
 		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("stage marshaling failed", err2.Error()))
 	}
@@ -120,8 +154,15 @@ func queryStage(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte,
 
 func queryCurrentRound(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
 	round := keeper.CurrentRound(ctx)
+	collectingRound, decryptingRound := int64(-1), int64(-1)
+	if collecting, ok := keeper.CollectingRound(ctx); ok {
+		collectingRound = int64(collecting)
+	}
+	if decrypting, ok := keeper.DecryptingRound(ctx); ok {
+		decryptingRound = int64(decrypting)
+	}
 
-	res, err := codec.MarshalJSONIndent(keeper.cdc, types.QueryCurrentRoundRes{round})
+	res, err := codec.MarshalJSONIndent(keeper.cdc, types.QueryCurrentRoundRes{Round: round, CollectingRound: collectingRound, DecryptingRound: decryptingRound})
 	if err != nil {
 		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("round marshaling failed", err.Error()))
 	}
@@ -130,24 +171,269 @@ func queryCurrentRound(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
 }
 
 func queryResult(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
+	var params types.QueryByRound
+	if err := keeper.cdc.UnmarshalJSON(req.Data, &params); err != nil {
+		return nil, sdk.ErrUnknownRequest(sdk.AppendMsgToErr("incorrectly formatted request data", err.Error()))
+	}
+	// negative round requests the latest result, the explicit round must be completed
+	var round uint64
+	if params.Round < 0 {
+		latest, err := keeper.LatestCompletedRound(ctx)
+		if err != nil {
+			return nil, err
+		}
+		round = latest
+	} else {
+		round = uint64(params.Round)
+		if stage := keeper.GetStage(ctx, round); stage != stageCompleted {
+			return nil, types.ErrRoundNotCompleted(round, stage)
+		}
+	}
+
+	randomBytes, err := keeper.RandomResult(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+
+	var transcriptHash []byte
+	if keeper.isRoundPruned(ctx, round) {
+		transcriptHash, _ = keeper.GetTranscriptHash(ctx, round)
+	}
+
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryResultRes{Random: randomBytes, TranscriptHash: transcriptHash})
+	if err2 != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("random results marshaling failed", err2.Error()))
+	}
+
+	return res, nil
+}
+
+func queryDKGPhase(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
+	phase := keeper.GetDKGPhase(ctx)
+
+	res, err := codec.MarshalJSONIndent(keeper.cdc, types.QueryDKGPhaseRes{Phase: phase})
+	if err != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("DKG phase marshaling failed", err.Error()))
+	}
+
+	return res, nil
+}
+
+func queryDKGParticipants(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
+	participants, err := keeper.GetDKGParticipants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryDKGParticipantsRes{Participants: participants, Threshold: keeper.GetDKGThreshold(ctx)})
+	if err2 != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("DKG participants marshaling failed", err2.Error()))
+	}
+
+	return res, nil
+}
+
+func queryDKGDeals(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
+	var params types.QueryDKGDealsParams
+	err := keeper.cdc.UnmarshalJSON(req.Data, &params)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(sdk.AppendMsgToErr("incorrectly formatted request data", err.Error()))
+	}
+
+	deals, err2 := keeper.GetDKGDeals(ctx, params.Recipient)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	res, err := codec.MarshalJSONIndent(keeper.cdc, types.QueryDKGDealsRes{Deals: deals})
+	if err != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("DKG deals marshaling failed", err.Error()))
+	}
+
+	return res, nil
+}
+
+func queryDKGMessages(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
+	var params types.QueryDKGMessagesParams
+	err := keeper.cdc.UnmarshalJSON(req.Data, &params)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(sdk.AppendMsgToErr("incorrectly formatted request data", err.Error()))
+	}
+
+	messages, err2 := keeper.GetDKGMessages(ctx, params.Phase)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	res, err := codec.MarshalJSONIndent(keeper.cdc, types.QueryDKGMessagesRes{Messages: messages})
+	if err != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("DKG messages marshaling failed", err.Error()))
+	}
+
+	return res, nil
+}
+
+func queryRefresh(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
+	keyHolders, err := keeper.GetVerificationKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deals, err := keeper.GetRefreshDeals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	complaints, err := keeper.GetRefreshComplaints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	justifications, err := keeper.GetRefreshJustifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := keeper.GetThresholdDecryption(ctx)
+	if err != nil {
+		return nil, err
+	}
+	encKeys := make([]string, len(keyHolders))
+	for i, kh := range keyHolders {
+		encKeys[i] = keeper.GetEncryptionKey(ctx, kh.Sender)
+	}
+
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryRefreshRes{
+		Phase:          keeper.GetRefreshPhase(ctx),
+		Number:         keeper.GetRefreshNumber(ctx),
+		KeysRound:      keeper.GetVerificationKeysRound(ctx),
+		Threshold:      threshold,
+		QUAL:           keeper.GetRefreshQUAL(ctx),
+		KeyHolders:     keyHolders,
+		PendingKeys:    keeper.GetPendingVerificationKeys(ctx),
+		EncryptionKeys: encKeys,
+		Deals:          deals,
+		Complaints:     complaints,
+		Justifications: justifications,
+	})
+	if err2 != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("refresh state marshaling failed", err2.Error()))
+	}
+
+	return res, nil
+}
+
+func queryEpoch(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
+	var params types.QueryEpochParams
+	err := keeper.cdc.UnmarshalJSON(req.Data, &params)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(sdk.AppendMsgToErr("incorrectly formatted request data", err.Error()))
+	}
+
+	number := keeper.CurrentKeyEpoch(ctx)
+	if params.Epoch >= 0 {
+		number = uint64(params.Epoch)
+	}
+	epoch, err2 := keeper.GetKeyEpoch(ctx, number)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	res, err := codec.MarshalJSONIndent(keeper.cdc, epoch)
+	if err != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("key epoch marshaling failed", err.Error()))
+	}
+
+	return res, nil
+}
+
+func queryRoundEpoch(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
 	round, err := getRoundFromQuery(ctx, req, keeper)
 	if err != nil {
 		return nil, err
 	}
-	
-	if round == keeper.CurrentRound(ctx) {
-		round = round - 1
+
+	epoch, err := keeper.GetRoundEpoch(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, epoch)
+	if err2 != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("key epoch marshaling failed", err2.Error()))
 	}
 
-	randomBytes, err := keeper.RandomResult(ctx, round)
+	return res, nil
+}
 
+func queryMissCounters(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
+	counters, err := keeper.GetMissCounters(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err2 := codec.MarshalJSONIndent(keeper.cdc, types.QueryResultRes{randomBytes})
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, counters)
 	if err2 != nil {
-		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("random results marshaling failed", err2.Error()))
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("miss counters marshaling failed", err2.Error()))
+	}
+
+	return res, nil
+}
+
+func queryRewardPool(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
+	res, err := codec.MarshalJSONIndent(keeper.cdc, keeper.GetRewardPool(ctx))
+	if err != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("rewards pool marshaling failed", err.Error()))
+	}
+
+	return res, nil
+}
+
+func queryEntropyProviders(ctx sdk.Context, keeper Keeper) ([]byte, sdk.Error) {
+	providers, err := keeper.GetEntropyProviders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, providers)
+	if err2 != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("entropy providers marshaling failed", err2.Error()))
+	}
+
+	return res, nil
+}
+
+func queryRoundTranscript(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
+	round, err := getRoundFromQuery(ctx, req, keeper)
+	if err != nil {
+		return nil, err
+	}
+	transcript, err := keeper.GetRoundTranscript(ctx, round)
+	if err != nil {
+		return nil, err
+	}
+	res, err2 := codec.MarshalJSONIndent(keeper.cdc, transcript)
+	if err2 != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("round transcript marshaling failed", err2.Error()))
+	}
+
+	return res, nil
+}
+
+func queryRequest(ctx sdk.Context, req abci.RequestQuery, keeper Keeper) ([]byte, sdk.Error) {
+	var params types.QueryRequestParams
+	err := keeper.cdc.UnmarshalJSON(req.Data, &params)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(sdk.AppendMsgToErr("incorrectly formatted request data", err.Error()))
+	}
+	request, err2 := keeper.GetRequest(ctx, params.ID)
+	if err2 != nil {
+		return nil, err2
+	}
+	var result []byte
+	if keeper.GetStage(ctx, request.Round) == stageCompleted {
+		if result, err2 = keeper.RandomResult(ctx, request.Round); err2 != nil {
+			return nil, err2
+		}
+	}
+	res, err := codec.MarshalJSONIndent(keeper.cdc, types.QueryRequestRes{Request: *request, Result: result})
+	if err != nil {
+		return nil, sdk.ErrInternal(sdk.AppendMsgToErr("randomness request marshaling failed", err.Error()))
 	}
 
 	return res, nil
@@ -15,6 +15,22 @@ func init() {
 func RegisterCodec(cdc *codec.Codec) {
 	cdc.RegisterConcrete(MsgSetCiphertextShare{}, "herb/MsgSetCiphertextShare", nil)
 	cdc.RegisterConcrete(MsgSetDecryptionShare{}, "herb/MsgSetDecryptionShare", nil)
+	cdc.RegisterConcrete(MsgDKGDeal{}, "herb/MsgDKGDeal", nil)
+	cdc.RegisterConcrete(MsgDKGResponse{}, "herb/MsgDKGResponse", nil)
+	cdc.RegisterConcrete(MsgDKGJustification{}, "herb/MsgDKGJustification", nil)
+	cdc.RegisterConcrete(MsgDKGSecretCommits{}, "herb/MsgDKGSecretCommits", nil)
+	cdc.RegisterConcrete(MsgDKGComplaintCommits{}, "herb/MsgDKGComplaintCommits", nil)
+	cdc.RegisterConcrete(MsgDKGReconstructCommits{}, "herb/MsgDKGReconstructCommits", nil)
+	cdc.RegisterConcrete(MsgStartRefresh{}, "herb/MsgStartRefresh", nil)
+	cdc.RegisterConcrete(MsgRefreshDeal{}, "herb/MsgRefreshDeal", nil)
+	cdc.RegisterConcrete(MsgRefreshComplaint{}, "herb/MsgRefreshComplaint", nil)
+	cdc.RegisterConcrete(MsgRefreshJustification{}, "herb/MsgRefreshJustification", nil)
+	cdc.RegisterConcrete(MsgSetEncryptionKey{}, "herb/MsgSetEncryptionKey", nil)
+	cdc.RegisterConcrete(MsgRegisterEntropyProvider{}, "herb/MsgRegisterEntropyProvider", nil)
+	cdc.RegisterConcrete(MsgDeregisterEntropyProvider{}, "herb/MsgDeregisterEntropyProvider", nil)
+	cdc.RegisterConcrete(MsgRequestRandomness{}, "herb/MsgRequestRandomness", nil)
+	cdc.RegisterConcrete(KeyHoldersChangeProposal{}, "herb/KeyHoldersChangeProposal", nil)
+	cdc.RegisterConcrete(InstanceParamsChangeProposal{}, "herb/InstanceParamsChangeProposal", nil)
 	cdc.RegisterConcrete(CiphertextShareJSON{}, "herb/CiphertextShareJSON", nil)
 	cdc.RegisterConcrete(CiphertextShare{}, "herb/CiphertextShare", nil)
 
@@ -0,0 +1,114 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.dedis.ch/kyber/v3"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+	"go.dedis.ch/protobuf"
+)
+
+// DKG phases. Each phase corresponds to one type of the Rabin DKG messages
+const (
+	DKGPhaseNone         = "dkgNone"
+	DKGPhaseDeals        = "dkgDeals"
+	DKGPhaseResponses    = "dkgResponses"
+	DKGPhaseJustify      = "dkgJustifications"
+	DKGPhaseCommits      = "dkgSecretCommits"
+	DKGPhaseComplaints   = "dkgComplaintCommits"
+	DKGPhaseReconstructs = "dkgReconstructCommits"
+	DKGPhaseCompleted    = "dkgCompleted"
+	DKGPhaseFailed       = "dkgFailed"
+)
+
+// DKGPhases lists DKG phases in the order they are passed
+var DKGPhases = []string{
+	DKGPhaseDeals,
+	DKGPhaseResponses,
+	DKGPhaseJustify,
+	DKGPhaseCommits,
+	DKGPhaseComplaints,
+	DKGPhaseReconstructs,
+	DKGPhaseCompleted,
+}
+
+// DKGParticipant is a future key holder which takes part in the distributed key generation
+// Participant's index in the DKG is its position in the participants list
+type DKGParticipant struct {
+	Address sdk.AccAddress
+	PubKey  kyber.Point // long-term public key used for the deals encryption and messages signing
+}
+
+type DKGParticipantJSON struct {
+	Address sdk.AccAddress `json:"address"`
+	PubKey  string         `json:"pub_key"`
+}
+
+func NewDKGParticipantJSON(p *DKGParticipant) (DKGParticipantJSON, sdk.Error) {
+	pubKey, err := kyberenc.PointToStringHex(P256, p.PubKey)
+	if err != nil {
+		return DKGParticipantJSON{}, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode participant's public key: %v", err))
+	}
+	return DKGParticipantJSON{
+		Address: p.Address,
+		PubKey:  pubKey,
+	}, nil
+}
+
+func (pJSON DKGParticipantJSON) Deserialize() (*DKGParticipant, sdk.Error) {
+	pubKey, err := kyberenc.StringHexToPoint(P256, pJSON.PubKey)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode participant's public key: %v", err))
+	}
+	return &DKGParticipant{
+		Address: pJSON.Address,
+		PubKey:  pubKey,
+	}, nil
+}
+
+func DKGParticipantArrayDeserialize(pJSONList []DKGParticipantJSON) ([]*DKGParticipant, sdk.Error) {
+	pList := make([]*DKGParticipant, len(pJSONList))
+	var err sdk.Error
+	for i, p := range pJSONList {
+		pList[i], err = p.Deserialize()
+		if err != nil {
+			return nil, sdk.ErrUnknownRequest(fmt.Sprintf("can't deserialize participants array: %v", err))
+		}
+	}
+	return pList, nil
+}
+
+// DKGDeal is an encrypted deal from the dealer (message sender) to the recipient
+type DKGDeal struct {
+	Recipient uint32 `json:"recipient"`
+	Data      []byte `json:"data"` // protobuf-encoded rabin Deal
+}
+
+// DKGMessages holds all messages which participant sent at some DKG phase
+type DKGMessages struct {
+	Index uint32   `json:"index"`
+	Data  [][]byte `json:"data"`
+}
+
+var dkgConstructors = newDKGConstructors()
+
+func newDKGConstructors() protobuf.Constructors {
+	var point kyber.Point
+	var scalar kyber.Scalar
+	cons := make(protobuf.Constructors)
+	cons[reflect.TypeOf(&point).Elem()] = func() interface{} { return P256.Point() }
+	cons[reflect.TypeOf(&scalar).Elem()] = func() interface{} { return P256.Scalar() }
+	return cons
+}
+
+// EncodeDKGMessage encodes rabin DKG structure (deal, response, etc.) to bytes
+func EncodeDKGMessage(msg interface{}) ([]byte, error) {
+	return protobuf.Encode(msg)
+}
+
+// DecodeDKGMessage decodes rabin DKG structure from bytes, msg must be a pointer to the structure
+func DecodeDKGMessage(bz []byte, msg interface{}) error {
+	return protobuf.DecodeWithConstructors(bz, msg, dkgConstructors)
+}
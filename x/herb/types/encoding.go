@@ -0,0 +1,279 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/proof/dleq"
+	"go.dedis.ch/kyber/v3/share"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+//this file defines the canonical binary encoding of the HERB shares. It's used in the messages and in the store,
+//the JSON forms are kept for the queries and genesis only.
+//
+//Points are P-256 points marshalled by kyber in the uncompressed form (65 bytes: 0x04 | X | Y),
+//scalars are 32-byte big-endian integers less than the group order. All parts have fixed size:
+//
+//	ciphertext:               A | B                              130 bytes
+//	CE proof:                 commitments V1 | V2 | responses   194 bytes
+//	                          r | x in the kyber proof package order
+//	decryption share:         uint32 big-endian index | V        69 bytes, index fits int32
+//	decryption share message: V                                  65 bytes, index is the sender's key holder ID
+//	DLEQ proof:               C | R | VG | VH                    194 bytes
+//	ciphertext share record:  ciphertext | CE proof              324 bytes
+//	decryption share record:  decryption share | DLEQ proof      263 bytes
+//
+//Addresses of the share senders aren't encoded: a share belongs to the message sender,
+//the store keeps it under the sender's key.
+
+var (
+	PointLen  = P256.PointLen()
+	ScalarLen = P256.ScalarLen()
+
+	CiphertextLen      = 2 * PointLen
+	CEProofLen         = 2*PointLen + 2*ScalarLen
+	DecryptionShareLen = 4 + PointLen
+	DLEQProofLen       = 2*ScalarLen + 2*PointLen
+)
+
+// EncodeCiphertext returns the canonical encoding of the elgamal ciphertext
+func EncodeCiphertext(ct *elgamal.Ciphertext) ([]byte, sdk.Error) {
+	bz := make([]byte, 0, CiphertextLen)
+	bz, err := appendPoint(bz, ct.PointA)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode point A: %v", err))
+	}
+	bz, err = appendPoint(bz, ct.PointB)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode point B: %v", err))
+	}
+	return bz, nil
+}
+
+// DecodeCiphertext decodes the elgamal ciphertext, points must be on the curve
+func DecodeCiphertext(bz []byte) (*elgamal.Ciphertext, sdk.Error) {
+	if len(bz) != CiphertextLen {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("ciphertext must be %v bytes long, got %v", CiphertextLen, len(bz)))
+	}
+	pointA, err := decodePoint(bz[:PointLen])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode point A: %v", err))
+	}
+	pointB, err := decodePoint(bz[PointLen:])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode point B: %v", err))
+	}
+	return &elgamal.Ciphertext{PointA: pointA, PointB: pointB}, nil
+}
+
+// EncodeDecShare returns the canonical encoding of the decryption share with its index
+func EncodeDecShare(ds *share.PubShare) ([]byte, sdk.Error) {
+	if ds.I < 0 || uint64(ds.I) > math.MaxInt32 {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("decryption share index is out of range: %v", ds.I))
+	}
+	bz := make([]byte, 4, DecryptionShareLen)
+	binary.BigEndian.PutUint32(bz, uint32(ds.I))
+	bz, err := appendPoint(bz, ds.V)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode decryption share: %v", err))
+	}
+	return bz, nil
+}
+
+// DecodeDecShare decodes the decryption share
+func DecodeDecShare(bz []byte) (*share.PubShare, sdk.Error) {
+	if len(bz) != DecryptionShareLen {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("decryption share must be %v bytes long, got %v", DecryptionShareLen, len(bz)))
+	}
+	index := binary.BigEndian.Uint32(bz[:4])
+	if uint64(index) > math.MaxInt32 {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("decryption share index is out of range: %v", index))
+	}
+	v, err := decodePoint(bz[4:])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode decryption share: %v", err))
+	}
+	return &share.PubShare{I: int(index), V: v}, nil
+}
+
+// EncodeDecSharePoint returns the encoding of the decryption share point sent in the message without the index
+func EncodeDecSharePoint(v kyber.Point) ([]byte, sdk.Error) {
+	bz, err := appendPoint(make([]byte, 0, PointLen), v)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode decryption share: %v", err))
+	}
+	return bz, nil
+}
+
+// DecodeDecSharePoint decodes the decryption share point sent in the message
+func DecodeDecSharePoint(bz []byte) (kyber.Point, sdk.Error) {
+	if len(bz) != PointLen {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("decryption share must be %v bytes long, got %v", PointLen, len(bz)))
+	}
+	v, err := decodePoint(bz)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode decryption share: %v", err))
+	}
+	return v, nil
+}
+
+// EncodeDLEQProof returns the canonical encoding of the DLEQ proof
+func EncodeDLEQProof(proof *dleq.Proof) ([]byte, sdk.Error) {
+	if proof == nil {
+		return nil, sdk.ErrUnknownRequest("DLEQ proof is missing")
+	}
+	bz := make([]byte, 0, DLEQProofLen)
+	bz, err := appendScalar(bz, proof.C)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode DLEQ proof challenge: %v", err))
+	}
+	bz, err = appendScalar(bz, proof.R)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode DLEQ proof response: %v", err))
+	}
+	bz, err = appendPoint(bz, proof.VG)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode DLEQ proof commitment VG: %v", err))
+	}
+	bz, err = appendPoint(bz, proof.VH)
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode DLEQ proof commitment VH: %v", err))
+	}
+	return bz, nil
+}
+
+// DecodeDLEQProof decodes the DLEQ proof
+func DecodeDLEQProof(bz []byte) (*dleq.Proof, sdk.Error) {
+	if len(bz) != DLEQProofLen {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("DLEQ proof must be %v bytes long, got %v", DLEQProofLen, len(bz)))
+	}
+	c, err := decodeScalar(bz[:ScalarLen])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode DLEQ proof challenge: %v", err))
+	}
+	r, err := decodeScalar(bz[ScalarLen : 2*ScalarLen])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode DLEQ proof response: %v", err))
+	}
+	vg, err := decodePoint(bz[2*ScalarLen : 2*ScalarLen+PointLen])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode DLEQ proof commitment VG: %v", err))
+	}
+	vh, err := decodePoint(bz[2*ScalarLen+PointLen:])
+	if err != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode DLEQ proof commitment VH: %v", err))
+	}
+	return &dleq.Proof{C: c, R: r, VG: vg, VH: vh}, nil
+}
+
+// ValidateCEProofEncoding checks the CE proof size, the proof itself is checked against the common key by the keeper
+func ValidateCEProofEncoding(bz []byte) sdk.Error {
+	if len(bz) != CEProofLen {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("CE proof must be %v bytes long, got %v", CEProofLen, len(bz)))
+	}
+	return nil
+}
+
+// EncodeCiphertextShare returns the ciphertext share record
+func EncodeCiphertextShare(ctShare *CiphertextShare) ([]byte, sdk.Error) {
+	if err := ValidateCEProofEncoding(ctShare.CEproof); err != nil {
+		return nil, err
+	}
+	ctBytes, err := EncodeCiphertext(&ctShare.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return append(ctBytes, ctShare.CEproof...), nil
+}
+
+// DecodeCiphertextShare decodes the ciphertext share record of the entropy provider
+func DecodeCiphertextShare(bz []byte, entropyProvider sdk.AccAddress) (*CiphertextShare, sdk.Error) {
+	if len(bz) != CiphertextLen+CEProofLen {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("ciphertext share must be %v bytes long, got %v", CiphertextLen+CEProofLen, len(bz)))
+	}
+	ct, err := DecodeCiphertext(bz[:CiphertextLen])
+	if err != nil {
+		return nil, err
+	}
+	ceProof := make([]byte, CEProofLen)
+	copy(ceProof, bz[CiphertextLen:])
+	return &CiphertextShare{Ciphertext: *ct, CEproof: ceProof, EntropyProvider: entropyProvider}, nil
+}
+
+// EncodeDecryptionShare returns the decryption share record
+func EncodeDecryptionShare(ds *DecryptionShare) ([]byte, sdk.Error) {
+	dsBytes, err := EncodeDecShare(&ds.DecShare)
+	if err != nil {
+		return nil, err
+	}
+	proofBytes, err := EncodeDLEQProof(ds.DLEQproof)
+	if err != nil {
+		return nil, err
+	}
+	return append(dsBytes, proofBytes...), nil
+}
+
+// DecodeDecryptionShare decodes the decryption share record of the key holder
+func DecodeDecryptionShare(bz []byte, keyHolder sdk.AccAddress) (*DecryptionShare, sdk.Error) {
+	if len(bz) != DecryptionShareLen+DLEQProofLen {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("decryption share must be %v bytes long, got %v", DecryptionShareLen+DLEQProofLen, len(bz)))
+	}
+	ds, err := DecodeDecShare(bz[:DecryptionShareLen])
+	if err != nil {
+		return nil, err
+	}
+	proof, err := DecodeDLEQProof(bz[DecryptionShareLen:])
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptionShare{DecShare: *ds, DLEQproof: proof, KeyHolderAddr: keyHolder}, nil
+}
+
+func appendPoint(bz []byte, p kyber.Point) ([]byte, error) {
+	if p == nil {
+		return nil, fmt.Errorf("point is missing")
+	}
+	pBytes, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if len(pBytes) != PointLen {
+		return nil, fmt.Errorf("point must be %v bytes long, got %v", PointLen, len(pBytes))
+	}
+	return append(bz, pBytes...), nil
+}
+
+func appendScalar(bz []byte, s kyber.Scalar) ([]byte, error) {
+	if s == nil {
+		return nil, fmt.Errorf("scalar is missing")
+	}
+	sBytes, err := s.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if len(sBytes) != ScalarLen {
+		return nil, fmt.Errorf("scalar must be %v bytes long, got %v", ScalarLen, len(sBytes))
+	}
+	return append(bz, sBytes...), nil
+}
+
+func decodePoint(bz []byte) (kyber.Point, error) {
+	p := P256.Point()
+	if err := p.UnmarshalBinary(bz); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func decodeScalar(bz []byte) (kyber.Scalar, error) {
+	s := P256.Scalar()
+	if err := s.UnmarshalBinary(bz); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
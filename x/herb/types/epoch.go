@@ -0,0 +1,33 @@
+package types
+
+import (
+	"fmt"
+)
+
+// KeyEpoch is the key holders set with its common key and thresholds
+// Epoch is changed when the key holders set is replaced or key holders' shares are refreshed,
+// each round uses keys of a single epoch
+type KeyEpoch struct {
+	Number               uint64                `json:"number"`
+	StartRound           uint64                `json:"start_round"` // first round which uses the epoch keys
+	CommonPublicKey      string                `json:"common_public_key"`
+	KeyHolders           []VerificationKeyJSON `json:"key_holders"`
+	ThresholdCiphertexts uint64                `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64                `json:"threshold_decryption"`
+}
+
+func (e KeyEpoch) String() string {
+	str := fmt.Sprintf("Epoch: %v\nStart round: %v\nCommon key: %v\nThreshold ciphertexts: %v\nThreshold decryption: %v\n",
+		e.Number, e.StartRound, e.CommonPublicKey, e.ThresholdCiphertexts, e.ThresholdDecryption)
+	for _, kh := range e.KeyHolders {
+		str = str + fmt.Sprintf("Key holder %v: %v %v\n", kh.KeyHolderID, kh.Sender, kh.Key)
+	}
+	return str
+}
+
+// KeyHoldersChange is the new key holders set whose DKG isn't completed
+type KeyHoldersChange struct {
+	Participants         []DKGParticipantJSON `json:"participants"`
+	ThresholdCiphertexts uint64               `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64               `json:"threshold_decryption"`
+}
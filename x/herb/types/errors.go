@@ -0,0 +1,49 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// herb module error codes
+const (
+	CodeInvalidDecryptionShare sdk.CodeType = 101
+	CodeRoundNotCompleted      sdk.CodeType = 102
+	CodeRoundPruned            sdk.CodeType = 103
+	CodeUnknownInstance        sdk.CodeType = 104
+	CodeWrongRound             sdk.CodeType = 105
+)
+
+// ErrInvalidDecryptionShare is returned when the decryption share's DLEQ proof isn't correct,
+// key holder is slashed for the invalid share
+func ErrInvalidDecryptionShare(msg string) sdk.Error {
+	return sdk.NewError(DefaultCodespace, CodeInvalidDecryptionShare, msg)
+}
+
+// ErrRoundNotCompleted is returned when the randomness of the round isn't finalized yet or the round is failed
+func ErrRoundNotCompleted(round uint64, stage string) sdk.Error {
+	return sdk.NewError(DefaultCodespace, CodeRoundNotCompleted, fmt.Sprintf("round %v isn't completed, stage: %v", round, stage))
+}
+
+// ErrRoundPruned is returned when shares of the round are deleted by the pruning,
+// only the result and the transcript hash of the round are kept
+func ErrRoundPruned(round uint64) sdk.Error {
+	return sdk.NewError(DefaultCodespace, CodeRoundPruned, fmt.Sprintf("round %v is pruned, only the result and the transcript hash are kept", round))
+}
+
+// ErrUnknownInstance is returned for messages and queries to the beacon instance which isn't defined in the genesis
+func ErrUnknownInstance(id string) sdk.Error {
+	return sdk.NewError(DefaultCodespace, CodeUnknownInstance, fmt.Sprintf("beacon instance %q doesn't exist", id))
+}
+
+// ErrWrongRound is returned for the share sent to the round which doesn't collect such shares,
+// usually the share is built for the round which is already finished
+func ErrWrongRound(round uint64, msg string) sdk.Error {
+	return sdk.NewError(DefaultCodespace, CodeWrongRound, fmt.Sprintf("round %v: %v", round, msg))
+}
+
+// ErrInvalidMsgRound is returned for the share message without the round
+func ErrInvalidMsgRound() sdk.Error {
+	return sdk.NewError(DefaultCodespace, CodeWrongRound, "the round of the share is required")
+}
@@ -0,0 +1,37 @@
+package types
+
+// herb module event types
+const (
+	EventTypeShareAccepted = "share_accepted"
+	EventTypeStageChanged  = "stage_changed"
+	EventTypeRandomResult  = "random_result"
+	EventTypeRequest       = "randomness_requested"
+
+	EventTypeDKGMessage           = "dkg_message"
+	EventTypeDKGPhaseChanged      = "dkg_phase_changed"
+	EventTypeRefreshMessage       = "refresh_message"
+	EventTypeRefreshPhaseChanged  = "refresh_phase_changed"
+	EventTypeProviderRegistered   = "entropy_provider_registered"
+	EventTypeProviderDeregistered = "entropy_provider_deregistered"
+
+	AttributeKeyRound      = "round"
+	AttributeKeySender     = "sender"
+	AttributeKeyShareType  = "share_type"
+	AttributeKeyShareCount = "share_count" // number of the round's shares of the same type including the accepted one
+	AttributeKeyOldStage   = "old_stage"
+	AttributeKeyNewStage   = "new_stage"
+	AttributeKeyResult     = "result"   // hex encoded random result
+	AttributeKeyInstance   = "instance" // beacon instance ID, it's omitted for the default instance
+	AttributeKeyRequestID  = "request_id"
+
+	AttributeKeyMessageType   = "message_type" // type of the accepted DKG or refresh message
+	AttributeKeyPhase         = "phase"        // DKG or refresh phase after the message is processed
+	AttributeKeyOldPhase      = "old_phase"
+	AttributeKeyNewPhase      = "new_phase"
+	AttributeKeyRefreshNumber = "refresh_number"
+	AttributeKeyBond          = "bond"
+
+	AttributeValueCiphertext      = "ciphertext"
+	AttributeValueDecryptionShare = "decryption_share"
+	AttributeValueCategory        = ModuleName
+)
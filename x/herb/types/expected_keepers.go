@@ -0,0 +1,44 @@
+package types
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/slashing"
+	stakingexported "github.com/cosmos/cosmos-sdk/x/staking/exported"
+	supplyexported "github.com/cosmos/cosmos-sdk/x/supply/exported"
+)
+
+// StakingKeeper defines the staking functions used to punish key holders, key holders are bonded as validators
+type StakingKeeper interface {
+	Validator(ctx sdk.Context, address sdk.ValAddress) stakingexported.ValidatorI
+	Slash(ctx sdk.Context, consAddr sdk.ConsAddress, infractionHeight int64, power int64, slashFactor sdk.Dec)
+	Jail(ctx sdk.Context, consAddr sdk.ConsAddress)
+}
+
+// SlashingKeeper defines the slashing functions used to keep the jailed key holders out of the validator set until the jail duration expires
+type SlashingKeeper interface {
+	IterateValidatorSigningInfos(ctx sdk.Context, handler func(address sdk.ConsAddress, info slashing.ValidatorSigningInfo) (stop bool))
+	SetValidatorSigningInfo(ctx sdk.Context, address sdk.ConsAddress, info slashing.ValidatorSigningInfo)
+}
+
+// SupplyKeeper defines the supply functions used to fund the rewards pool, pay rewards and keep the entropy providers' bonds
+type SupplyKeeper interface {
+	GetModuleAddress(moduleName string) sdk.AccAddress
+	GetModuleAccount(ctx sdk.Context, moduleName string) supplyexported.ModuleAccountI
+	SendCoinsFromModuleToAccount(ctx sdk.Context, senderModule string, recipientAddr sdk.AccAddress, amt sdk.Coins) sdk.Error
+	SendCoinsFromModuleToModule(ctx sdk.Context, senderModule, recipientModule string, amt sdk.Coins) sdk.Error
+	SendCoinsFromAccountToModule(ctx sdk.Context, senderAddr sdk.AccAddress, recipientModule string, amt sdk.Coins) sdk.Error
+}
+
+// BankKeeper defines the bank functions used to get the rewards pool and fee collector balances
+type BankKeeper interface {
+	GetCoins(ctx sdk.Context, addr sdk.AccAddress) sdk.Coins
+}
+
+// HerbHooks defines event hooks for the other modules which react to the random beacon rounds
+// Hooks are called in the same transaction or block which changes the round stage, a panic in the hook fails the transaction.
+// Hooks are called for every beacon instance with the instance ID, it's empty for the default instance
+type HerbHooks interface {
+	AfterCiphertextStageClosed(ctx sdk.Context, instance string, round uint64)         // ciphertext shares are aggregated, decryption shares collecting is started
+	AfterRoundCompleted(ctx sdk.Context, instance string, round uint64, result []byte) // random result of the round is available
+	AfterRoundFailed(ctx sdk.Context, instance string, round uint64)                   // round is aborted without result
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MultiHerbHooks combines multiple herb hooks, all hook functions are run in array sequence
+type MultiHerbHooks []HerbHooks
+
+// NewMultiHerbHooks creates the combined hooks
+func NewMultiHerbHooks(hooks ...HerbHooks) MultiHerbHooks {
+	return hooks
+}
+
+// AfterCiphertextStageClosed runs the hook of each hooks set
+func (h MultiHerbHooks) AfterCiphertextStageClosed(ctx sdk.Context, instance string, round uint64) {
+	for i := range h {
+		h[i].AfterCiphertextStageClosed(ctx, instance, round)
+	}
+}
+
+// AfterRoundCompleted runs the hook of each hooks set
+func (h MultiHerbHooks) AfterRoundCompleted(ctx sdk.Context, instance string, round uint64, result []byte) {
+	for i := range h {
+		h[i].AfterRoundCompleted(ctx, instance, round, result)
+	}
+}
+
+// AfterRoundFailed runs the hook of each hooks set
+func (h MultiHerbHooks) AfterRoundFailed(ctx sdk.Context, instance string, round uint64) {
+	for i := range h {
+		h[i].AfterRoundFailed(ctx, instance, round)
+	}
+}
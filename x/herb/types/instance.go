@@ -0,0 +1,38 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// DefaultInstance is ID of the default beacon instance, messages and queries without the instance ID go to it
+const DefaultInstance = ""
+
+// MaxInstanceIDLength is the maximum length of the beacon instance ID
+const MaxInstanceIDLength = 32
+
+// InstanceMsg is a message sent to the particular beacon instance
+type InstanceMsg interface {
+	sdk.Msg
+	GetInstance() string
+}
+
+// ValidateInstanceID checks that the instance ID consists of lowercase letters, digits, '-' and '_'
+func ValidateInstanceID(id string) sdk.Error {
+	if len(id) > MaxInstanceIDLength {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("instance ID is longer than %v characters", MaxInstanceIDLength))
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("invalid character %q in the instance ID %q", c, id))
+		}
+	}
+	return nil
+}
+
+// InstanceGenesisState is the genesis state of the beacon instance besides the default one, it can't contain other instances
+type InstanceGenesisState struct {
+	ID    string       `json:"id"`
+	State GenesisState `json:"state"`
+}
@@ -1,5 +1,9 @@
 package types
 
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
 const (
 	// ModuleName is a herb module name for routing messages/queries/etc.
 	ModuleName = "herb"
@@ -9,4 +13,9 @@ const (
 	StoreKey   = ModuleName
 	CtStoreKey = "herbCtStoreKey"
 	DsStoreKey = "herbDecSharesKey"
+	// ProviderBondPoolName is the module account which keeps the entropy providers' bonds
+	ProviderBondPoolName = "herbProviderBonds"
+
+	// DefaultCodespace is the herb module codespace for errors
+	DefaultCodespace sdk.CodespaceType = ModuleName
 )
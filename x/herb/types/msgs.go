@@ -4,22 +4,32 @@ import (
 	"fmt"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.dedis.ch/kyber/v3/share"
 )
 
 // RouterKey is they name of the herb module
 const RouterKey = ModuleName
 
 // MsgSetCiphertextshare defines message for the first HERB phase (collecting ciphertext share)
+// Ciphertext and CE proof use the canonical binary encoding, see encoding.go
 type MsgSetCiphertextShare struct {
-	CiphertextShare CiphertextShareJSON `json:"ciphertext_share"`
-	Sender          sdk.AccAddress      `json:"sender"`
+	Round      uint64         `json:"round"`     // round collecting ciphertext shares the share is sent to
+	HasRound   bool           `json:"has_round"` // the round is set, amino drops zero numbers, so round 0 is told apart from the missing round by the flag
+	Ciphertext []byte         `json:"ciphertext"`
+	CEProof    []byte         `json:"ce_proof"`
+	Sender     sdk.AccAddress `json:"sender"`
+	Instance   string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
 }
 
 // NewMsgSetCiphertextShare is a constructor for set ciphertext share message (first HERB phase)
-func NewMsgSetCiphertextShare(ctShare CiphertextShareJSON, sender sdk.AccAddress) MsgSetCiphertextShare {
+func NewMsgSetCiphertextShare(instance string, round uint64, ciphertext []byte, ceProof []byte, sender sdk.AccAddress) MsgSetCiphertextShare {
 	return MsgSetCiphertextShare{
-		CiphertextShare: ctShare,
-		Sender:          sender,
+		Instance:   instance,
+		Round:      round,
+		HasRound:   true,
+		Ciphertext: ciphertext,
+		CEProof:    ceProof,
+		Sender:     sender,
 	}
 }
 
@@ -29,25 +39,40 @@ func (msg MsgSetCiphertextShare) Route() string { return RouterKey }
 // Type returns the action
 func (msg MsgSetCiphertextShare) Type() string { return "setCiphertextShare" }
 
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgSetCiphertextShare) GetInstance() string { return msg.Instance }
+
 // ValidateBasic runs stateless checks on the message
 func (msg MsgSetCiphertextShare) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if !msg.HasRound {
+		return ErrInvalidMsgRound()
+	}
 	if msg.Sender.Empty() {
 		return sdk.ErrInvalidAddress("missing entropy provider address")
 	}
 
-	ctShare, err := msg.CiphertextShare.Deserialize()
-
-	if err != nil {
+	if _, err := msg.CiphertextShare(); err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't deserialaize ciphertext: %v", err))
 	}
 
-	if !ctShare.EntropyProvider.Equals(msg.Sender) {
-		return sdk.ErrUnauthorized("entropy provider and sender are not equal")
-	}
-
 	return nil
 }
 
+// CiphertextShare decodes the ciphertext share, the sender is its entropy provider
+func (msg MsgSetCiphertextShare) CiphertextShare() (*CiphertextShare, sdk.Error) {
+	if err := ValidateCEProofEncoding(msg.CEProof); err != nil {
+		return nil, err
+	}
+	ct, err := DecodeCiphertext(msg.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return &CiphertextShare{Ciphertext: *ct, CEproof: msg.CEProof, EntropyProvider: msg.Sender}, nil
+}
+
 // GetSignBytes encodes the message for signing
 func (msg MsgSetCiphertextShare) GetSignBytes() []byte {
 	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
@@ -58,16 +83,26 @@ func (msg MsgSetCiphertextShare) GetSigners() []sdk.AccAddress {
 	return []sdk.AccAddress{msg.Sender}
 }
 
-// MsgSetCiphertextShare defines message for the first HERB phase (collecting ciphertext share)
+// MsgSetDecryptionShare defines message for the second HERB phase (collecting decryption shares)
+// Decryption share and DLEQ proof use the canonical binary encoding, see encoding.go
+// The share index isn't sent, it's the registered key holder ID of the sender
 type MsgSetDecryptionShare struct {
-	DecryptionShare DecryptionShareJSON `json:"decryption_share"`
-	Sender          sdk.AccAddress      `json:"sender"`
+	Round           uint64         `json:"round"`            // round collecting decryption shares the share is sent to
+	HasRound        bool           `json:"has_round"`        // the round is set, see MsgSetCiphertextShare
+	DecryptionShare []byte         `json:"decryption_share"` // share point only
+	DLEQProof       []byte         `json:"dleq_proof"`
+	Sender          sdk.AccAddress `json:"sender"`
+	Instance        string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
 }
 
-// NewMsgSetCiphertextShare is a constructor for set ciphertext share message (first HERB phase)
-func NewMsgSetDecryptionShare(decryptionShare DecryptionShareJSON, sender sdk.AccAddress) MsgSetDecryptionShare {
+// NewMsgSetDecryptionShare is a constructor for set decryption share message (second HERB phase)
+func NewMsgSetDecryptionShare(instance string, round uint64, decryptionShare []byte, dleqProof []byte, sender sdk.AccAddress) MsgSetDecryptionShare {
 	return MsgSetDecryptionShare{
+		Instance:        instance,
+		Round:           round,
+		HasRound:        true,
 		DecryptionShare: decryptionShare,
+		DLEQProof:       dleqProof,
 		Sender:          sender,
 	}
 }
@@ -78,25 +113,42 @@ func (msg MsgSetDecryptionShare) Route() string { return RouterKey }
 // Type returns the action
 func (msg MsgSetDecryptionShare) Type() string { return "setDecryptionShare" }
 
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgSetDecryptionShare) GetInstance() string { return msg.Instance }
+
 // ValidateBasic runs stateless checks on the message
 func (msg MsgSetDecryptionShare) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if !msg.HasRound {
+		return ErrInvalidMsgRound()
+	}
 	if msg.Sender.Empty() {
 		return sdk.ErrInvalidAddress("missing key holder address")
 	}
 
-	share, err := msg.DecryptionShare.Deserialize()
-
-	if err != nil {
+	if _, err := msg.DecShare(); err != nil {
 		return sdk.ErrUnknownRequest(fmt.Sprintf("can't deserialaize decryption share: %v", err))
 	}
 
-	if !share.KeyHolderAddr.Equals(msg.Sender) {
-		return sdk.ErrUnauthorized("key holder and sender are not equal")
-	}
-
 	return nil
 }
 
+// DecShare decodes the decryption share, the sender is its key holder
+// The index is left zero, the keeper sets it to the sender's key holder ID
+func (msg MsgSetDecryptionShare) DecShare() (*DecryptionShare, sdk.Error) {
+	v, err := DecodeDecSharePoint(msg.DecryptionShare)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := DecodeDLEQProof(msg.DLEQProof)
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptionShare{DecShare: share.PubShare{V: v}, DLEQproof: proof, KeyHolderAddr: msg.Sender}, nil
+}
+
 // GetSignBytes encodes the message for signing
 func (msg MsgSetDecryptionShare) GetSignBytes() []byte {
 	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
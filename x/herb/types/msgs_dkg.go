@@ -0,0 +1,320 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	rabin "go.dedis.ch/kyber/v3/share/dkg/rabin"
+)
+
+// this file defines messages for the on-chain distributed key generation (Rabin DKG)
+// all DKG structures are sent protobuf-encoded, see EncodeDKGMessage
+
+// MsgDKGDeal defines message with the dealer's deals for all other participants
+type MsgDKGDeal struct {
+	Deals    []DKGDeal      `json:"deals"`
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDKGDeal is a constructor for DKG deals message
+func NewMsgDKGDeal(instance string, deals []DKGDeal, sender sdk.AccAddress) MsgDKGDeal {
+	return MsgDKGDeal{
+		Instance: instance,
+		Deals:    deals,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDKGDeal) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDKGDeal) Type() string { return "dkgDeal" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDKGDeal) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDKGDeal) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing dealer address")
+	}
+	for _, deal := range msg.Deals {
+		if err := DecodeDKGMessage(deal.Data, &rabin.Deal{}); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode deal: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDKGDeal) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDKGDeal) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgDKGResponse defines message with the participant's responses to the received deals
+type MsgDKGResponse struct {
+	Responses [][]byte       `json:"responses"`
+	Sender    sdk.AccAddress `json:"sender"`
+	Instance  string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDKGResponse is a constructor for DKG responses message
+func NewMsgDKGResponse(instance string, responses [][]byte, sender sdk.AccAddress) MsgDKGResponse {
+	return MsgDKGResponse{
+		Instance:  instance,
+		Responses: responses,
+		Sender:    sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDKGResponse) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDKGResponse) Type() string { return "dkgResponse" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDKGResponse) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDKGResponse) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing participant address")
+	}
+	for _, resp := range msg.Responses {
+		if err := DecodeDKGMessage(resp, &rabin.Response{}); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode response: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDKGResponse) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDKGResponse) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgDKGJustification defines message with the dealer's justifications for the complaints about its deal
+type MsgDKGJustification struct {
+	Justifications [][]byte       `json:"justifications"`
+	Sender         sdk.AccAddress `json:"sender"`
+	Instance       string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDKGJustification is a constructor for DKG justifications message
+func NewMsgDKGJustification(instance string, justifications [][]byte, sender sdk.AccAddress) MsgDKGJustification {
+	return MsgDKGJustification{
+		Instance:       instance,
+		Justifications: justifications,
+		Sender:         sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDKGJustification) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDKGJustification) Type() string { return "dkgJustification" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDKGJustification) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDKGJustification) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing dealer address")
+	}
+	for _, j := range msg.Justifications {
+		if err := DecodeDKGMessage(j, &rabin.Justification{}); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode justification: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDKGJustification) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDKGJustification) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgDKGSecretCommits defines message with the dealer's secret commits and the QUAL set seen by the dealer
+// SecretCommits is empty if the dealer's own deal wasn't certified
+type MsgDKGSecretCommits struct {
+	SecretCommits []byte         `json:"secret_commits"`
+	QUAL          []uint32       `json:"qual"`
+	Sender        sdk.AccAddress `json:"sender"`
+	Instance      string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDKGSecretCommits is a constructor for DKG secret commits message
+func NewMsgDKGSecretCommits(instance string, secretCommits []byte, qual []uint32, sender sdk.AccAddress) MsgDKGSecretCommits {
+	return MsgDKGSecretCommits{
+		Instance:      instance,
+		SecretCommits: secretCommits,
+		QUAL:          qual,
+		Sender:        sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDKGSecretCommits) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDKGSecretCommits) Type() string { return "dkgSecretCommits" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDKGSecretCommits) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDKGSecretCommits) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing dealer address")
+	}
+	if len(msg.SecretCommits) > 0 {
+		if err := DecodeDKGMessage(msg.SecretCommits, &rabin.SecretCommits{}); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode secret commits: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDKGSecretCommits) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDKGSecretCommits) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgDKGComplaintCommits defines message with the participant's complaints about invalid secret commits
+type MsgDKGComplaintCommits struct {
+	Complaints [][]byte       `json:"complaints"`
+	Sender     sdk.AccAddress `json:"sender"`
+	Instance   string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDKGComplaintCommits is a constructor for DKG complaint commits message
+func NewMsgDKGComplaintCommits(instance string, complaints [][]byte, sender sdk.AccAddress) MsgDKGComplaintCommits {
+	return MsgDKGComplaintCommits{
+		Instance:   instance,
+		Complaints: complaints,
+		Sender:     sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDKGComplaintCommits) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDKGComplaintCommits) Type() string { return "dkgComplaintCommits" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDKGComplaintCommits) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDKGComplaintCommits) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing participant address")
+	}
+	for _, cc := range msg.Complaints {
+		if err := DecodeDKGMessage(cc, &rabin.ComplaintCommits{}); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode complaint commits: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDKGComplaintCommits) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDKGComplaintCommits) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgDKGReconstructCommits defines message with the participant's shares of the dealers which got complaints
+type MsgDKGReconstructCommits struct {
+	Reconstructs [][]byte       `json:"reconstructs"`
+	Sender       sdk.AccAddress `json:"sender"`
+	Instance     string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDKGReconstructCommits is a constructor for DKG reconstruct commits message
+func NewMsgDKGReconstructCommits(instance string, reconstructs [][]byte, sender sdk.AccAddress) MsgDKGReconstructCommits {
+	return MsgDKGReconstructCommits{
+		Instance:     instance,
+		Reconstructs: reconstructs,
+		Sender:       sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDKGReconstructCommits) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDKGReconstructCommits) Type() string { return "dkgReconstructCommits" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDKGReconstructCommits) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDKGReconstructCommits) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing participant address")
+	}
+	for _, rc := range msg.Reconstructs {
+		if err := DecodeDKGMessage(rc, &rabin.ReconstructCommits{}); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode reconstruct commits: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDKGReconstructCommits) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDKGReconstructCommits) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
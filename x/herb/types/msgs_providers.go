@@ -0,0 +1,96 @@
+package types
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// this file defines messages for the entropy providers registry
+
+// MsgRegisterEntropyProvider defines message which registers the sender as an entropy provider
+// The bond defined by the params is taken from the sender
+type MsgRegisterEntropyProvider struct {
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgRegisterEntropyProvider is a constructor for register entropy provider message
+func NewMsgRegisterEntropyProvider(instance string, sender sdk.AccAddress) MsgRegisterEntropyProvider {
+	return MsgRegisterEntropyProvider{
+		Instance: instance,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgRegisterEntropyProvider) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgRegisterEntropyProvider) Type() string { return "registerEntropyProvider" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgRegisterEntropyProvider) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgRegisterEntropyProvider) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing entropy provider address")
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgRegisterEntropyProvider) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgRegisterEntropyProvider) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgDeregisterEntropyProvider defines message which removes the sender from the entropy providers and returns its bond
+type MsgDeregisterEntropyProvider struct {
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgDeregisterEntropyProvider is a constructor for deregister entropy provider message
+func NewMsgDeregisterEntropyProvider(instance string, sender sdk.AccAddress) MsgDeregisterEntropyProvider {
+	return MsgDeregisterEntropyProvider{
+		Instance: instance,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgDeregisterEntropyProvider) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgDeregisterEntropyProvider) Type() string { return "deregisterEntropyProvider" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgDeregisterEntropyProvider) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgDeregisterEntropyProvider) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing entropy provider address")
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgDeregisterEntropyProvider) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgDeregisterEntropyProvider) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
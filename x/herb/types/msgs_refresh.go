@@ -0,0 +1,276 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+)
+
+// this file defines messages for the proactive refresh of the key holders' shares
+
+// MsgStartRefresh defines message which starts the key holders' shares refresh
+type MsgStartRefresh struct {
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgStartRefresh is a constructor for start refresh message
+func NewMsgStartRefresh(instance string, sender sdk.AccAddress) MsgStartRefresh {
+	return MsgStartRefresh{
+		Instance: instance,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgStartRefresh) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgStartRefresh) Type() string { return "startRefresh" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgStartRefresh) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgStartRefresh) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing key holder address")
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgStartRefresh) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgStartRefresh) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgRefreshDeal defines message with the key holder's sharing of zero
+type MsgRefreshDeal struct {
+	Deal     RefreshDeal    `json:"deal"`
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgRefreshDeal is a constructor for refresh deal message
+func NewMsgRefreshDeal(instance string, deal RefreshDeal, sender sdk.AccAddress) MsgRefreshDeal {
+	return MsgRefreshDeal{
+		Instance: instance,
+		Deal:     deal,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgRefreshDeal) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgRefreshDeal) Type() string { return "refreshDeal" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgRefreshDeal) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgRefreshDeal) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing dealer address")
+	}
+	if len(msg.Deal.Commitments) == 0 || len(msg.Deal.Shares) == 0 {
+		return sdk.ErrUnknownRequest("refresh deal is empty")
+	}
+	if _, err := msg.Deal.PubPoly(len(msg.Deal.Commitments), len(msg.Deal.Shares)); err != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("invalid refresh deal: %v", err))
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgRefreshDeal) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgRefreshDeal) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgRefreshComplaint defines message with the dealers whose shares for the sender are incorrect
+// Key holder sends the message with empty list if all shares are correct
+type MsgRefreshComplaint struct {
+	Dealers  []sdk.AccAddress `json:"dealers"`
+	Sender   sdk.AccAddress   `json:"sender"`
+	Instance string           `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgRefreshComplaint is a constructor for refresh complaint message
+func NewMsgRefreshComplaint(instance string, dealers []sdk.AccAddress, sender sdk.AccAddress) MsgRefreshComplaint {
+	return MsgRefreshComplaint{
+		Instance: instance,
+		Dealers:  dealers,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgRefreshComplaint) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgRefreshComplaint) Type() string { return "refreshComplaint" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgRefreshComplaint) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgRefreshComplaint) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing key holder address")
+	}
+	for _, dealer := range msg.Dealers {
+		if dealer.Empty() {
+			return sdk.ErrInvalidAddress("empty dealer address")
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgRefreshComplaint) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgRefreshComplaint) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// MsgSetEncryptionKey defines message which registers the key holder's long-term encryption key for the refresh deals
+type MsgSetEncryptionKey struct {
+	Key      string         `json:"key"` // hex-encoded public key
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgSetEncryptionKey is a constructor for set encryption key message
+func NewMsgSetEncryptionKey(instance string, key string, sender sdk.AccAddress) MsgSetEncryptionKey {
+	return MsgSetEncryptionKey{
+		Instance: instance,
+		Key:      key,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgSetEncryptionKey) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgSetEncryptionKey) Type() string { return "setEncryptionKey" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgSetEncryptionKey) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgSetEncryptionKey) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing key holder address")
+	}
+	if err := ValidateEncryptionKey(msg.Key); err != nil {
+		return sdk.ErrUnknownRequest(err.Error())
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgSetEncryptionKey) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgSetEncryptionKey) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
+
+// ValidateEncryptionKey checks that the hex-encoded encryption key is a valid point
+func ValidateEncryptionKey(key string) error {
+	point, err := kyberenc.StringHexToPoint(P256, key)
+	if err != nil {
+		return fmt.Errorf("can't decode encryption key: %v", err)
+	}
+	if point.Equal(P256.Point().Null()) {
+		return fmt.Errorf("encryption key is the null point")
+	}
+	return nil
+}
+
+// MsgRefreshJustification defines message with the dealer's shares for the key holders who complained about them
+type MsgRefreshJustification struct {
+	Justifications []RefreshJustification `json:"justifications"`
+	Sender         sdk.AccAddress         `json:"sender"`
+	Instance       string                 `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgRefreshJustification is a constructor for refresh justification message
+func NewMsgRefreshJustification(instance string, justifications []RefreshJustification, sender sdk.AccAddress) MsgRefreshJustification {
+	return MsgRefreshJustification{
+		Instance:       instance,
+		Justifications: justifications,
+		Sender:         sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgRefreshJustification) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgRefreshJustification) Type() string { return "refreshJustification" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgRefreshJustification) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgRefreshJustification) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing dealer address")
+	}
+	if len(msg.Justifications) == 0 {
+		return sdk.ErrUnknownRequest("refresh justification is empty")
+	}
+	for _, j := range msg.Justifications {
+		if j.KeyHolder.Empty() {
+			return sdk.ErrInvalidAddress("empty key holder address")
+		}
+		if _, err := kyberenc.StringHexToScalar(P256, j.Share); err != nil {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("can't decode share: %v", err))
+		}
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgRefreshJustification) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgRefreshJustification) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
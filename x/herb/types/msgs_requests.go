@@ -0,0 +1,59 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// this file defines messages for the on-demand randomness
+
+// MsgRequestRandomness defines message which requests the random value, in the on-demand mode it opens the next round
+// The optional fee is moved from the sender to the rewards pool
+type MsgRequestRandomness struct {
+	Fee      sdk.Coins      `json:"fee"`
+	Sender   sdk.AccAddress `json:"sender"`
+	Instance string         `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewMsgRequestRandomness is a constructor for request randomness message
+func NewMsgRequestRandomness(instance string, fee sdk.Coins, sender sdk.AccAddress) MsgRequestRandomness {
+	return MsgRequestRandomness{
+		Instance: instance,
+		Fee:      fee,
+		Sender:   sender,
+	}
+}
+
+// Route returns the name of the module
+func (msg MsgRequestRandomness) Route() string { return RouterKey }
+
+// Type returns the action
+func (msg MsgRequestRandomness) Type() string { return "requestRandomness" }
+
+// GetInstance returns ID of the beacon instance the message is sent to
+func (msg MsgRequestRandomness) GetInstance() string { return msg.Instance }
+
+// ValidateBasic runs stateless checks on the message
+func (msg MsgRequestRandomness) ValidateBasic() sdk.Error {
+	if err := ValidateInstanceID(msg.Instance); err != nil {
+		return err
+	}
+	if msg.Sender.Empty() {
+		return sdk.ErrInvalidAddress("missing requester address")
+	}
+	if !msg.Fee.Empty() && !msg.Fee.IsValid() {
+		return sdk.ErrInvalidCoins(fmt.Sprintf("invalid fee: %v", msg.Fee))
+	}
+	return nil
+}
+
+// GetSignBytes encodes the message for signing
+func (msg MsgRequestRandomness) GetSignBytes() []byte {
+	return sdk.MustSortJSON(ModuleCdc.MustMarshalJSON(msg))
+}
+
+// GetSigners defines whose signature is required
+func (msg MsgRequestRandomness) GetSigners() []sdk.AccAddress {
+	return []sdk.AccAddress{msg.Sender}
+}
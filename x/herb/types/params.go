@@ -0,0 +1,219 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/params"
+)
+
+// DefaultParamspace defines the default herb module parameter subspace
+const DefaultParamspace = ModuleName
+
+// Parameter store keys
+var (
+	KeyCiphertextDeadline  = []byte("CiphertextDeadline")
+	KeyDecryptionDeadline  = []byte("DecryptionDeadline")
+	KeyDKGPhaseDeadline    = []byte("DKGPhaseDeadline")
+	KeyRefreshEpoch        = []byte("RefreshEpoch")
+	KeyMissWindow          = []byte("MissWindow")
+	KeyMaxMissedRounds     = []byte("MaxMissedRounds")
+	KeySlashFractionMiss   = []byte("SlashFractionMiss")
+	KeySlashFractionShare  = []byte("SlashFractionInvalidShare")
+	KeyJailDuration        = []byte("JailDuration")
+	KeyProviderReward      = []byte("EntropyProviderReward")
+	KeyKeyHolderReward     = []byte("KeyHolderReward")
+	KeyRewardFeeShare      = []byte("RewardFeeShare")
+	KeyRestrictedProviders = []byte("RestrictedProviders")
+	KeyMaxProviders        = []byte("MaxEntropyProviders")
+	KeyProviderBond        = []byte("EntropyProviderBond")
+	KeyRoundsRetention     = []byte("RoundsRetention")
+	KeyPipelinedRounds     = []byte("PipelinedRounds")
+	KeyOnDemandRounds      = []byte("OnDemandRounds")
+)
+
+// Params defines the HERB round parameters which are stored in the params subspace
+type Params struct {
+	CiphertextDeadline int64 `json:"ciphertext_deadline"` // max number of blocks for the ciphertext collecting stage
+	DecryptionDeadline int64 `json:"decryption_deadline"` // max number of blocks for the decryption shares collecting stage
+	DKGPhaseDeadline   int64 `json:"dkg_phase_deadline"`  // max number of blocks for each DKG and key refresh phase
+	RefreshEpoch       int64 `json:"refresh_epoch"`       // number of rounds between key refreshes, 0 disables periodic refresh
+
+	MissWindow                int64         `json:"miss_window"`                  // number of rounds in the missed decryption shares window, 0 disables the penalty
+	MaxMissedRounds           int64         `json:"max_missed_rounds"`            // key holder is slashed and jailed if it misses more rounds in the window
+	SlashFractionMiss         sdk.Dec       `json:"slash_fraction_miss"`          // penalty for missed rounds
+	SlashFractionInvalidShare sdk.Dec       `json:"slash_fraction_invalid_share"` // penalty for decryption share with incorrect DLEQ proof
+	JailDuration              time.Duration `json:"jail_duration"`                // punished key holder can't unjail its validator during this time
+
+	EntropyProviderReward sdk.Coins `json:"entropy_provider_reward"` // paid to each entropy provider of the completed round
+	KeyHolderReward       sdk.Coins `json:"key_holder_reward"`       // paid to each key holder whose decryption share was counted
+	RewardFeeShare        sdk.Dec   `json:"reward_fee_share"`        // share of the collected fees moved to the herb rewards pool each block
+
+	RestrictedProviders bool      `json:"restricted_providers"`  // only registered entropy providers can send ciphertext shares
+	MaxEntropyProviders int64     `json:"max_entropy_providers"` // max size of the entropy providers registry, 0 means unlimited
+	EntropyProviderBond sdk.Coins `json:"entropy_provider_bond"` // bond required for the entropy provider registration
+
+	RoundsRetention int64 `json:"rounds_retention"` // shares of the rounds older than this number of rounds are pruned, 0 keeps all rounds
+	PipelinedRounds bool  `json:"pipelined_rounds"` // the next round collects ciphertext shares while the previous one collects decryption shares
+	OnDemandRounds  bool  `json:"on_demand_rounds"` // the next round is opened only if it's requested by MsgRequestRandomness
+}
+
+// ParamKeyTable returns the key table for the herb module
+func ParamKeyTable() params.KeyTable {
+	return params.NewKeyTable().RegisterParamSet(&Params{})
+}
+
+// NewParams creates a new Params instance
+func NewParams(ciphertextDeadline, decryptionDeadline, dkgPhaseDeadline, refreshEpoch, missWindow, maxMissedRounds int64,
+	slashFractionMiss, slashFractionInvalidShare sdk.Dec, jailDuration time.Duration, entropyProviderReward, keyHolderReward sdk.Coins, rewardFeeShare sdk.Dec,
+	restrictedProviders bool, maxEntropyProviders int64, entropyProviderBond sdk.Coins, roundsRetention int64, pipelinedRounds bool, onDemandRounds bool) Params {
+	return Params{
+		CiphertextDeadline:        ciphertextDeadline,
+		DecryptionDeadline:        decryptionDeadline,
+		DKGPhaseDeadline:          dkgPhaseDeadline,
+		RefreshEpoch:              refreshEpoch,
+		MissWindow:                missWindow,
+		MaxMissedRounds:           maxMissedRounds,
+		SlashFractionMiss:         slashFractionMiss,
+		SlashFractionInvalidShare: slashFractionInvalidShare,
+		JailDuration:              jailDuration,
+		EntropyProviderReward:     entropyProviderReward,
+		KeyHolderReward:           keyHolderReward,
+		RewardFeeShare:            rewardFeeShare,
+		RestrictedProviders:       restrictedProviders,
+		MaxEntropyProviders:       maxEntropyProviders,
+		EntropyProviderBond:       entropyProviderBond,
+		RoundsRetention:           roundsRetention,
+		PipelinedRounds:           pipelinedRounds,
+		OnDemandRounds:            onDemandRounds,
+	}
+}
+
+// DefaultParams returns default herb module parameters
+func DefaultParams() Params {
+	return Params{
+		CiphertextDeadline: 100,
+		DecryptionDeadline: 100,
+		DKGPhaseDeadline:   50,
+		RefreshEpoch:       0,
+
+		MissWindow:                100,
+		MaxMissedRounds:           50,
+		SlashFractionMiss:         sdk.NewDecWithPrec(1, 2),
+		SlashFractionInvalidShare: sdk.NewDecWithPrec(5, 2),
+		JailDuration:              10 * time.Minute,
+
+		EntropyProviderReward: sdk.NewCoins(),
+		KeyHolderReward:       sdk.NewCoins(),
+		RewardFeeShare:        sdk.ZeroDec(),
+
+		RestrictedProviders: false,
+		MaxEntropyProviders: 0,
+		EntropyProviderBond: sdk.NewCoins(),
+
+		RoundsRetention: 0,
+		PipelinedRounds: false,
+		OnDemandRounds:  false,
+	}
+}
+
+// ValidateParams checks that the round parameters are consistent
+func ValidateParams(p Params) error {
+	if p.CiphertextDeadline < 1 {
+		return fmt.Errorf("ciphertext collecting deadline must be positive, is %d", p.CiphertextDeadline)
+	}
+	if p.DecryptionDeadline < 1 {
+		return fmt.Errorf("decryption collecting deadline must be positive, is %d", p.DecryptionDeadline)
+	}
+	if p.DKGPhaseDeadline < 1 {
+		return fmt.Errorf("DKG phase deadline must be positive, is %d", p.DKGPhaseDeadline)
+	}
+	if p.RefreshEpoch < 0 {
+		return fmt.Errorf("refresh epoch can't be negative, is %d", p.RefreshEpoch)
+	}
+	if p.MissWindow < 0 {
+		return fmt.Errorf("miss window can't be negative, is %d", p.MissWindow)
+	}
+	if p.MissWindow > 0 && (p.MaxMissedRounds < 0 || p.MaxMissedRounds >= p.MissWindow) {
+		return fmt.Errorf("max missed rounds must be in [0, %d), is %d", p.MissWindow, p.MaxMissedRounds)
+	}
+	if p.SlashFractionMiss.IsNil() || p.SlashFractionMiss.IsNegative() || p.SlashFractionMiss.GT(sdk.OneDec()) {
+		return fmt.Errorf("slash fraction for missed rounds must be in [0, 1], is %v", p.SlashFractionMiss)
+	}
+	if p.SlashFractionInvalidShare.IsNil() || p.SlashFractionInvalidShare.IsNegative() || p.SlashFractionInvalidShare.GT(sdk.OneDec()) {
+		return fmt.Errorf("slash fraction for invalid shares must be in [0, 1], is %v", p.SlashFractionInvalidShare)
+	}
+	if p.JailDuration < 0 {
+		return fmt.Errorf("jail duration can't be negative, is %v", p.JailDuration)
+	}
+	if !p.EntropyProviderReward.IsValid() && !p.EntropyProviderReward.Empty() {
+		return fmt.Errorf("invalid entropy provider reward: %v", p.EntropyProviderReward)
+	}
+	if !p.KeyHolderReward.IsValid() && !p.KeyHolderReward.Empty() {
+		return fmt.Errorf("invalid key holder reward: %v", p.KeyHolderReward)
+	}
+	if p.RewardFeeShare.IsNil() || p.RewardFeeShare.IsNegative() || p.RewardFeeShare.GT(sdk.OneDec()) {
+		return fmt.Errorf("reward fee share must be in [0, 1], is %v", p.RewardFeeShare)
+	}
+	if p.MaxEntropyProviders < 0 {
+		return fmt.Errorf("max number of entropy providers can't be negative, is %d", p.MaxEntropyProviders)
+	}
+	if !p.EntropyProviderBond.IsValid() && !p.EntropyProviderBond.Empty() {
+		return fmt.Errorf("invalid entropy provider bond: %v", p.EntropyProviderBond)
+	}
+	if p.RoundsRetention < 0 {
+		return fmt.Errorf("rounds retention can't be negative, is %d", p.RoundsRetention)
+	}
+	return nil
+}
+
+func (p Params) String() string {
+	return fmt.Sprintf(`HERB Params:
+  Ciphertext Deadline: %d
+  Decryption Deadline: %d
+  DKG Phase Deadline:  %d
+  Refresh Epoch:       %d
+  Miss Window:         %d
+  Max Missed Rounds:   %d
+  Slash Fraction Miss: %s
+  Slash Fraction Invalid Share: %s
+  Jail Duration:       %s
+  Entropy Provider Reward: %s
+  Key Holder Reward:   %s
+  Reward Fee Share:    %s
+  Restricted Providers: %t
+  Max Entropy Providers: %d
+  Entropy Provider Bond: %s
+  Rounds Retention:    %d
+  Pipelined Rounds:    %t
+  On-Demand Rounds:    %t
+`, p.CiphertextDeadline, p.DecryptionDeadline, p.DKGPhaseDeadline, p.RefreshEpoch,
+		p.MissWindow, p.MaxMissedRounds, p.SlashFractionMiss, p.SlashFractionInvalidShare, p.JailDuration,
+		p.EntropyProviderReward, p.KeyHolderReward, p.RewardFeeShare,
+		p.RestrictedProviders, p.MaxEntropyProviders, p.EntropyProviderBond, p.RoundsRetention, p.PipelinedRounds, p.OnDemandRounds)
+}
+
+// ParamSetPairs implements params.ParamSet
+func (p *Params) ParamSetPairs() params.ParamSetPairs {
+	return params.ParamSetPairs{
+		{Key: KeyCiphertextDeadline, Value: &p.CiphertextDeadline},
+		{Key: KeyDecryptionDeadline, Value: &p.DecryptionDeadline},
+		{Key: KeyDKGPhaseDeadline, Value: &p.DKGPhaseDeadline},
+		{Key: KeyRefreshEpoch, Value: &p.RefreshEpoch},
+		{Key: KeyMissWindow, Value: &p.MissWindow},
+		{Key: KeyMaxMissedRounds, Value: &p.MaxMissedRounds},
+		{Key: KeySlashFractionMiss, Value: &p.SlashFractionMiss},
+		{Key: KeySlashFractionShare, Value: &p.SlashFractionInvalidShare},
+		{Key: KeyJailDuration, Value: &p.JailDuration},
+		{Key: KeyProviderReward, Value: &p.EntropyProviderReward},
+		{Key: KeyKeyHolderReward, Value: &p.KeyHolderReward},
+		{Key: KeyRewardFeeShare, Value: &p.RewardFeeShare},
+		{Key: KeyRestrictedProviders, Value: &p.RestrictedProviders},
+		{Key: KeyMaxProviders, Value: &p.MaxEntropyProviders},
+		{Key: KeyProviderBond, Value: &p.EntropyProviderBond},
+		{Key: KeyRoundsRetention, Value: &p.RoundsRetention},
+		{Key: KeyPipelinedRounds, Value: &p.PipelinedRounds},
+		{Key: KeyOnDemandRounds, Value: &p.OnDemandRounds},
+	}
+}
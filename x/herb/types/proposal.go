@@ -0,0 +1,171 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	govtypes "github.com/cosmos/cosmos-sdk/x/gov/types"
+)
+
+const (
+	// ProposalTypeKeyHoldersChange defines the type for a KeyHoldersChangeProposal
+	ProposalTypeKeyHoldersChange = "KeyHoldersChange"
+	// ProposalTypeInstanceParamsChange defines the type for an InstanceParamsChangeProposal
+	ProposalTypeInstanceParamsChange = "InstanceParamsChange"
+)
+
+// Assert herb proposals implement govtypes.Content at compile-time
+var _ govtypes.Content = KeyHoldersChangeProposal{}
+var _ govtypes.Content = InstanceParamsChangeProposal{}
+
+func init() {
+	govtypes.RegisterProposalType(ProposalTypeKeyHoldersChange)
+	govtypes.RegisterProposalTypeCodec(KeyHoldersChangeProposal{}, "herb/KeyHoldersChangeProposal")
+	govtypes.RegisterProposalType(ProposalTypeInstanceParamsChange)
+	govtypes.RegisterProposalTypeCodec(InstanceParamsChangeProposal{}, "herb/InstanceParamsChangeProposal")
+}
+
+// KeyHoldersChangeProposal replaces the key holders set
+// New key holders run the DKG after the proposal is passed, the new common key and verification keys
+// are used since the first round started after the DKG completion (new key epoch)
+type KeyHoldersChangeProposal struct {
+	Title                string               `json:"title"`
+	Description          string               `json:"description"`
+	Participants         []DKGParticipantJSON `json:"participants"`
+	ThresholdCiphertexts uint64               `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64               `json:"threshold_decryption"`
+	Instance             string               `json:"instance,omitempty"` // beacon instance ID, empty for the default instance
+}
+
+// NewKeyHoldersChangeProposal is a constructor for the key holders change proposal
+func NewKeyHoldersChangeProposal(instance, title, description string, participants []DKGParticipantJSON, thresholdCiphertexts, thresholdDecryption uint64) KeyHoldersChangeProposal {
+	return KeyHoldersChangeProposal{
+		Instance:             instance,
+		Title:                title,
+		Description:          description,
+		Participants:         participants,
+		ThresholdCiphertexts: thresholdCiphertexts,
+		ThresholdDecryption:  thresholdDecryption,
+	}
+}
+
+// GetTitle returns the title of the proposal
+func (p KeyHoldersChangeProposal) GetTitle() string { return p.Title }
+
+// GetDescription returns the description of the proposal
+func (p KeyHoldersChangeProposal) GetDescription() string { return p.Description }
+
+// ProposalRoute returns the routing key of the proposal
+func (p KeyHoldersChangeProposal) ProposalRoute() string { return RouterKey }
+
+// ProposalType returns the type of the proposal
+func (p KeyHoldersChangeProposal) ProposalType() string { return ProposalTypeKeyHoldersChange }
+
+// ValidateBasic validates the key holders change proposal
+func (p KeyHoldersChangeProposal) ValidateBasic() sdk.Error {
+	if err := govtypes.ValidateAbstract(DefaultCodespace, p); err != nil {
+		return err
+	}
+	if err := ValidateInstanceID(p.Instance); err != nil {
+		return err
+	}
+	if len(p.Participants) == 0 {
+		return sdk.ErrUnknownRequest("key holders list is empty")
+	}
+	if p.ThresholdCiphertexts < 1 {
+		return sdk.ErrUnknownRequest("threshold for ciphertext shares must be positive")
+	}
+	if p.ThresholdDecryption < 1 || p.ThresholdDecryption > uint64(len(p.Participants)) {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("invalid decryption threshold %v for %v key holders", p.ThresholdDecryption, len(p.Participants)))
+	}
+	seen := make(map[string]bool)
+	for _, participant := range p.Participants {
+		if participant.Address.Empty() {
+			return sdk.ErrInvalidAddress("key holder address can't be empty")
+		}
+		if seen[participant.Address.String()] {
+			return sdk.ErrUnknownRequest(fmt.Sprintf("duplicate key holder %v", participant.Address))
+		}
+		seen[participant.Address.String()] = true
+	}
+	if _, err := DKGParticipantArrayDeserialize(p.Participants); err != nil {
+		return err
+	}
+	return nil
+}
+
+// String implements the Stringer interface
+func (p KeyHoldersChangeProposal) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf(`Key Holders Change Proposal:
+  Title:                 %s
+  Description:           %s
+  Instance:              %s
+  Threshold ciphertexts: %d
+  Threshold decryption:  %d
+  Key holders:
+`, p.Title, p.Description, p.Instance, p.ThresholdCiphertexts, p.ThresholdDecryption))
+	for _, participant := range p.Participants {
+		b.WriteString(fmt.Sprintf("    %s %s\n", participant.Address, participant.PubKey))
+	}
+	return b.String()
+}
+
+// InstanceParamsChangeProposal replaces params of the beacon instance besides the default one
+// Params of the other instances are kept in the instance store and aren't reached by the param change proposals,
+// params of the default instance are changed by the param change proposals to the herb subspace
+type InstanceParamsChangeProposal struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Instance    string `json:"instance"` // beacon instance ID, can't be empty
+	Params      Params `json:"params"`
+}
+
+// NewInstanceParamsChangeProposal is a constructor for the instance params change proposal
+func NewInstanceParamsChangeProposal(instance, title, description string, params Params) InstanceParamsChangeProposal {
+	return InstanceParamsChangeProposal{
+		Instance:    instance,
+		Title:       title,
+		Description: description,
+		Params:      params,
+	}
+}
+
+// GetTitle returns the title of the proposal
+func (p InstanceParamsChangeProposal) GetTitle() string { return p.Title }
+
+// GetDescription returns the description of the proposal
+func (p InstanceParamsChangeProposal) GetDescription() string { return p.Description }
+
+// ProposalRoute returns the routing key of the proposal
+func (p InstanceParamsChangeProposal) ProposalRoute() string { return RouterKey }
+
+// ProposalType returns the type of the proposal
+func (p InstanceParamsChangeProposal) ProposalType() string { return ProposalTypeInstanceParamsChange }
+
+// ValidateBasic validates the instance params change proposal
+func (p InstanceParamsChangeProposal) ValidateBasic() sdk.Error {
+	if err := govtypes.ValidateAbstract(DefaultCodespace, p); err != nil {
+		return err
+	}
+	if p.Instance == DefaultInstance {
+		return sdk.ErrUnknownRequest("params of the default instance are changed by the param change proposal")
+	}
+	if err := ValidateInstanceID(p.Instance); err != nil {
+		return err
+	}
+	if err := ValidateParams(p.Params); err != nil {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("invalid params: %v", err))
+	}
+	return nil
+}
+
+// String implements the Stringer interface
+func (p InstanceParamsChangeProposal) String() string {
+	return fmt.Sprintf(`Instance Params Change Proposal:
+  Title:       %s
+  Description: %s
+  Instance:    %s
+%s`, p.Title, p.Description, p.Instance, p.Params)
+}
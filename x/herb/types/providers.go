@@ -0,0 +1,17 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// EntropyProvider is the registered entropy provider with its bond
+type EntropyProvider struct {
+	Address sdk.AccAddress `json:"address"`
+	Bond    sdk.Coins      `json:"bond"` // returned when the provider is deregistered
+}
+
+func (p EntropyProvider) String() string {
+	return fmt.Sprintf("%v: bond %v", p.Address, p.Bond)
+}
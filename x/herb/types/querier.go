@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/corestario/HERB/x/herb/elgamal"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 const (
@@ -14,6 +15,18 @@ const (
 	QueryStage                = "queryStage"
 	QueryCurrentRound         = "queryCurrentRound"
 	QueryResult               = "queryResult"
+	QueryDKGPhase             = "queryDKGPhase"
+	QueryDKGParticipants      = "queryDKGParticipants"
+	QueryDKGDeals             = "queryDKGDeals"
+	QueryDKGMessages          = "queryDKGMessages"
+	QueryRefresh              = "queryRefresh"
+	QueryEpoch                = "queryEpoch"
+	QueryRoundEpoch           = "queryRoundEpoch"
+	QueryMissCounters         = "queryMissCounters"
+	QueryRewardPool           = "queryRewardPool"
+	QueryEntropyProviders     = "queryEntropyProviders"
+	QueryRoundTranscript      = "queryRoundTranscript"
+	QueryRequest              = "queryRequest"
 )
 
 type QueryByRound struct {
@@ -27,8 +40,12 @@ func NewQueryByRound(round int64) QueryByRound {
 	}
 }
 
+// QueryCurrentRoundRes holds the latest started round and the rounds accepting shares, -1 if no round accepts them
+// With pipelined rounds the collecting round is the next one after the decrypting round
 type QueryCurrentRoundRes struct {
-	Round uint64 `json:"round"`
+	Round           uint64 `json:"round"`
+	CollectingRound int64  `json:"collecting_round"`
+	DecryptingRound int64  `json:"decrypting_round"`
 }
 
 func (r QueryCurrentRoundRes) String() string {
@@ -36,7 +53,8 @@ func (r QueryCurrentRoundRes) String() string {
 }
 
 type QueryStageRes struct {
-	Stage string `json:"stage"`
+	Stage  string `json:"stage"`
+	Reason string `json:"reason,omitempty"` // set only for the failed rounds
 }
 
 func (r QueryStageRes) String() string {
@@ -44,7 +62,8 @@ func (r QueryStageRes) String() string {
 }
 
 type QueryResultRes struct {
-	Random []byte `json:"random_value"`
+	Random         []byte `json:"random_value"`
+	TranscriptHash []byte `json:"transcript_hash,omitempty"` // set for the pruned rounds
 }
 
 func (r QueryResultRes) String() string {
@@ -84,3 +103,89 @@ func (r QueryAllDescryptionSharesRes) String() string {
 	str = str + fmt.Sprintf("Total shares: %v\n", len(r.DecryptionShares))
 	return str
 }
+
+type QueryDKGPhaseRes struct {
+	Phase string `json:"phase"`
+}
+
+func (r QueryDKGPhaseRes) String() string {
+	return r.Phase
+}
+
+type QueryDKGParticipantsRes struct {
+	Participants []DKGParticipantJSON `json:"participants"`
+	Threshold    uint64               `json:"threshold"`
+}
+
+func (r QueryDKGParticipantsRes) String() string {
+	str := fmt.Sprintf("Threshold: %v\n", r.Threshold)
+	for i, p := range r.Participants {
+		str = str + fmt.Sprintf("%v: %v %v\n", i, p.Address.String(), p.PubKey)
+	}
+	return str
+}
+
+// QueryDKGDealsParams defines params for querying deals addressed to the recipient
+type QueryDKGDealsParams struct {
+	Recipient uint32 `json:"recipient"`
+}
+
+type QueryDKGDealsRes struct {
+	Deals [][]byte `json:"deals"`
+}
+
+// QueryDKGMessagesParams defines params for querying all messages sent at the DKG phase
+type QueryDKGMessagesParams struct {
+	Phase string `json:"phase"`
+}
+
+type QueryDKGMessagesRes struct {
+	Messages []DKGMessages `json:"messages"`
+}
+
+// QueryRefreshRes contains the state of the key holders' shares refresh
+type QueryRefreshRes struct {
+	Phase          string                      `json:"phase"`
+	Number         uint64                      `json:"number"`     // number of started refreshes
+	KeysRound      uint64                      `json:"keys_round"` // round since which the current verification keys are used
+	Threshold      uint64                      `json:"threshold"`
+	QUAL           []sdk.AccAddress            `json:"qual"` // dealers whose deals are applied
+	KeyHolders     []VerificationKeyJSON       `json:"key_holders"`
+	PendingKeys    []VerificationKeyJSON       `json:"pending_keys"`
+	EncryptionKeys []string                    `json:"encryption_keys"` // in the key holders order, empty if the key isn't registered
+	Deals          []RefreshDealJSON           `json:"deals"`
+	Complaints     []RefreshComplaintJSON      `json:"complaints"`
+	Justifications []RefreshJustificationsJSON `json:"justifications"`
+}
+
+func (r QueryRefreshRes) String() string {
+	str := fmt.Sprintf("Phase: %v\nRefresh number: %v\nKeys are used since round: %v\nDeals: %v\nComplaints: %v\nJustifications: %v\n",
+		r.Phase, r.Number, r.KeysRound, len(r.Deals), len(r.Complaints), len(r.Justifications))
+	for _, addr := range r.QUAL {
+		str = str + fmt.Sprintf("QUAL: %v\n", addr.String())
+	}
+	return str
+}
+
+// QueryEpochParams defines the key epoch number for the epoch query, -1 means the current epoch
+type QueryEpochParams struct {
+	Epoch int64 `json:"epoch"`
+}
+
+// QueryRequestParams defines the request ID for the randomness request query
+type QueryRequestParams struct {
+	ID uint64 `json:"id"`
+}
+
+// QueryRequestRes contains the randomness request and the result of its round, the result is empty until the round is completed
+type QueryRequestRes struct {
+	Request RandomnessRequest `json:"request"`
+	Result  []byte            `json:"result,omitempty"`
+}
+
+func (r QueryRequestRes) String() string {
+	if len(r.Result) == 0 {
+		return fmt.Sprintf("%v\nresult: pending", r.Request)
+	}
+	return fmt.Sprintf("%v\nresult: %X", r.Request, r.Result)
+}
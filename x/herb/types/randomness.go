@@ -0,0 +1,40 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// RandomnessKeeper is the read-only randomness API for the consumer modules
+// Only results of the completed rounds are returned, randomness is derived for the consumer module and purpose,
+// so different consumers never get the same bytes for the same round
+type RandomnessKeeper interface {
+	// GetRandomness returns randomness of the completed round derived for the module and purpose
+	GetRandomness(ctx sdk.Context, moduleName string, purpose string, round uint64) ([]byte, sdk.Error)
+	// LatestCompletedRound returns the last round with the finalized result
+	LatestCompletedRound(ctx sdk.Context) (uint64, sdk.Error)
+}
+
+// DeriveRandomness derives 32 bytes of randomness from the round result for the module and purpose
+// Module name and purpose are length-prefixed, so different pairs can't produce the same input
+func DeriveRandomness(result []byte, moduleName string, purpose string, round uint64) []byte {
+	h := sha256.New()
+	h.Write([]byte(ModuleName))
+	writeLengthPrefixed(h, []byte(moduleName))
+	writeLengthPrefixed(h, []byte(purpose))
+	roundBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(roundBytes, round)
+	h.Write(roundBytes)
+	h.Write(result)
+	return h.Sum(nil)
+}
+
+func writeLengthPrefixed(h hash.Hash, data []byte) {
+	lenBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(lenBytes, uint64(len(data)))
+	h.Write(lenBytes)
+	h.Write(data)
+}
@@ -0,0 +1,173 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/encrypt/ecies"
+	"go.dedis.ch/kyber/v3/share"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+)
+
+// Key refresh phases. Each key holder shares zero with a random polynomial at the deals phase,
+// key holders complain about incorrect shares at the complaints phase,
+// dealers reveal the complained shares at the justifications phase,
+// new verification keys are pending until the next round starts.
+const (
+	RefreshPhaseNone           = "refreshNone"
+	RefreshPhaseDeals          = "refreshDeals"
+	RefreshPhaseComplaints     = "refreshComplaints"
+	RefreshPhaseJustifications = "refreshJustifications"
+	RefreshPhasePending        = "refreshPending"
+)
+
+// EncryptionKey is the key holder's long-term public key, refresh deals are encrypted with it.
+// It must not be derived from the key holder's share, otherwise the holder of an old share could decrypt the new ones.
+type EncryptionKey struct {
+	Address sdk.AccAddress `json:"address"`
+	Key     string         `json:"key"` // hex-encoded public key
+}
+
+// RefreshDeal is the key holder's sharing of zero
+// Commitments are hex-encoded commitments of the polynomial coefficients, the first one must be the null point.
+// Shares[i] is the ECIES-encrypted share for the i-th key holder in the verification keys list,
+// it's encrypted with the key holder's encryption key.
+type RefreshDeal struct {
+	Commitments []string `json:"commitments"`
+	Shares      [][]byte `json:"shares"`
+}
+
+// NewRefreshDeal creates a random sharing of zero for the key holders with the threshold t,
+// encKeys[i] is the encryption key of keyHolders[i]. The polynomial is returned for the justifications
+func NewRefreshDeal(keyHolders []*VerificationKey, encKeys []kyber.Point, t int) (*RefreshDeal, *share.PriPoly, error) {
+	if len(encKeys) != len(keyHolders) {
+		return nil, nil, fmt.Errorf("wrong number of encryption keys: %v, expected: %v", len(encKeys), len(keyHolders))
+	}
+	poly := share.NewPriPoly(P256, t, P256.Scalar().Zero(), P256.RandomStream())
+	_, commits := poly.Commit(nil).Info()
+	deal := &RefreshDeal{
+		Commitments: make([]string, len(commits)),
+		Shares:      make([][]byte, len(keyHolders)),
+	}
+	var err error
+	for i, c := range commits {
+		if deal.Commitments[i], err = kyberenc.PointToStringHex(P256, c); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, kh := range keyHolders {
+		shareBytes, err := poly.Eval(kh.KeyHolderID).V.MarshalBinary()
+		if err != nil {
+			return nil, nil, err
+		}
+		if deal.Shares[i], err = ecies.Encrypt(P256, encKeys[i], shareBytes, nil); err != nil {
+			return nil, nil, err
+		}
+	}
+	return deal, poly, nil
+}
+
+// PubPoly returns the public polynomial of the deal
+// It checks that the deal shares zero with the polynomial of the threshold t for n key holders
+func (deal RefreshDeal) PubPoly(t int, n int) (*share.PubPoly, error) {
+	if len(deal.Commitments) != t {
+		return nil, fmt.Errorf("wrong number of commitments: %v, expected: %v", len(deal.Commitments), t)
+	}
+	if len(deal.Shares) != n {
+		return nil, fmt.Errorf("wrong number of shares: %v, expected: %v", len(deal.Shares), n)
+	}
+	commits := make([]kyber.Point, t)
+	for i, c := range deal.Commitments {
+		point, err := kyberenc.StringHexToPoint(P256, c)
+		if err != nil {
+			return nil, fmt.Errorf("can't decode commitment: %v", err)
+		}
+		commits[i] = point
+	}
+	if !commits[0].Equal(P256.Point().Null()) {
+		return nil, fmt.Errorf("deal doesn't share zero")
+	}
+	return share.NewPubPoly(P256, nil, commits), nil
+}
+
+// DecryptShare decrypts and verifies the share of the key holder with the given position in the verification keys list,
+// privateKey is the key holder's encryption private key
+func (deal RefreshDeal) DecryptShare(privateKey kyber.Scalar, pos int, id int, t int, n int) (kyber.Scalar, error) {
+	pubPoly, err := deal.PubPoly(t, n)
+	if err != nil {
+		return nil, err
+	}
+	shareBytes, err := ecies.Decrypt(P256, privateKey, deal.Shares[pos], nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't decrypt share: %v", err)
+	}
+	s := P256.Scalar()
+	if err := s.UnmarshalBinary(shareBytes); err != nil {
+		return nil, fmt.Errorf("can't decode share: %v", err)
+	}
+	if !pubPoly.Check(&share.PriShare{I: id, V: s}) {
+		return nil, fmt.Errorf("share doesn't match the commitments")
+	}
+	return s, nil
+}
+
+// RefreshJustification reveals the dealer's share for the key holder who complained about it
+type RefreshJustification struct {
+	KeyHolder sdk.AccAddress `json:"key_holder"`
+	Share     string         `json:"share"` // hex-encoded share
+}
+
+// NewRefreshJustification reveals the share of the key holder from the dealer's polynomial
+func NewRefreshJustification(poly *share.PriPoly, kh *VerificationKey) (RefreshJustification, error) {
+	s, err := kyberenc.ScalarToStringHex(P256, poly.Eval(kh.KeyHolderID).V)
+	if err != nil {
+		return RefreshJustification{}, err
+	}
+	return RefreshJustification{KeyHolder: kh.Sender, Share: s}, nil
+}
+
+// JustifiedShare checks the revealed share of the key holder with the given ID against the deal's commitments
+func (deal RefreshDeal) JustifiedShare(j RefreshJustification, id int, t int, n int) (kyber.Scalar, error) {
+	pubPoly, err := deal.PubPoly(t, n)
+	if err != nil {
+		return nil, err
+	}
+	s, err := kyberenc.StringHexToScalar(P256, j.Share)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode share: %v", err)
+	}
+	if !pubPoly.Check(&share.PriShare{I: id, V: s}) {
+		return nil, fmt.Errorf("share doesn't match the commitments")
+	}
+	return s, nil
+}
+
+// RefreshDealJSON is the refresh deal with its dealer for queries
+type RefreshDealJSON struct {
+	Dealer sdk.AccAddress `json:"dealer"`
+	Deal   RefreshDeal    `json:"deal"`
+}
+
+// RefreshComplaintJSON is the key holder's refresh complaint for queries
+type RefreshComplaintJSON struct {
+	Sender  sdk.AccAddress   `json:"sender"`
+	Dealers []sdk.AccAddress `json:"dealers"`
+}
+
+// RefreshJustificationsJSON is the dealer's answer to the complaints for queries
+type RefreshJustificationsJSON struct {
+	Dealer         sdk.AccAddress         `json:"dealer"`
+	Justifications []RefreshJustification `json:"justifications"`
+}
+
+// RefreshState is the state of the last key refresh, its messages are kept until the next refresh is started
+type RefreshState struct {
+	Number         uint64                      `json:"number"`
+	Phase          string                      `json:"phase"`
+	Deals          []RefreshDealJSON           `json:"deals"`
+	Complaints     []RefreshComplaintJSON      `json:"complaints"`
+	Justifications []RefreshJustificationsJSON `json:"justifications"`
+	QUAL           []sdk.AccAddress            `json:"qual"`
+	PendingKeys    []VerificationKeyJSON       `json:"pending_keys"` // refreshed verification keys waiting for the round boundary
+}
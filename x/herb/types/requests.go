@@ -0,0 +1,19 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// RandomnessRequest is the request of the random value, it's served by the round it's bound to
+type RandomnessRequest struct {
+	ID        uint64         `json:"id"`
+	Round     uint64         `json:"round"` // requests of the failed round are moved to the next one
+	Requester sdk.AccAddress `json:"requester"`
+	Fee       sdk.Coins      `json:"fee"` // paid to the rewards pool
+}
+
+func (r RandomnessRequest) String() string {
+	return fmt.Sprintf("request %v by %v: round %v, fee %v", r.ID, r.Requester, r.Round, r.Fee)
+}
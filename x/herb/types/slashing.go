@@ -0,0 +1,23 @@
+package types
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MissCounter tracks key holder's missed rounds in the current window and penalties
+type MissCounter struct {
+	Address       sdk.AccAddress `json:"address"`
+	WindowStart   uint64         `json:"window_start"` // first round of the current window
+	MissedRounds  int64          `json:"missed_rounds"`
+	InvalidShares int64          `json:"invalid_shares"` // number of decryption shares with incorrect DLEQ proof
+	Penalties     int64          `json:"penalties"`
+	// round of the last decryption share with incorrect DLEQ proof, the key holder is punished once per round
+	InvalidShareRound uint64 `json:"invalid_share_round"`
+}
+
+func (c MissCounter) String() string {
+	return fmt.Sprintf("%v: window start: %v, missed rounds: %v, invalid shares: %v, penalties: %v",
+		c.Address, c.WindowStart, c.MissedRounds, c.InvalidShares, c.Penalties)
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+	"github.com/corestario/HERB/x/herb/verifier"
+)
+
+// RoundTranscript contains everything needed to check the round result offline:
+// keys of the round's key epoch, ciphertext shares with CE proofs, decryption shares with DLEQ proofs and the result
+// Use LightTranscript to get the plain format of the verifier package
+type RoundTranscript struct {
+	ChainID              string                  `json:"chain_id"` // chain ID and beacon instance the share proofs are bound to
+	Instance             string                  `json:"instance"`
+	Round                uint64                  `json:"round"`
+	Stage                string                  `json:"stage"`
+	Epoch                uint64                  `json:"epoch"`
+	CommonPublicKey      string                  `json:"common_public_key"`
+	KeyHolders           []VerificationKeyJSON   `json:"key_holders"`
+	ThresholdCiphertexts uint64                  `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64                  `json:"threshold_decryption"`
+	Ciphertexts          []*CiphertextShareJSON  `json:"ciphertexts"`
+	AggregatedCiphertext *elgamal.CiphertextJSON `json:"aggregated_ciphertext"`
+	DecryptionShares     []DecryptionShareJSON   `json:"decryption_shares"`
+	Result               []byte                  `json:"result"`
+}
+
+func (t RoundTranscript) String() string {
+	return fmt.Sprintf("Round: %v\nStage: %v\nEpoch: %v\nCiphertext shares: %v\nDecryption shares: %v\nResult: %x",
+		t.Round, t.Stage, t.Epoch, len(t.Ciphertexts), len(t.DecryptionShares), t.Result)
+}
+
+// LightTranscript converts the transcript to the format of the light verifier
+func (t RoundTranscript) LightTranscript() (*verifier.Transcript, error) {
+	lt := &verifier.Transcript{
+		ChainID:              t.ChainID,
+		Instance:             t.Instance,
+		Round:                t.Round,
+		CommonKey:            t.CommonPublicKey,
+		ThresholdCiphertexts: t.ThresholdCiphertexts,
+		ThresholdDecryption:  t.ThresholdDecryption,
+		KeyHolders:           make([]verifier.KeyHolder, len(t.KeyHolders)),
+		Ciphertexts:          make([]verifier.CiphertextShare, len(t.Ciphertexts)),
+		DecryptionShares:     make([]verifier.DecryptionShare, len(t.DecryptionShares)),
+		Result:               hex.EncodeToString(t.Result),
+	}
+	for i, kh := range t.KeyHolders {
+		lt.KeyHolders[i] = verifier.KeyHolder{ID: kh.KeyHolderID, Address: kh.Sender.String(), VerificationKey: kh.Key}
+	}
+	for i, ct := range t.Ciphertexts {
+		lt.Ciphertexts[i] = verifier.CiphertextShare{
+			EntropyProvider: ct.EntropyProvider.String(),
+			PointA:          ct.Ciphertext.PointA,
+			PointB:          ct.Ciphertext.PointB,
+			CEProof:         ct.CEproof,
+		}
+	}
+	for i, dsJSON := range t.DecryptionShares {
+		ds, err := dsJSON.Deserialize()
+		if err != nil {
+			return nil, fmt.Errorf("can't decode decryption share %v: %v", i, err)
+		}
+		v, err1 := verifier.EncodePoint(ds.DecShare.V)
+		if err1 != nil {
+			return nil, fmt.Errorf("can't encode decryption share %v: %v", i, err1)
+		}
+		proof, err1 := verifier.NewDLEQProof(ds.DLEQproof)
+		if err1 != nil {
+			return nil, fmt.Errorf("can't encode DLEQ proof %v: %v", i, err1)
+		}
+		lt.DecryptionShares[i] = verifier.DecryptionShare{
+			KeyHolder: ds.KeyHolderAddr.String(),
+			Index:     ds.DecShare.I,
+			Share:     v,
+			DLEQProof: proof,
+		}
+	}
+	return lt, nil
+}
+
+// Verify checks the transcript with the light verifier: proofs of all shares, aggregation and decryption of the result
+func (t RoundTranscript) Verify() error {
+	if len(t.Result) == 0 {
+		return fmt.Errorf("round %v has no result, stage: %v", t.Round, t.Stage)
+	}
+	lt, err := t.LightTranscript()
+	if err != nil {
+		return err
+	}
+	return verifier.Verify(lt)
+}
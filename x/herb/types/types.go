@@ -1,9 +1,7 @@
 package types
 
 import (
-	"bytes"
 	"encoding/base64"
-	"encoding/gob"
 	"fmt"
 
 	"go.dedis.ch/kyber/v3"
@@ -18,19 +16,40 @@ import (
 
 var P256 = nist.NewBlakeSHA256P256()
 
-//for genesis state
+// for genesis state, shares and the aggregated ciphertext of the pruned rounds are omitted
 type RoundData struct {
-	CiphertextShares []*CiphertextShareJSON `json:"ciphertext_shares"`
-	DecryptionShares []*DecryptionShareJSON `json:"decryption_shares"`
+	Round                uint64                  `json:"round"`
+	Stage                string                  `json:"stage"`
+	Epoch                uint64                  `json:"epoch"`    // key epoch used by the round
+	ChainID              string                  `json:"chain_id"` // chain ID the round's share proofs are bound to
+	FailReason           string                  `json:"fail_reason"`
+	CiphertextShares     []*CiphertextShareJSON  `json:"ciphertext_shares"`
+	AggregatedCiphertext *elgamal.CiphertextJSON `json:"aggregated_ciphertext"`
+	DecryptionShares     []*DecryptionShareJSON  `json:"decryption_shares"`
+	Result               []byte                  `json:"result"`
+	TranscriptHash       []byte                  `json:"transcript_hash"` // hash of the pruned round's transcript
 }
 
 // GenesisState - herb genesis state
 type GenesisState struct {
-	ThresholdCiphertexts uint64                `json:"threshold_ciphertexts"`
-	ThresholdDecryption  uint64                `json:"threshold_decryption"`
-	CommonPublicKey      string                `json:"common_public_key"`
-	KeyHolders           []VerificationKeyJSON `json:"key_holders"`
-	RoundData            []RoundData           `json:"round_data"`
+	ThresholdCiphertexts uint64                 `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64                 `json:"threshold_decryption"`
+	CommonPublicKey      string                 `json:"common_public_key"`
+	KeyHolders           []VerificationKeyJSON  `json:"key_holders"`
+	CurrentRound         uint64                 `json:"current_round"`
+	PrunedRounds         uint64                 `json:"pruned_rounds"` // shares of the rounds below are pruned
+	KeyEpochs            []KeyEpoch             `json:"key_epochs"`
+	RoundData            []RoundData            `json:"round_data"` // all started rounds in order
+	Params               Params                 `json:"params"`
+	DKGParticipants      []DKGParticipantJSON   `json:"dkg_participants"` // if set, keys are generated on-chain by DKG
+	Requests             []RandomnessRequest    `json:"requests"`
+	EncryptionKeys       []EncryptionKey        `json:"encryption_keys"`              // key holders' encryption keys for the refresh deals
+	MissCounters         []MissCounter          `json:"miss_counters"`                // miss counters of the current and former key holders
+	EntropyProviders     []EntropyProvider      `json:"entropy_providers"`            // registered entropy providers, their bonds are kept in the bond pool account
+	PendingKeyEpoch      *KeyEpoch              `json:"pending_key_epoch,omitempty"`  // keys of the new key holders set waiting for the round boundary
+	KeyHoldersChange     *KeyHoldersChange      `json:"key_holders_change,omitempty"` // key holders change in progress, its DKG is started from scratch on import
+	Refresh              *RefreshState          `json:"refresh,omitempty"`            // the last key refresh with its messages
+	Instances            []InstanceGenesisState `json:"instances"`                    // beacon instances besides the default one, the default instance state is above
 }
 
 type VerificationKey struct {
@@ -114,20 +133,19 @@ type DecryptionShareJSON struct {
 	KeyHolderAddr sdk.AccAddress `json:"key_holder"`
 }
 
+// NewDecryptionShareJSON encodes the decryption share and DLEQ proof as base64 of the canonical binary encoding
 func NewDecryptionShareJSON(decShares *DecryptionShare) (DecryptionShareJSON, sdk.Error) {
-	dsBuf := bytes.NewBuffer(nil)
-	dsEnc := gob.NewEncoder(dsBuf)
-	if err := dsEnc.Encode(decShares.DecShare); err != nil {
+	dsBytes, err := EncodeDecShare(&decShares.DecShare)
+	if err != nil {
 		return DecryptionShareJSON{}, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode decryption shares: %v", err))
 	}
-	dleqBuf := bytes.NewBuffer(nil)
-	dleqEnc := gob.NewEncoder(dleqBuf)
-	if err := dleqEnc.Encode(decShares.DLEQproof); err != nil {
+	dleqBytes, err := EncodeDLEQProof(decShares.DLEQproof)
+	if err != nil {
 		return DecryptionShareJSON{}, sdk.ErrUnknownRequest(fmt.Sprintf("failed to encode dleq proof: %v", err))
 	}
 	return DecryptionShareJSON{
-		DecShare:      base64.StdEncoding.EncodeToString(dsBuf.Bytes()),
-		DLEQproof:     base64.StdEncoding.EncodeToString(dleqBuf.Bytes()),
+		DecShare:      base64.StdEncoding.EncodeToString(dsBytes),
+		DLEQproof:     base64.StdEncoding.EncodeToString(dleqBytes),
 		KeyHolderAddr: decShares.KeyHolderAddr,
 	}, nil
 }
@@ -136,24 +154,22 @@ func (dsJSON DecryptionShareJSON) Deserialize() (*DecryptionShare, sdk.Error) {
 	if err != nil {
 		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to base64-decode decryption shares: %v", err))
 	}
-	dsDec := gob.NewDecoder(bytes.NewBuffer(dsBytes))
-	decshare := share.PubShare{I: 0, V: P256.Point().Base()}
-	if err := dsDec.Decode(&decshare); err != nil {
-		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode decryption share : %v", err))
+	decshare, err1 := DecodeDecShare(dsBytes)
+	if err1 != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode decryption share : %v", err1))
 	}
 
 	dleqBytes, err := base64.StdEncoding.DecodeString(dsJSON.DLEQproof)
 	if err != nil {
 		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to base64-decode DLEQ proof: %v", err))
 	}
-	dleqDec := gob.NewDecoder(bytes.NewBuffer(dleqBytes))
-	dleqproof := dleq.Proof{C: P256.Scalar().Zero(), R: P256.Scalar().Zero(), VG: P256.Point().Base(), VH: P256.Point().Base()}
-	if err := dleqDec.Decode(&dleqproof); err != nil {
-		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode DLEQ proof : %v", err))
+	dleqproof, err1 := DecodeDLEQProof(dleqBytes)
+	if err1 != nil {
+		return nil, sdk.ErrUnknownRequest(fmt.Sprintf("failed to decode DLEQ proof : %v", err1))
 	}
 	return &DecryptionShare{
-		DecShare:      decshare,
-		DLEQproof:     &dleqproof,
+		DecShare:      *decshare,
+		DLEQproof:     dleqproof,
 		KeyHolderAddr: dsJSON.KeyHolderAddr,
 	}, nil
 }
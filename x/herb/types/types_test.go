@@ -1,6 +1,8 @@
 package types
 
 import (
+	"bytes"
+	"fmt"
 	"testing"
 
 	"github.com/corestario/HERB/x/herb/elgamal"
@@ -52,7 +54,7 @@ func TestDecryptionSharesSerialization_Positive(t *testing.T) {
 	g2 := suite.Point().Mul(x, g1)
 	userPk1 := ed25519.GenPrivKey().PubKey()
 	userAddr1 := sdk.AccAddress(userPk1.Address())
-	dleqProof, _, _, err := elgamal.DLEQ(suite, g1, g2, x)
+	dleqProof, _, _, err := elgamal.DLEQ(suite, nil, g1, g2, x)
 	if err != nil {
 		t.Errorf("can't create dleq proof")
 	}
@@ -87,3 +89,151 @@ func TestDecryptionSharesSerialization_Positive(t *testing.T) {
 		t.Errorf("dleq proofs are not equal")
 	}
 }
+
+func TestBinaryEncoding_RoundTrip(t *testing.T) {
+	commonKey := P256.Point().Pick(P256.RandomStream())
+	ct, ceProof, err := elgamal.RandomCiphertext(P256, nil, commonKey)
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	userAddr := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+	ctShare := CiphertextShare{ct, ceProof, userAddr}
+	ctBytes, err1 := EncodeCiphertextShare(&ctShare)
+	if err1 != nil {
+		t.Fatalf("can't encode ciphertext share: %v", err1)
+	}
+	if len(ctBytes) != 324 {
+		t.Errorf("wrong ciphertext share size: %v", len(ctBytes))
+	}
+	newCtShare, err1 := DecodeCiphertextShare(ctBytes, userAddr)
+	if err1 != nil {
+		t.Fatalf("can't decode ciphertext share: %v", err1)
+	}
+	if !newCtShare.Ciphertext.Equal(ct) || !bytes.Equal(newCtShare.CEproof, ceProof) || !newCtShare.EntropyProvider.Equals(userAddr) {
+		t.Errorf("ciphertext shares are not equal")
+	}
+	if reencoded, _ := EncodeCiphertextShare(newCtShare); !bytes.Equal(reencoded, ctBytes) {
+		t.Errorf("ciphertext share encoding isn't canonical")
+	}
+
+	x := P256.Scalar().Pick(P256.RandomStream())
+	decShare, dleqProof, err := elgamal.CreateDecShare(P256, nil, ct, x)
+	if err != nil {
+		t.Fatalf("can't create decryption share: %v", err)
+	}
+	ds := DecryptionShare{share.PubShare{I: 7, V: decShare}, dleqProof, userAddr}
+	dsBytes, err1 := EncodeDecryptionShare(&ds)
+	if err1 != nil {
+		t.Fatalf("can't encode decryption share: %v", err1)
+	}
+	if len(dsBytes) != 263 {
+		t.Errorf("wrong decryption share size: %v", len(dsBytes))
+	}
+	newDs, err1 := DecodeDecryptionShare(dsBytes, userAddr)
+	if err1 != nil {
+		t.Fatalf("can't decode decryption share: %v", err1)
+	}
+	if newDs.DecShare.I != 7 || !newDs.DecShare.V.Equal(decShare) {
+		t.Errorf("decryption shares are not equal")
+	}
+	if err := elgamal.DLEQVerify(P256, nil, newDs.DLEQproof, P256.Point().Base(), ct.PointA, P256.Point().Mul(x, nil), newDs.DecShare.V); err != nil {
+		t.Errorf("decoded DLEQ proof isn't verified: %v", err)
+	}
+}
+
+func TestBinaryEncoding_Negative(t *testing.T) {
+	commonKey := P256.Point().Pick(P256.RandomStream())
+	ct, ceProof, err := elgamal.RandomCiphertext(P256, nil, commonKey)
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	ctBytes, err1 := EncodeCiphertext(&ct)
+	if err1 != nil {
+		t.Fatalf("can't encode ciphertext: %v", err1)
+	}
+	sender := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+	if err := NewMsgSetCiphertextShare(DefaultInstance, 0, ctBytes, ceProof, sender).ValidateBasic(); err != nil {
+		t.Errorf("valid message is rejected: %v", err)
+	}
+	if err := NewMsgSetCiphertextShare(DefaultInstance, 0, ctBytes[1:], ceProof, sender).ValidateBasic(); err == nil {
+		t.Errorf("truncated ciphertext is accepted")
+	}
+	if err := NewMsgSetCiphertextShare(DefaultInstance, 0, ctBytes, append(ceProof, 0), sender).ValidateBasic(); err == nil {
+		t.Errorf("CE proof with trailing bytes is accepted")
+	}
+	offCurve := make([]byte, len(ctBytes))
+	copy(offCurve, ctBytes)
+	offCurve[PointLen-1] ^= 1
+	if _, err := DecodeCiphertext(offCurve); err == nil {
+		t.Errorf("point out of the curve is accepted")
+	}
+
+	proof := make([]byte, DLEQProofLen)
+	for i := 0; i < ScalarLen; i++ {
+		proof[i] = 0xff // not less than the group order
+	}
+	if _, err := DecodeDLEQProof(proof); err == nil {
+		t.Errorf("non-canonical scalar is accepted")
+	}
+	if _, err := EncodeDecShare(&share.PubShare{I: -1, V: ct.PointA}); err == nil {
+		t.Errorf("negative share index is accepted")
+	}
+}
+
+func TestMsgRound_Required(t *testing.T) {
+	commonKey := P256.Point().Pick(P256.RandomStream())
+	ct, ceProof, err := elgamal.RandomCiphertext(P256, nil, commonKey)
+	if err != nil {
+		t.Fatalf("can't create ciphertext: %v", err)
+	}
+	ctBytes, err1 := EncodeCiphertext(&ct)
+	if err1 != nil {
+		t.Fatalf("can't encode ciphertext: %v", err1)
+	}
+	sender := sdk.AccAddress(ed25519.GenPrivKey().PubKey().Address())
+
+	// round 0 survives the binary and JSON encodings of the transaction
+	var decoded, decodedJSON MsgSetCiphertextShare
+	msg := NewMsgSetCiphertextShare(DefaultInstance, 0, ctBytes, ceProof, sender)
+	ModuleCdc.MustUnmarshalBinaryBare(ModuleCdc.MustMarshalBinaryBare(msg), &decoded)
+	ModuleCdc.MustUnmarshalJSON(ModuleCdc.MustMarshalJSON(msg), &decodedJSON)
+	for _, decoded := range []MsgSetCiphertextShare{decoded, decodedJSON} {
+		if err := decoded.ValidateBasic(); err != nil || decoded.Round != 0 {
+			t.Errorf("round 0 isn't kept by the encoding: %v, %v", decoded.Round, err)
+		}
+	}
+
+	// the message without the round is rejected
+	msg.HasRound = false
+	ModuleCdc.MustUnmarshalBinaryBare(ModuleCdc.MustMarshalBinaryBare(msg), &decoded)
+	if err := decoded.ValidateBasic(); err == nil || err.Code() != CodeWrongRound {
+		t.Errorf("ciphertext share without round isn't rejected: %v", err)
+	}
+	var dsMsg MsgSetDecryptionShare
+	ModuleCdc.MustUnmarshalJSON([]byte(fmt.Sprintf(`{"type":"herb/MsgSetDecryptionShare","value":{"round":"1","sender":"%s"}}`, sender)), &dsMsg)
+	if err := dsMsg.ValidateBasic(); err == nil || err.Code() != CodeWrongRound {
+		t.Errorf("decryption share without round isn't rejected: %v", err)
+	}
+}
+
+func TestDeriveRandomness_DomainSeparation(t *testing.T) {
+	result := []byte("round result")
+	derived := [][]byte{
+		DeriveRandomness(result, "lottery", "draw", 1),
+		DeriveRandomness(result, "lottery", "draw", 2),
+		DeriveRandomness(result, "lottery", "shuffle", 1),
+		DeriveRandomness(result, "auction", "draw", 1),
+		DeriveRandomness(result, "lotterydraw", "", 1),
+		DeriveRandomness(result, "", "lotterydraw", 1),
+	}
+	for i := range derived {
+		for j := i + 1; j < len(derived); j++ {
+			if bytes.Equal(derived[i], derived[j]) {
+				t.Errorf("same randomness for the different domains %v and %v", i, j)
+			}
+		}
+	}
+	if !bytes.Equal(derived[0], DeriveRandomness(result, "lottery", "draw", 1)) {
+		t.Errorf("randomness derivation isn't deterministic")
+	}
+}
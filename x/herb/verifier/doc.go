@@ -0,0 +1,36 @@
+/*
+Package verifier checks HERB round results without the Cosmos SDK, it depends only on kyber.
+
+The transcript is a plain JSON document, points are hex encoded P-256 points (kyber encoding),
+scalars are hex encoded big-endian scalars, byte arrays are base64 encoded:
+
+	{
+	  "chain_id": "<chain ID>",
+	  "instance": "<beacon instance ID, empty for the default instance>",
+	  "round": 5,
+	  "common_key": "<point>",
+	  "threshold_ciphertexts": 2,
+	  "threshold_decryption": 2,
+	  "key_holders": [
+	    {"id": 0, "address": "<key holder address>", "verification_key": "<point>"}
+	  ],
+	  "ciphertexts": [
+	    {"entropy_provider": "<address>", "point_a": "<point>", "point_b": "<point>", "ce_proof": "<base64>"}
+	  ],
+	  "decryption_shares": [
+	    {"key_holder": "<address>", "index": 0, "share": "<point>",
+	     "dleq_proof": {"c": "<scalar>", "r": "<scalar>", "vg": "<point>", "vh": "<point>"}}
+	  ],
+	  "result": "<hex encoded 32 bytes>"
+	}
+
+Addresses are opaque strings, they identify shares in the reports and bind decryption shares to the key holders.
+Key holder id is the index of the key holder's share in the threshold scheme.
+Proofs are bound to the chain ID, beacon instance, round and sender (see elgamal.ProofContext),
+so a share copied from another sender, round or chain fails the verification.
+
+Verify rejects duplicate ciphertexts, checks CE proof of each ciphertext share and DLEQ proof of each decryption share against the aggregated ciphertext,
+then decrypts the aggregated ciphertext and compares SHA-256 of the decrypted point with the result.
+Each incorrect share is reported with its position, sender and the reason.
+*/
+package verifier
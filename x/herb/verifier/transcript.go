@@ -0,0 +1,127 @@
+package verifier
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/proof/dleq"
+	kyberenc "go.dedis.ch/kyber/v3/util/encoding"
+)
+
+// Transcript is the round data needed for the verification
+type Transcript struct {
+	ChainID              string            `json:"chain_id"` // chain ID and beacon instance the share proofs are bound to
+	Instance             string            `json:"instance"`
+	Round                uint64            `json:"round"`
+	CommonKey            string            `json:"common_key"`
+	ThresholdCiphertexts uint64            `json:"threshold_ciphertexts"`
+	ThresholdDecryption  uint64            `json:"threshold_decryption"`
+	KeyHolders           []KeyHolder       `json:"key_holders"`
+	Ciphertexts          []CiphertextShare `json:"ciphertexts"`
+	DecryptionShares     []DecryptionShare `json:"decryption_shares"`
+	Result               string            `json:"result"`
+}
+
+// KeyHolder is the key holder's verification key
+type KeyHolder struct {
+	ID              int    `json:"id"`
+	Address         string `json:"address"`
+	VerificationKey string `json:"verification_key"`
+}
+
+// CiphertextShare is the entropy provider's ElGamal ciphertext with CE proof
+type CiphertextShare struct {
+	EntropyProvider string `json:"entropy_provider"`
+	PointA          string `json:"point_a"`
+	PointB          string `json:"point_b"`
+	CEProof         []byte `json:"ce_proof"`
+}
+
+// DecryptionShare is the key holder's decryption share with DLEQ proof
+type DecryptionShare struct {
+	KeyHolder string    `json:"key_holder"`
+	Index     int       `json:"index"`
+	Share     string    `json:"share"`
+	DLEQProof DLEQProof `json:"dleq_proof"`
+}
+
+// DLEQProof is the proof that the decryption share and the verification key have the same discrete logarithm
+type DLEQProof struct {
+	C  string `json:"c"`
+	R  string `json:"r"`
+	VG string `json:"vg"`
+	VH string `json:"vh"`
+}
+
+// EncodePoint returns the transcript encoding of the point
+func EncodePoint(p kyber.Point) (string, error) {
+	return kyberenc.PointToStringHex(Suite, p)
+}
+
+// EncodeScalar returns the transcript encoding of the scalar
+func EncodeScalar(s kyber.Scalar) (string, error) {
+	return kyberenc.ScalarToStringHex(Suite, s)
+}
+
+// NewDLEQProof returns the transcript encoding of the DLEQ proof
+func NewDLEQProof(proof *dleq.Proof) (DLEQProof, error) {
+	if proof == nil {
+		return DLEQProof{}, fmt.Errorf("empty DLEQ proof")
+	}
+	var res DLEQProof
+	var err error
+	if res.C, err = EncodeScalar(proof.C); err != nil {
+		return DLEQProof{}, err
+	}
+	if res.R, err = EncodeScalar(proof.R); err != nil {
+		return DLEQProof{}, err
+	}
+	if res.VG, err = EncodePoint(proof.VG); err != nil {
+		return DLEQProof{}, err
+	}
+	if res.VH, err = EncodePoint(proof.VH); err != nil {
+		return DLEQProof{}, err
+	}
+	return res, nil
+}
+
+func decodePoint(str string) (kyber.Point, error) {
+	return kyberenc.StringHexToPoint(Suite, str)
+}
+
+func (p DLEQProof) decode() (*dleq.Proof, error) {
+	c, err := kyberenc.StringHexToScalar(Suite, p.C)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode c: %v", err)
+	}
+	r, err := kyberenc.StringHexToScalar(Suite, p.R)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode r: %v", err)
+	}
+	vg, err := decodePoint(p.VG)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode vg: %v", err)
+	}
+	vh, err := decodePoint(p.VH)
+	if err != nil {
+		return nil, fmt.Errorf("can't decode vh: %v", err)
+	}
+	return &dleq.Proof{C: c, R: r, VG: vg, VH: vh}, nil
+}
+
+func decodeResult(str string) ([]byte, error) {
+	return hex.DecodeString(str)
+}
+
+// Hash returns SHA-256 of the transcript JSON encoding, it's kept on chain for the pruned rounds
+func (t *Transcript) Hash() ([]byte, error) {
+	bz, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(bz)
+	return hash[:], nil
+}
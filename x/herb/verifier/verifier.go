@@ -0,0 +1,174 @@
+package verifier
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/group/nist"
+	"go.dedis.ch/kyber/v3/share"
+)
+
+// Suite is the group used by the HERB
+var Suite = nist.NewBlakeSHA256P256()
+
+// share types of the failure report
+const (
+	ShareTypeCiphertext = "ciphertext"
+	ShareTypeDecryption = "decryption"
+)
+
+// ShareError describes the incorrect share
+type ShareError struct {
+	Type   string // ciphertext or decryption
+	Index  int    // position of the share in the transcript
+	Sender string
+	Reason string
+}
+
+func (e ShareError) Error() string {
+	return fmt.Sprintf("%s share %d from %s: %s", e.Type, e.Index, e.Sender, e.Reason)
+}
+
+// SharesError contains all incorrect shares of the transcript
+type SharesError []ShareError
+
+func (e SharesError) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Verify checks the transcript and returns nil if the result is correct
+// Incorrect shares are reported with SharesError
+func Verify(t *Transcript) error {
+	result, err := decodeResult(t.Result)
+	if err != nil {
+		return fmt.Errorf("can't decode result: %v", err)
+	}
+	if len(result) == 0 {
+		return fmt.Errorf("round %d has no result", t.Round)
+	}
+	commonKey, err := decodePoint(t.CommonKey)
+	if err != nil {
+		return fmt.Errorf("can't decode common key: %v", err)
+	}
+	keyHolders := make(map[string]KeyHolder, len(t.KeyHolders))
+	vks := make(map[string]kyber.Point, len(t.KeyHolders))
+	for _, kh := range t.KeyHolders {
+		if _, ok := keyHolders[kh.Address]; ok {
+			return fmt.Errorf("key holder %s is listed twice", kh.Address)
+		}
+		vk, err := decodePoint(kh.VerificationKey)
+		if err != nil {
+			return fmt.Errorf("can't decode verification key of %s: %v", kh.Address, err)
+		}
+		keyHolders[kh.Address] = kh
+		vks[kh.Address] = vk
+	}
+
+	var failures SharesError
+	ciphertexts := make([]elgamal.Ciphertext, 0, len(t.Ciphertexts))
+	providers := make(map[string]bool, len(t.Ciphertexts))
+	accepted := make(map[string]bool, len(t.Ciphertexts))
+	for i, ct := range t.Ciphertexts {
+		fail := func(reason string) {
+			failures = append(failures, ShareError{Type: ShareTypeCiphertext, Index: i, Sender: ct.EntropyProvider, Reason: reason})
+		}
+		if providers[ct.EntropyProvider] {
+			fail("duplicate share of the entropy provider")
+			continue
+		}
+		providers[ct.EntropyProvider] = true
+		a, err := decodePoint(ct.PointA)
+		if err != nil {
+			fail(fmt.Sprintf("can't decode point a: %v", err))
+			continue
+		}
+		b, err := decodePoint(ct.PointB)
+		if err != nil {
+			fail(fmt.Sprintf("can't decode point b: %v", err))
+			continue
+		}
+		if accepted[a.String()+b.String()] {
+			fail("duplicate ciphertext")
+			continue
+		}
+		accepted[a.String()+b.String()] = true
+		context := elgamal.ProofContext(elgamal.ContextCE, t.ChainID, t.Instance, t.Round, ct.EntropyProvider)
+		if err := elgamal.CEVerify(Suite, context, Suite.Point().Base(), commonKey, a, b, ct.CEProof); err != nil {
+			fail(fmt.Sprintf("CE proof isn't correct: %v", err))
+			continue
+		}
+		ciphertexts = append(ciphertexts, elgamal.Ciphertext{PointA: a, PointB: b})
+	}
+	if failures != nil {
+		return failures
+	}
+	if uint64(len(ciphertexts)) < t.ThresholdCiphertexts {
+		return fmt.Errorf("not enough ciphertext shares: %d, threshold: %d", len(ciphertexts), t.ThresholdCiphertexts)
+	}
+	aggCt := elgamal.AggregateCiphertext(Suite, ciphertexts)
+
+	decShares := make([]*share.PubShare, 0, len(t.DecryptionShares))
+	senders := make(map[string]bool, len(t.DecryptionShares))
+	for i, ds := range t.DecryptionShares {
+		fail := func(reason string) {
+			failures = append(failures, ShareError{Type: ShareTypeDecryption, Index: i, Sender: ds.KeyHolder, Reason: reason})
+		}
+		kh, ok := keyHolders[ds.KeyHolder]
+		if !ok {
+			fail("sender isn't a key holder")
+			continue
+		}
+		if senders[ds.KeyHolder] {
+			fail("duplicate share of the key holder")
+			continue
+		}
+		senders[ds.KeyHolder] = true
+		if ds.Index != kh.ID {
+			fail(fmt.Sprintf("share index %d doesn't match key holder id %d", ds.Index, kh.ID))
+			continue
+		}
+		v, err := decodePoint(ds.Share)
+		if err != nil {
+			fail(fmt.Sprintf("can't decode share: %v", err))
+			continue
+		}
+		proof, err := ds.DLEQProof.decode()
+		if err != nil {
+			fail(fmt.Sprintf("can't decode DLEQ proof: %v", err))
+			continue
+		}
+		context := elgamal.ProofContext(elgamal.ContextDLEQ, t.ChainID, t.Instance, t.Round, ds.KeyHolder)
+		if err := elgamal.DLEQVerify(Suite, context, proof, Suite.Point().Base(), aggCt.PointA, vks[ds.KeyHolder], v); err != nil {
+			fail(fmt.Sprintf("DLEQ proof isn't correct: %v", err))
+			continue
+		}
+		decShares = append(decShares, &share.PubShare{I: ds.Index, V: v})
+	}
+	if failures != nil {
+		return failures
+	}
+	if uint64(len(decShares)) < t.ThresholdDecryption {
+		return fmt.Errorf("not enough decryption shares: %d, threshold: %d", len(decShares), t.ThresholdDecryption)
+	}
+
+	resultPoint, err := elgamal.Decrypt(Suite, aggCt, decShares, int(t.ThresholdDecryption), len(t.KeyHolders))
+	if err != nil {
+		return fmt.Errorf("can't decrypt the aggregated ciphertext: %v", err)
+	}
+	hash := Suite.Hash()
+	if _, err := resultPoint.MarshalTo(hash); err != nil {
+		return fmt.Errorf("can't hash decrypted point: %v", err)
+	}
+	if !bytes.Equal(hash.Sum(nil), result) {
+		return fmt.Errorf("decrypted result %x doesn't match the round result %x", hash.Sum(nil), result)
+	}
+	return nil
+}
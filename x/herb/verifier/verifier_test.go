@@ -0,0 +1,174 @@
+package verifier
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/corestario/HERB/x/herb/elgamal"
+
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/share"
+	"go.dedis.ch/kyber/v3/util/random"
+)
+
+// createTranscript runs the round with n key holders, threshold t and ct entropy providers
+func createTranscript(t *testing.T, n int, trh int, ctNum int) *Transcript {
+	priPoly := share.NewPriPoly(Suite, trh, nil, random.New())
+	commonKey := priPoly.Commit(nil).Commit()
+	priShares := priPoly.Shares(n)
+	tr := &Transcript{ChainID: "test-chain", Round: 1, ThresholdCiphertexts: uint64(ctNum), ThresholdDecryption: uint64(trh)}
+	tr.CommonKey = mustEncodePoint(t, commonKey)
+	for i := 0; i < n; i++ {
+		tr.KeyHolders = append(tr.KeyHolders, KeyHolder{
+			ID:              priShares[i].I,
+			Address:         fmt.Sprintf("holder%d", i),
+			VerificationKey: mustEncodePoint(t, Suite.Point().Mul(priShares[i].V, nil)),
+		})
+	}
+
+	var cts []elgamal.Ciphertext
+	for i := 0; i < ctNum; i++ {
+		context := elgamal.ProofContext(elgamal.ContextCE, tr.ChainID, tr.Instance, tr.Round, fmt.Sprintf("provider%d", i))
+		ct, ceProof, err := elgamal.RandomCiphertext(Suite, context, commonKey)
+		if err != nil {
+			t.Fatalf("can't create ciphertext: %v", err)
+		}
+		cts = append(cts, ct)
+		tr.Ciphertexts = append(tr.Ciphertexts, CiphertextShare{
+			EntropyProvider: fmt.Sprintf("provider%d", i),
+			PointA:          mustEncodePoint(t, ct.PointA),
+			PointB:          mustEncodePoint(t, ct.PointB),
+			CEProof:         ceProof,
+		})
+	}
+	aggCt := elgamal.AggregateCiphertext(Suite, cts)
+
+	var pubShares []*share.PubShare
+	for i := 0; i < trh; i++ {
+		context := elgamal.ProofContext(elgamal.ContextDLEQ, tr.ChainID, tr.Instance, tr.Round, tr.KeyHolders[i].Address)
+		ds, proof, err := elgamal.CreateDecShare(Suite, context, aggCt, priShares[i].V)
+		if err != nil {
+			t.Fatalf("can't create decryption share: %v", err)
+		}
+		pubShares = append(pubShares, &share.PubShare{I: priShares[i].I, V: ds})
+		proofJSON, err := NewDLEQProof(proof)
+		if err != nil {
+			t.Fatalf("can't encode DLEQ proof: %v", err)
+		}
+		tr.DecryptionShares = append(tr.DecryptionShares, DecryptionShare{
+			KeyHolder: tr.KeyHolders[i].Address,
+			Index:     priShares[i].I,
+			Share:     mustEncodePoint(t, ds),
+			DLEQProof: proofJSON,
+		})
+	}
+	hash := Suite.Hash()
+	resultPoint, err := elgamal.Decrypt(Suite, aggCt, pubShares, trh, n)
+	if err != nil {
+		t.Fatalf("can't decrypt: %v", err)
+	}
+	if _, err := resultPoint.MarshalTo(hash); err != nil {
+		t.Fatalf("can't hash result: %v", err)
+	}
+	tr.Result = hex.EncodeToString(hash.Sum(nil))
+	return tr
+}
+
+func mustEncodePoint(t *testing.T, p kyber.Point) string {
+	str, err := EncodePoint(p)
+	if err != nil {
+		t.Fatalf("can't encode point: %v", err)
+	}
+	return str
+}
+
+func TestVerify_Positive(t *testing.T) {
+	tr := createTranscript(t, 5, 3, 4)
+	bz, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatalf("can't marshal transcript: %v", err)
+	}
+	var decoded Transcript
+	if err := json.Unmarshal(bz, &decoded); err != nil {
+		t.Fatalf("can't unmarshal transcript: %v", err)
+	}
+	if err := Verify(&decoded); err != nil {
+		t.Errorf("correct transcript isn't verified: %v", err)
+	}
+}
+
+func TestVerify_ReportsShare(t *testing.T) {
+	testCases := []struct {
+		name      string
+		tamper    func(tr *Transcript)
+		shareType string
+		index     int
+	}{
+		{"wrong CE proof", func(tr *Transcript) {
+			tr.Ciphertexts[2].CEProof = tr.Ciphertexts[1].CEProof
+		}, ShareTypeCiphertext, 2},
+		{"duplicate provider", func(tr *Transcript) {
+			tr.Ciphertexts[3].EntropyProvider = tr.Ciphertexts[0].EntropyProvider
+		}, ShareTypeCiphertext, 3},
+		{"share of another provider", func(tr *Transcript) {
+			tr.Ciphertexts[2].EntropyProvider = "provider9"
+		}, ShareTypeCiphertext, 2},
+		{"duplicate ciphertext", func(tr *Transcript) {
+			tr.Ciphertexts[3].PointA = tr.Ciphertexts[1].PointA
+			tr.Ciphertexts[3].PointB = tr.Ciphertexts[1].PointB
+			tr.Ciphertexts[3].CEProof = tr.Ciphertexts[1].CEProof
+		}, ShareTypeCiphertext, 3},
+		{"wrong decryption share", func(tr *Transcript) {
+			tr.DecryptionShares[1].Share = tr.DecryptionShares[0].Share
+		}, ShareTypeDecryption, 1},
+		{"wrong index", func(tr *Transcript) {
+			tr.DecryptionShares[2].Index = 4
+		}, ShareTypeDecryption, 2},
+		{"unknown key holder", func(tr *Transcript) {
+			tr.DecryptionShares[0].KeyHolder = "stranger"
+		}, ShareTypeDecryption, 0},
+	}
+	for _, tc := range testCases {
+		tr := createTranscript(t, 5, 3, 4)
+		tc.tamper(tr)
+		err := Verify(tr)
+		failures, ok := err.(SharesError)
+		if !ok {
+			t.Errorf("%s: shares aren't reported: %v", tc.name, err)
+			continue
+		}
+		if len(failures) != 1 || failures[0].Type != tc.shareType || failures[0].Index != tc.index {
+			t.Errorf("%s: wrong report: %v", tc.name, failures)
+		}
+	}
+}
+
+func TestVerify_WrongResult(t *testing.T) {
+	tr := createTranscript(t, 3, 2, 2)
+	tr.Result = hex.EncodeToString(make([]byte, 32))
+	if err := Verify(tr); err == nil {
+		t.Errorf("wrong result is verified")
+	}
+	tr = createTranscript(t, 3, 2, 2)
+	tr.DecryptionShares = tr.DecryptionShares[:1]
+	if err := Verify(tr); err == nil {
+		t.Errorf("transcript with not enough decryption shares is verified")
+	}
+}
+
+func TestVerify_WrongContext(t *testing.T) {
+	for _, tamper := range []func(tr *Transcript){
+		func(tr *Transcript) { tr.ChainID = "other-chain" },
+		func(tr *Transcript) { tr.Instance = "other" },
+		func(tr *Transcript) { tr.Round++ },
+	} {
+		tr := createTranscript(t, 3, 2, 2)
+		tamper(tr)
+		failures, ok := Verify(tr).(SharesError)
+		if !ok || len(failures) != len(tr.Ciphertexts) {
+			t.Errorf("shares of another context aren't reported: %v", failures)
+		}
+	}
+}